@@ -0,0 +1,328 @@
+package yiigo
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AlertChannel indicates the webhook robot which alerts are sent to.
+type AlertChannel int
+
+const (
+	AlertDingTalk AlertChannel = 1 // DingTalk group robot
+	AlertWeCom    AlertChannel = 2 // WeCom (WeChat Work) group robot
+	AlertSlack    AlertChannel = 3 // Slack incoming webhook
+)
+
+// defaultAlertTemplate the default template of alert content.
+const defaultAlertTemplate = `[{{.Level}}] {{.Message}}
+time: {{.Time}}{{if .Caller}}
+caller: {{.Caller}}{{end}}{{range $k, $v := .Fields}}
+{{$k}}: {{$v}}{{end}}{{if .Suppressed}}
+(suppressed {{.Suppressed}} times){{end}}{{if .Stack}}
+stack:
+{{.Stack}}{{end}}`
+
+// LogAlert the data which alert template is executed with.
+type LogAlert struct {
+	Level      string
+	Time       string
+	Message    string
+	Caller     string
+	Stack      string
+	Fields     map[string]interface{}
+	Suppressed int // the number of the same alerts suppressed by rate limit since last sent
+}
+
+// alertOptions alert options
+type alertOptions struct {
+	secret   string
+	level    zapcore.Level
+	interval time.Duration
+	tmpl     *template.Template
+}
+
+// AlertOption configures how we set up the log alert
+type AlertOption interface {
+	apply(options *alertOptions)
+}
+
+// funcAlertOption implements alert option
+type funcAlertOption struct {
+	f func(options *alertOptions)
+}
+
+func (fo *funcAlertOption) apply(o *alertOptions) {
+	fo.f(o)
+}
+
+func newFuncAlertOption(f func(options *alertOptions)) *funcAlertOption {
+	return &funcAlertOption{f: f}
+}
+
+// WithAlertSecret specifies the sign secret of DingTalk robot.
+func WithAlertSecret(s string) AlertOption {
+	return newFuncAlertOption(func(o *alertOptions) {
+		o.secret = s
+	})
+}
+
+// WithAlertLevel specifies the minimum level of logs to alert, eg: zapcore.FatalLevel to alert fatal logs only.
+func WithAlertLevel(l zapcore.Level) AlertOption {
+	return newFuncAlertOption(func(o *alertOptions) {
+		o.level = l
+	})
+}
+
+// WithAlertInterval specifies the minimum interval between the alerts of the same level and message,
+// the alerts within the interval are suppressed and counted.
+func WithAlertInterval(d time.Duration) AlertOption {
+	return newFuncAlertOption(func(o *alertOptions) {
+		o.interval = d
+	})
+}
+
+// WithAlertTemplate specifies the `text/template` of alert content, which is executed with *LogAlert.
+// An invalid template panics.
+func WithAlertTemplate(s string) AlertOption {
+	return newFuncAlertOption(func(o *alertOptions) {
+		o.tmpl = template.Must(template.New("alert").Parse(s))
+	})
+}
+
+// WithLogAlert specifies a webhook robot to alert the error logs (including recovered panics logged as error) and fatal logs.
+// The alerts are sent asynchronously, except panic and fatal ones which are sent before the process goes down.
+//
+// The default `Level` is zapcore.ErrorLevel.
+// The default `Interval` is 1 minute.
+//
+// The options can be loaded from the config file, eg:
+//
+//	yiigo.WithLogAlert(yiigo.AlertDingTalk, yiigo.Env.String("alert.webhook"),
+//	    yiigo.WithAlertSecret(yiigo.Env.String("alert.secret")),
+//	    yiigo.WithAlertInterval(time.Duration(yiigo.Env.Int("alert.interval", 60))*time.Second),
+//	)
+func WithLogAlert(channel AlertChannel, webhook string, options ...AlertOption) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		o.alerts = append(o.alerts, newAlertCore(channel, webhook, options...))
+	})
+}
+
+// alertLimiter suppresses the same alerts within the interval.
+type alertLimiter struct {
+	interval time.Duration
+	entries  map[string]*alertLimit
+	mutex    sync.Mutex
+}
+
+type alertLimit struct {
+	last       time.Time
+	suppressed int
+}
+
+// allow reports whether the alert of key can be sent now, and returns the number of the alerts suppressed before.
+func (l *alertLimiter) allow(key string, now time.Time) (bool, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	e, ok := l.entries[key]
+
+	if !ok {
+		// drop the expired entries, so the map never grows with distinct messages
+		for k, v := range l.entries {
+			if now.Sub(v.last) >= l.interval && v.suppressed == 0 {
+				delete(l.entries, k)
+			}
+		}
+
+		l.entries[key] = &alertLimit{last: now}
+
+		return true, 0
+	}
+
+	if now.Sub(e.last) < l.interval {
+		e.suppressed++
+
+		return false, 0
+	}
+
+	suppressed := e.suppressed
+
+	e.last = now
+	e.suppressed = 0
+
+	return true, suppressed
+}
+
+// alertCore a zapcore.Core which sends the entries to a webhook robot.
+type alertCore struct {
+	zapcore.LevelEnabler
+	channel  AlertChannel
+	webhook  string
+	options  *alertOptions
+	limiter  *alertLimiter
+	fields   []zapcore.Field
+	queue    chan string
+	notifier *Notifier
+}
+
+func newAlertCore(channel AlertChannel, webhook string, options ...AlertOption) *alertCore {
+	o := &alertOptions{
+		level:    zapcore.ErrorLevel,
+		interval: time.Minute,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	if o.tmpl == nil {
+		o.tmpl = template.Must(template.New("alert").Parse(defaultAlertTemplate))
+	}
+
+	c := &alertCore{
+		LevelEnabler: o.level,
+		channel:      channel,
+		webhook:      webhook,
+		options:      o,
+		limiter: &alertLimiter{
+			interval: o.interval,
+			entries:  make(map[string]*alertLimit),
+		},
+		queue: make(chan string, 100),
+	}
+
+	switch channel {
+	case AlertDingTalk:
+		c.notifier = NewNotifier(NotifyDingTalk, webhook, WithNotifySecret(o.secret))
+	case AlertWeCom:
+		c.notifier = NewNotifier(NotifyWeCom, webhook)
+	}
+
+	SafeGo(c.run)
+
+	return c
+}
+
+func (c *alertCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+
+	return &clone
+}
+
+func (c *alertCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+
+	return ce
+}
+
+func (c *alertCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	ok, suppressed := c.limiter.allow(e.Level.String()+":"+e.Message, e.Time)
+
+	if !ok {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	alert := &LogAlert{
+		Level:      e.Level.CapitalString(),
+		Time:       e.Time.In(TimeLocation()).Format("2006-01-02 15:04:05"),
+		Message:    e.Message,
+		Stack:      e.Stack,
+		Fields:     enc.Fields,
+		Suppressed: suppressed,
+	}
+
+	if e.Caller.Defined {
+		alert.Caller = e.Caller.String()
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := c.options.tmpl.Execute(buf, alert); err != nil {
+		return err
+	}
+
+	content := buf.String()
+
+	// the process is going down, send it right now
+	if e.Level > zapcore.DPanicLevel {
+		return c.send(content)
+	}
+
+	select {
+	case c.queue <- content:
+	default:
+		// drop the alert rather than blocking the logging
+	}
+
+	return nil
+}
+
+func (c *alertCore) Sync() error {
+	return nil
+}
+
+func (c *alertCore) run() {
+	for content := range c.queue {
+		c.send(content)
+	}
+}
+
+func (c *alertCore) send(content string) error {
+	switch c.channel {
+	case AlertDingTalk:
+		return c.notifier.Send(NotifyText(truncateBytes(content, 20000)))
+	case AlertWeCom:
+		return c.notifier.Send(NotifyText(truncateBytes(content, 2048)))
+	case AlertSlack:
+		body, err := jsonCodec.Marshal(X{"text": truncateBytes(content, 40000)})
+
+		if err != nil {
+			return err
+		}
+
+		// Slack responds `ok` as text
+		_, err = HTTPPost(c.webhook, body, WithRequestHeader("Content-Type", "application/json; charset=utf-8"))
+
+		return err
+	}
+
+	return fmt.Errorf("yiigo: invalid alert channel %d", c.channel)
+}
+
+// truncateBytes truncates s to at most n bytes without breaking a multi-byte character.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
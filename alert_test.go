@@ -0,0 +1,120 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_truncateBytes(t *testing.T) {
+	type args struct {
+		s string
+		n int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{s: "hello", n: 10},
+			want: "hello",
+		},
+		{
+			name: "t2",
+			args: args{s: "hello", n: 3},
+			want: "hel",
+		},
+		{
+			name: "t3",
+			args: args{s: "你好", n: 4},
+			want: "你",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateBytes(tt.args.s, tt.args.n); got != tt.want {
+				t.Errorf("truncateBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_alertLimiter_allow(t *testing.T) {
+	l := &alertLimiter{
+		interval: time.Minute,
+		entries:  make(map[string]*alertLimit),
+	}
+
+	now := time.Now()
+
+	steps := []struct {
+		key        string
+		at         time.Duration
+		ok         bool
+		suppressed int
+	}{
+		{key: "a", at: 0, ok: true, suppressed: 0},
+		{key: "a", at: time.Second, ok: false, suppressed: 0},
+		{key: "b", at: time.Second, ok: true, suppressed: 0},
+		{key: "a", at: 2 * time.Second, ok: false, suppressed: 0},
+		{key: "a", at: time.Minute, ok: true, suppressed: 2},
+		{key: "a", at: 2 * time.Minute, ok: true, suppressed: 0},
+	}
+
+	for i, s := range steps {
+		ok, suppressed := l.allow(s.key, now.Add(s.at))
+
+		if ok != s.ok || suppressed != s.suppressed {
+			t.Errorf("step %d: alertLimiter.allow() = (%v, %d), want (%v, %d)", i, ok, suppressed, s.ok, s.suppressed)
+		}
+	}
+}
+
+func TestWithLogAlert(t *testing.T) {
+	contents := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		msg := new(struct {
+			Text struct {
+				Content string `json:"content"`
+			} `json:"text"`
+		})
+
+		json.Unmarshal(b, msg)
+
+		contents <- msg.Text.Content
+
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+
+	defer ts.Close()
+
+	o := &logOptions{}
+
+	WithLogAlert(AlertWeCom, ts.URL, WithAlertTemplate("{{.Level}} {{.Message}} {{.Fields.order}}")).apply(o)
+
+	logger := zap.New(zapcore.NewTee(o.alerts...))
+
+	logger.Info("ignored")
+	logger.Error("payment failed", zap.Int("order", 42))
+
+	select {
+	case got := <-contents:
+		if want := "ERROR payment failed 42"; !strings.HasPrefix(got, want) {
+			t.Errorf("WithLogAlert() content = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("WithLogAlert() alert not sent")
+	}
+}
@@ -0,0 +1,383 @@
+package yiigo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrArchiveTooLarge returned when the extracted size or files of an archive exceeds the limits.
+	ErrArchiveTooLarge = errors.New("yiigo: archive exceeds the size limits")
+	// ErrArchiveInvalidPath returned when an entry of archive escapes the destination (eg: "../../etc/passwd").
+	ErrArchiveInvalidPath = errors.New("yiigo: archive entry has invalid path")
+)
+
+// archiveOptions archive extraction options
+type archiveOptions struct {
+	maxSize  int64
+	maxFiles int
+}
+
+// ArchiveOption configures how we extract the archive
+type ArchiveOption interface {
+	apply(options *archiveOptions)
+}
+
+// funcArchiveOption implements archive option
+type funcArchiveOption struct {
+	f func(options *archiveOptions)
+}
+
+func (fo *funcArchiveOption) apply(o *archiveOptions) {
+	fo.f(o)
+}
+
+func newFuncArchiveOption(f func(options *archiveOptions)) *funcArchiveOption {
+	return &funcArchiveOption{f: f}
+}
+
+// WithArchiveMaxSize specifies the maximum total bytes extracted, 0 means no limit.
+// It's checked against the actual bytes written, so a zip bomb lying about its sizes is stopped too.
+func WithArchiveMaxSize(n int64) ArchiveOption {
+	return newFuncArchiveOption(func(o *archiveOptions) {
+		o.maxSize = n
+	})
+}
+
+// WithArchiveMaxFiles specifies the maximum number of entries extracted, 0 means no limit.
+func WithArchiveMaxFiles(n int) ArchiveOption {
+	return newFuncArchiveOption(func(o *archiveOptions) {
+		o.maxFiles = n
+	})
+}
+
+func newArchiveOptions(options ...ArchiveOption) *archiveOptions {
+	o := new(archiveOptions)
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return o
+}
+
+// archiveExtractor writes the entries into dest within the limits.
+type archiveExtractor struct {
+	dest    string
+	options *archiveOptions
+	size    int64
+	files   int
+}
+
+func newArchiveExtractor(dest string, options ...ArchiveOption) (*archiveExtractor, error) {
+	path, err := filepath.Abs(dest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	return &archiveExtractor{
+		dest:    path,
+		options: newArchiveOptions(options...),
+	}, nil
+}
+
+// path returns the path of entry name in dest, or ErrArchiveInvalidPath if it escapes dest.
+func (e *archiveExtractor) path(name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", ErrArchiveInvalidPath
+	}
+
+	path := filepath.Join(e.dest, filepath.FromSlash(name))
+
+	if path != e.dest && !strings.HasPrefix(path, e.dest+string(os.PathSeparator)) {
+		return "", ErrArchiveInvalidPath
+	}
+
+	return path, nil
+}
+
+func (e *archiveExtractor) mkdir(name string) error {
+	path, err := e.path(name)
+
+	if err != nil {
+		return err
+	}
+
+	return os.MkdirAll(path, 0755)
+}
+
+func (e *archiveExtractor) create(name string, mode os.FileMode, r io.Reader) error {
+	if e.files++; e.options.maxFiles > 0 && e.files > e.options.maxFiles {
+		return ErrArchiveTooLarge
+	}
+
+	path, err := e.path(name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if e.options.maxSize <= 0 {
+		_, err := io.Copy(f, r)
+
+		return err
+	}
+
+	// copy one more byte to detect exceeding
+	n, err := io.CopyN(f, r, e.options.maxSize-e.size+1)
+
+	e.size += n
+
+	if e.size > e.options.maxSize {
+		return ErrArchiveTooLarge
+	}
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// walkArchive walks the regular files and dirs of src, with the slash separated names relative to the parent of src.
+func walkArchive(src string, f func(path, name string, info os.FileInfo) error) error {
+	src, err := filepath.Abs(src)
+
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Dir(src)
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(base, path)
+
+		if err != nil {
+			return err
+		}
+
+		return f(path, filepath.ToSlash(name), info)
+	})
+}
+
+// Zip streams the zip of src (a file or dir) to w, the entries are named relative to the parent of src.
+func Zip(w io.Writer, src string) error {
+	zw := zip.NewWriter(w)
+
+	err := walkArchive(src, func(path, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+
+		if info.IsDir() {
+			header.Name += "/"
+
+			_, err := zw.CreateHeader(header)
+
+			return err
+		}
+
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+
+		if err != nil {
+			return err
+		}
+
+		return copyFile(fw, path)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Unzip extracts the zip of r into dest, eg: Unzip(f, fileInfo.Size(), dest) for a file, or Unzip(bytes.NewReader(b), int64(len(b)), dest) for an upload.
+// The entries escaping dest and the symlinks are rejected.
+func Unzip(r io.ReaderAt, size int64, dest string, options ...ArchiveOption) error {
+	zr, err := zip.NewReader(r, size)
+
+	if err != nil {
+		return err
+	}
+
+	e, err := newArchiveExtractor(dest, options...)
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+
+		if mode.IsDir() {
+			if err := e.mkdir(f.Name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !mode.IsRegular() {
+			return fmt.Errorf("yiigo: archive entry `%s` is not a regular file", f.Name)
+		}
+
+		if err := unzipFile(e, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unzipFile(e *archiveExtractor, f *zip.File) error {
+	rc, err := f.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	return e.create(f.Name, f.Mode(), rc)
+}
+
+// TarGz streams the tar.gz of src (a file or dir) to w, the entries are named relative to the parent of src.
+func TarGz(w io.Writer, src string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := walkArchive(src, func(path, name string, info os.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		return copyFile(tw, path)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+// UntarGz extracts the tar.gz streamed from r into dest.
+// The entries escaping dest and the links are rejected, the other special files are skipped.
+func UntarGz(r io.Reader, dest string, options ...ArchiveOption) error {
+	gr, err := gzip.NewReader(r)
+
+	if err != nil {
+		return err
+	}
+
+	defer gr.Close()
+
+	e, err := newArchiveExtractor(dest, options...)
+
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gr)
+
+	for {
+		header, err := tr.Next()
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := e.mkdir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := e.create(header.Name, os.FileMode(header.Mode), tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			return fmt.Errorf("yiigo: archive entry `%s` is a link", header.Name)
+		}
+	}
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+
+	return err
+}
@@ -0,0 +1,107 @@
+package yiigo
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func archiveTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "yiigo")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "src", "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("hello"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "src", "sub", "b.txt"), []byte("world"), 0644)
+
+	return dir
+}
+
+func TestZip(t *testing.T) {
+	dir := archiveTestDir(t)
+
+	defer os.RemoveAll(dir)
+
+	buf := new(bytes.Buffer)
+
+	if err := Zip(buf, filepath.Join(dir, "src")); err != nil {
+		t.Errorf("Zip() error = %v", err)
+
+		return
+	}
+
+	dest := filepath.Join(dir, "dest")
+
+	if err := Unzip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), dest); err != nil {
+		t.Errorf("Unzip() error = %v", err)
+
+		return
+	}
+
+	if b, _ := ioutil.ReadFile(filepath.Join(dest, "src", "sub", "b.txt")); string(b) != "world" {
+		t.Errorf("Unzip() sub/b.txt = %s, want world", b)
+	}
+
+	if err := Unzip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), dest, WithArchiveMaxSize(8)); err != ErrArchiveTooLarge {
+		t.Errorf("Unzip() error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
+
+func TestUnzip_InvalidPath(t *testing.T) {
+	dir := archiveTestDir(t)
+
+	defer os.RemoveAll(dir)
+
+	buf := new(bytes.Buffer)
+
+	zw := zip.NewWriter(buf)
+
+	fw, _ := zw.Create("../evil.txt")
+	fw.Write([]byte("evil"))
+
+	zw.Close()
+
+	if err := Unzip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), filepath.Join(dir, "dest")); err != ErrArchiveInvalidPath {
+		t.Errorf("Unzip() error = %v, want %v", err, ErrArchiveInvalidPath)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Error("Unzip() extracted the entry out of dest")
+	}
+}
+
+func TestTarGz(t *testing.T) {
+	dir := archiveTestDir(t)
+
+	defer os.RemoveAll(dir)
+
+	buf := new(bytes.Buffer)
+
+	if err := TarGz(buf, filepath.Join(dir, "src")); err != nil {
+		t.Errorf("TarGz() error = %v", err)
+
+		return
+	}
+
+	dest := filepath.Join(dir, "dest")
+
+	if err := UntarGz(bytes.NewReader(buf.Bytes()), dest, WithArchiveMaxFiles(2)); err != nil {
+		t.Errorf("UntarGz() error = %v", err)
+
+		return
+	}
+
+	if b, _ := ioutil.ReadFile(filepath.Join(dest, "src", "a.txt")); string(b) != "hello" {
+		t.Errorf("UntarGz() a.txt = %s, want hello", b)
+	}
+
+	if err := UntarGz(bytes.NewReader(buf.Bytes()), dest, WithArchiveMaxFiles(1)); err != ErrArchiveTooLarge {
+		t.Errorf("UntarGz() error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
@@ -0,0 +1,162 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrBatchPanicked reported by the item whose func panicked, the panic is logged with the stack trace.
+	ErrBatchPanicked = errors.New("yiigo: batch item panicked")
+	// ErrBatchSkipped reported by the items not executed, since the context is done or a previous item of DBBatch failed.
+	ErrBatchSkipped = errors.New("yiigo: batch item skipped")
+	// ErrBatchRolledBack reported by the items succeeded but rolled back, since another item of DBBatch failed.
+	ErrBatchRolledBack = errors.New("yiigo: batch item rolled back")
+)
+
+// BatchResult the result of an item of batch, which can be responded as the per-item status.
+type BatchResult struct {
+	Index int         `json:"index"`
+	OK    bool        `json:"ok"`
+	Code  int         `json:"code,omitempty"`
+	Error string      `json:"error,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	Err   error       `json:"-"`
+}
+
+func newBatchResult(i int, data interface{}, err error) *BatchResult {
+	r := &BatchResult{
+		Index: i,
+		OK:    err == nil,
+		Data:  data,
+		Err:   err,
+	}
+
+	if err != nil {
+		r.Code = ErrorCode(err)
+		r.Error = err.Error()
+		r.Data = nil
+	}
+
+	return r
+}
+
+// BatchFunc executes the item i of batch, and returns its data.
+type BatchFunc func(ctx context.Context, i int) (interface{}, error)
+
+// callBatch calls f and converts a panic to ErrBatchPanicked.
+func callBatch(ctx context.Context, i int, f BatchFunc) (data interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanicError(panicError(r))
+
+			data, err = nil, ErrBatchPanicked
+		}
+	}()
+
+	return f(ctx, i)
+}
+
+// Batch executes f for the n items concurrently, at most `concurrency` at a time (<= 0 means unlimited),
+// and returns the results in the same order as the items. A failed item doesn't stop the others,
+// and the items not started when ctx is done report ErrBatchSkipped.
+func Batch(ctx context.Context, n, concurrency int, f BatchFunc) []*BatchResult {
+	results := make([]*BatchResult, n)
+
+	if concurrency <= 0 || concurrency > n {
+		concurrency = n
+	}
+
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			results[i] = newBatchResult(i, nil, ErrBatchSkipped)
+
+			continue
+		case sem <- struct{}{}:
+		}
+
+		// ctx is checked again, since select chooses randomly when both are ready
+		if ctx.Err() != nil {
+			<-sem
+
+			results[i] = newBatchResult(i, nil, ErrBatchSkipped)
+
+			continue
+		}
+
+		wg.Add(1)
+
+		i := i
+
+		SafeGo(func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			data, err := callBatch(ctx, i, f)
+
+			results[i] = newBatchResult(i, data, err)
+		})
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// DBBatchFunc executes the item i of batch within the transaction, and returns its data.
+type DBBatchFunc func(ctx context.Context, tx *sqlx.Tx, i int) (interface{}, error)
+
+// DBBatch executes f for the n items one by one within a transaction of db, all or nothing.
+// When an item fails, the transaction is rolled back, the items before it report ErrBatchRolledBack
+// and the items after it report ErrBatchSkipped. It returns the error of the failed item or the transaction.
+func DBBatch(ctx context.Context, db *sqlx.DB, n int, f DBBatchFunc) ([]*BatchResult, error) {
+	results := make([]*BatchResult, n)
+
+	failed := -1
+
+	err := DBTransaction(ctx, db, func(ctx context.Context, tx *sqlx.Tx) error {
+		for i := 0; i < n; i++ {
+			data, err := callBatch(ctx, i, func(ctx context.Context, i int) (interface{}, error) {
+				return f(ctx, tx, i)
+			})
+
+			results[i] = newBatchResult(i, data, err)
+
+			if err != nil {
+				failed = i
+
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		return results, nil
+	}
+
+	for i := 0; i < n; i++ {
+		switch {
+		case failed < 0:
+			// the transaction failed to begin or commit
+			results[i] = newBatchResult(i, nil, err)
+		case i < failed:
+			results[i] = newBatchResult(i, nil, ErrBatchRolledBack)
+		case i > failed:
+			results[i] = newBatchResult(i, nil, ErrBatchSkipped)
+		}
+	}
+
+	return results, err
+}
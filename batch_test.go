@@ -0,0 +1,64 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	errOdd := ErrorWithCode(errors.New("odd"), 400)
+
+	results := Batch(context.Background(), 5, 2, func(ctx context.Context, i int) (interface{}, error) {
+		if i == 4 {
+			panic("boom")
+		}
+
+		if i%2 == 1 {
+			return nil, errOdd
+		}
+
+		return i * 10, nil
+	})
+
+	if len(results) != 5 {
+		t.Fatalf("Batch() got %d results, want 5", len(results))
+	}
+
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("Batch() results[%d].Index = %d", i, r.Index)
+		}
+
+		switch {
+		case i == 4:
+			if r.OK || r.Err != ErrBatchPanicked {
+				t.Errorf("Batch() results[%d] = %+v, want ErrBatchPanicked", i, r)
+			}
+		case i%2 == 1:
+			if r.OK || r.Code != 400 || r.Error != "odd" {
+				t.Errorf("Batch() results[%d] = %+v, want code 400", i, r)
+			}
+		default:
+			if !r.OK || r.Data != i*10 {
+				t.Errorf("Batch() results[%d] = %+v, want data %d", i, r, i*10)
+			}
+		}
+	}
+}
+
+func TestBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	results := Batch(ctx, 3, 1, func(ctx context.Context, i int) (interface{}, error) {
+		return i, nil
+	})
+
+	for i, r := range results {
+		if r.Err != ErrBatchSkipped {
+			t.Errorf("Batch() results[%d].Err = %v, want ErrBatchSkipped", i, r.Err)
+		}
+	}
+}
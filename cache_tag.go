@@ -0,0 +1,151 @@
+package yiigo
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// taggedSetScript sets KEYS[1] to ARGV[1] with ttl ARGV[2](ms, 0 means never), and adds it to the tag sets KEYS[2:].
+// A tag set lives as long as its longest-lived key.
+var taggedSetScript = redis.NewScript(-1, `
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	local existed = redis.call('EXISTS', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl == 0 then
+		redis.call('PERSIST', KEYS[i])
+	else
+		local pttl = redis.call('PTTL', KEYS[i])
+		if existed == 0 or (pttl >= 0 and pttl < ttl) then
+			redis.call('PEXPIRE', KEYS[i], ttl)
+		end
+	end
+end
+return 1
+`)
+
+// invalidateTagScript deletes the keys of the tag sets KEYS, and the sets, it returns the count of keys deleted.
+var invalidateTagScript = redis.NewScript(-1, `
+local n = 0
+for _, tag in ipairs(KEYS) do
+	local keys = redis.call('SMEMBERS', tag)
+	for i = 1, #keys, 1000 do
+		n = n + redis.call('DEL', unpack(keys, i, math.min(i + 999, #keys)))
+	end
+	redis.call('DEL', tag)
+end
+return n
+`)
+
+// TaggedCache a redis cache whose keys can be tagged, and invalidated together by tags after writes.
+//
+//	cache.Set("user:42:profile", b, time.Hour, "user:42")
+//	cache.Set("user:42:orders", b, time.Hour, "user:42", "orders")
+//
+//	// after the user is updated
+//	cache.InvalidateTag("user:42")
+type TaggedCache struct {
+	pool   *RedisPoolResource
+	prefix string
+}
+
+// NewTaggedCache returns a new tagged cache backed by the redis pool, the keys are prefixed by `prefix`,
+// and the tag sets by `prefix + "tag:"`.
+func NewTaggedCache(pool *RedisPoolResource, prefix string) *TaggedCache {
+	return &TaggedCache{
+		pool:   pool,
+		prefix: prefix,
+	}
+}
+
+// Get returns the cached value of key, it returns redis.ErrNil if missed.
+func (c *TaggedCache) Get(key string) ([]byte, error) {
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.pool.Put(conn)
+
+	return redis.Bytes(conn.Do("GET", c.prefix+key))
+}
+
+// Set caches the value of key for ttl (0 means never) with tags.
+func (c *TaggedCache) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer c.pool.Put(conn)
+
+	args := make(redis.Args, 0, len(tags)+4)
+	args = append(args, len(tags)+1, c.prefix+key)
+
+	for _, tag := range tags {
+		args = append(args, c.prefix+"tag:"+tag)
+	}
+
+	args = append(args, value, int64(ttl/time.Millisecond))
+
+	_, err = taggedSetScript.Do(conn.Conn, args...)
+
+	return err
+}
+
+// Delete deletes the keys, the stale members of tag sets are cleaned on invalidation.
+func (c *TaggedCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer c.pool.Put(conn)
+
+	args := make(redis.Args, 0, len(keys))
+
+	for _, key := range keys {
+		args = append(args, c.prefix+key)
+	}
+
+	_, err = conn.Do("DEL", args...)
+
+	return err
+}
+
+// InvalidateTag deletes all the keys of the tags atomically, it returns the count of keys deleted.
+func (c *TaggedCache) InvalidateTag(tags ...string) (int, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer c.pool.Put(conn)
+
+	args := make(redis.Args, 0, len(tags)+1)
+	args = append(args, len(tags))
+
+	for _, tag := range tags {
+		args = append(args, c.prefix+"tag:"+tag)
+	}
+
+	return redis.Int(invalidateTagScript.Do(conn.Conn, args...))
+}
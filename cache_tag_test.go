@@ -0,0 +1,98 @@
+package yiigo
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestTaggedCache_Set(t *testing.T) {
+	mr, pool, closeRedis := newTestRedis(t, "cache_tag_set")
+
+	defer closeRedis()
+
+	cache := NewTaggedCache(pool, "c:")
+
+	tests := []struct {
+		name    string
+		key     string
+		ttl     time.Duration
+		wantTTL time.Duration // the ttl of tag set, 0 means persistent
+	}{
+		{name: "first key sets the ttl", key: "a", ttl: time.Hour, wantTTL: time.Hour},
+		{name: "longer key extends the ttl", key: "b", ttl: 2 * time.Hour, wantTTL: 2 * time.Hour},
+		{name: "shorter key keeps the ttl", key: "c", ttl: 30 * time.Minute, wantTTL: 2 * time.Hour},
+		{name: "persistent key persists the set", key: "d", ttl: 0, wantTTL: 0},
+		{name: "expiring key keeps it persistent", key: "e", ttl: time.Minute, wantTTL: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cache.Set(tt.key, []byte(tt.key), tt.ttl, "users"); err != nil {
+				t.Fatalf("TaggedCache.Set() error = %v", err)
+			}
+
+			if got := mr.TTL("c:" + tt.key); got != tt.ttl {
+				t.Errorf("TaggedCache.Set() key ttl = %v, want %v", got, tt.ttl)
+			}
+
+			if got := mr.TTL("c:tag:users"); got != tt.wantTTL {
+				t.Errorf("TaggedCache.Set() tag set ttl = %v, want %v", got, tt.wantTTL)
+			}
+
+			b, err := cache.Get(tt.key)
+
+			if err != nil || string(b) != tt.key {
+				t.Errorf("TaggedCache.Get() = %s, %v, want %s", b, err, tt.key)
+			}
+		})
+	}
+}
+
+func TestTaggedCache_InvalidateTag(t *testing.T) {
+	mr, pool, closeRedis := newTestRedis(t, "cache_tag_invalidate")
+
+	defer closeRedis()
+
+	cache := NewTaggedCache(pool, "c:")
+
+	// more than a batch of DEL (1000 keys)
+	for i := 0; i < 2500; i++ {
+		if err := cache.Set("user:"+strconv.Itoa(i), []byte("x"), time.Hour, "users"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache.Set("order:1", []byte("x"), time.Hour, "orders", "users")
+	cache.Set("config", []byte("x"), time.Hour, "configs")
+
+	// a deleted key leaves a stale member, which isn't counted
+	cache.Delete("user:0")
+
+	n, err := cache.InvalidateTag("users")
+
+	if err != nil {
+		t.Fatalf("TaggedCache.InvalidateTag() error = %v", err)
+	}
+
+	if n != 2500 {
+		t.Errorf("TaggedCache.InvalidateTag() = %d, want 2500", n)
+	}
+
+	if mr.Exists("c:tag:users") || mr.Exists("c:user:1") || mr.Exists("c:order:1") {
+		t.Error("TaggedCache.InvalidateTag() left the keys of tag")
+	}
+
+	if _, err := cache.Get("config"); err != nil {
+		t.Errorf("TaggedCache.InvalidateTag() deleted an untagged key, Get() error = %v", err)
+	}
+
+	if _, err := cache.Get("user:2499"); err != redis.ErrNil {
+		t.Errorf("TaggedCache.Get() after invalidation error = %v, want redis.ErrNil", err)
+	}
+
+	if n, _ := cache.InvalidateTag(); n != 0 {
+		t.Errorf("TaggedCache.InvalidateTag() without tags = %d, want 0", n)
+	}
+}
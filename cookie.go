@@ -0,0 +1,265 @@
+package yiigo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrCookieInvalid returned when a cookie is tampered, expired or encrypted by an unknown key.
+	ErrCookieInvalid = errors.New("yiigo: invalid cookie")
+	// ErrCookieTooLarge returned when an encoded cookie exceeds 4096 bytes, which browsers drop.
+	ErrCookieTooLarge = errors.New("yiigo: cookie exceeds 4096 bytes")
+)
+
+// cookieOptions cookie options
+type cookieOptions struct {
+	path     string
+	domain   string
+	maxAge   time.Duration
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+}
+
+// CookieOption configures how we set the cookie
+type CookieOption interface {
+	apply(options *cookieOptions)
+}
+
+// funcCookieOption implements cookie option
+type funcCookieOption struct {
+	f func(options *cookieOptions)
+}
+
+func (fo *funcCookieOption) apply(o *cookieOptions) {
+	fo.f(o)
+}
+
+func newFuncCookieOption(f func(options *cookieOptions)) *funcCookieOption {
+	return &funcCookieOption{f: f}
+}
+
+// WithCookiePath specifies the `Path` to cookie.
+func WithCookiePath(s string) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.path = s
+	})
+}
+
+// WithCookieDomain specifies the `Domain` to cookie.
+func WithCookieDomain(s string) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.domain = s
+	})
+}
+
+// WithCookieMaxAge specifies the `MaxAge` to cookie, which is also enforced on decoding, 0 means a session cookie.
+func WithCookieMaxAge(d time.Duration) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.maxAge = d
+	})
+}
+
+// WithCookieSecure specifies the `Secure` to cookie.
+func WithCookieSecure(b bool) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.secure = b
+	})
+}
+
+// WithCookieHTTPOnly specifies the `HttpOnly` to cookie.
+func WithCookieHTTPOnly(b bool) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.httpOnly = b
+	})
+}
+
+// WithCookieSameSite specifies the `SameSite` to cookie.
+func WithCookieSameSite(s http.SameSite) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.sameSite = s
+	})
+}
+
+// CookieCodec encrypts the cookie values with AES-GCM, which are authenticated, so can't be read or forged by clients.
+type CookieCodec struct {
+	aeads []cipher.AEAD
+}
+
+// NewCookieCodec returns a new cookie codec of keys (16, 24 or 32 bytes for AES-128, AES-192 or AES-256).
+// The first key encrypts, and all the keys decrypt, so a key can be rotated by prepending the new one
+// and removing the old one after the cookies encrypted by it expire.
+func NewCookieCodec(keys ...[]byte) (*CookieCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("yiigo: cookie codec needs at least one key")
+	}
+
+	aeads := make([]cipher.AEAD, 0, len(keys))
+
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+
+		if err != nil {
+			return nil, err
+		}
+
+		aeads = append(aeads, aead)
+	}
+
+	return &CookieCodec{aeads: aeads}, nil
+}
+
+// Encode encrypts the JSON of v as the value of cookie name, which expires after maxAge (0 means never).
+// The name is authenticated, so a value can't be moved to another cookie.
+func (c *CookieCodec) Encode(name string, v interface{}, maxAge time.Duration) (string, error) {
+	b, err := jsonCodec.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	var expiresAt int64
+
+	if maxAge > 0 {
+		expiresAt = time.Now().Add(maxAge).Unix()
+	}
+
+	// expires at (8 bytes) + json
+	plain := make([]byte, 8+len(b))
+
+	binary.BigEndian.PutUint64(plain, uint64(expiresAt))
+	copy(plain[8:], b)
+
+	aead := c.aeads[0]
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, []byte(name))), nil
+}
+
+// Decode decrypts the value of cookie name into dest, it returns ErrCookieInvalid if the value is tampered or expired.
+func (c *CookieCodec) Decode(name, value string, dest interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return ErrCookieInvalid
+	}
+
+	for _, aead := range c.aeads {
+		if len(b) < aead.NonceSize() {
+			continue
+		}
+
+		plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(name))
+
+		if err != nil || len(plain) < 8 {
+			continue
+		}
+
+		if expiresAt := int64(binary.BigEndian.Uint64(plain)); expiresAt > 0 && time.Now().Unix() > expiresAt {
+			return ErrCookieInvalid
+		}
+
+		return jsonCodec.Unmarshal(plain[8:], dest)
+	}
+
+	return ErrCookieInvalid
+}
+
+// SetCookie sets an encrypted cookie of v to the response.
+//
+// The default `Path` is "/".
+// The default `HttpOnly` is true.
+// The default `SameSite` is http.SameSiteLaxMode.
+func (c *CookieCodec) SetCookie(w http.ResponseWriter, name string, v interface{}, options ...CookieOption) error {
+	o := &cookieOptions{
+		path:     "/",
+		httpOnly: true,
+		sameSite: http.SameSiteLaxMode,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	value, err := c.Encode(name, v, o.maxAge)
+
+	if err != nil {
+		return err
+	}
+
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.path,
+		Domain:   o.domain,
+		Secure:   o.secure,
+		HttpOnly: o.httpOnly,
+		SameSite: o.sameSite,
+	}
+
+	if o.maxAge > 0 {
+		cookie.MaxAge = int(o.maxAge / time.Second)
+		cookie.Expires = time.Now().Add(o.maxAge)
+	}
+
+	s := cookie.String()
+
+	if len(s) > 4096 {
+		return ErrCookieTooLarge
+	}
+
+	w.Header().Add("Set-Cookie", s)
+
+	return nil
+}
+
+// GetCookie decrypts the cookie of the request into dest, it returns http.ErrNoCookie if the cookie is absent.
+func (c *CookieCodec) GetCookie(r *http.Request, name string, dest interface{}) error {
+	cookie, err := r.Cookie(name)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Decode(name, cookie.Value, dest)
+}
+
+// DeleteCookie deletes the cookie from the client, the path and domain should be the same as set.
+func DeleteCookie(w http.ResponseWriter, name string, options ...CookieOption) {
+	o := &cookieOptions{path: "/"}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    name,
+		Value:   "",
+		Path:    o.path,
+		Domain:  o.domain,
+		MaxAge:  -1,
+		Expires: time.Unix(0, 0),
+	})
+}
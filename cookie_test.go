@@ -0,0 +1,79 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type cookieTestSession struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func TestCookieCodec(t *testing.T) {
+	oldKey := []byte("0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+
+	oldCodec, _ := NewCookieCodec(oldKey)
+	codec, err := NewCookieCodec(newKey, oldKey)
+
+	if err != nil {
+		t.Errorf("NewCookieCodec() error = %v", err)
+
+		return
+	}
+
+	want := &cookieTestSession{UserID: 1, Role: "admin"}
+
+	w := httptest.NewRecorder()
+
+	if err := codec.SetCookie(w, "session", want, WithCookieMaxAge(time.Hour)); err != nil {
+		t.Errorf("CookieCodec.SetCookie() error = %v", err)
+
+		return
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+
+	for _, v := range w.Result().Cookies() {
+		r.AddCookie(v)
+	}
+
+	got := new(cookieTestSession)
+
+	if err := codec.GetCookie(r, "session", got); err != nil {
+		t.Errorf("CookieCodec.GetCookie() error = %v", err)
+
+		return
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CookieCodec.GetCookie() = %v, want %v", got, want)
+	}
+
+	// encrypted by the rotated key
+	old, _ := oldCodec.Encode("session", want, 0)
+
+	if err := codec.Decode("session", old, new(cookieTestSession)); err != nil {
+		t.Errorf("CookieCodec.Decode() rotated key error = %v", err)
+	}
+
+	// moved to another cookie
+	if err := codec.Decode("other", old, new(cookieTestSession)); err != ErrCookieInvalid {
+		t.Errorf("CookieCodec.Decode() error = %v, want %v", err, ErrCookieInvalid)
+	}
+
+	// encrypted by the new key, which the old codec doesn't know
+	v, _ := codec.Encode("session", want, 0)
+
+	if err := oldCodec.Decode("session", v, new(cookieTestSession)); err != ErrCookieInvalid {
+		t.Errorf("CookieCodec.Decode() error = %v, want %v", err, ErrCookieInvalid)
+	}
+
+	if err := codec.GetCookie(httptest.NewRequest("GET", "/", nil), "session", got); err != http.ErrNoCookie {
+		t.Errorf("CookieCodec.GetCookie() error = %v, want %v", err, http.ErrNoCookie)
+	}
+}
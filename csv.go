@@ -0,0 +1,368 @@
+package yiigo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+var errCSVInvalidDest = errors.New("yiigo: invalid csv dest, expects pointer to struct")
+
+// CSVRowError returned when a row fails to convert or validate, the reader can go on reading the next rows.
+type CSVRowError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *CSVRowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("yiigo: csv line %d column `%s`: %v", e.Line, e.Column, e.Err)
+	}
+
+	return fmt.Sprintf("yiigo: csv line %d: %v", e.Line, e.Err)
+}
+
+// csvOptions csv reader options
+type csvOptions struct {
+	comma      rune
+	timeLayout string
+	validator  func(line int, dest interface{}) error
+}
+
+// CSVOption configures how we set up the csv reader
+type CSVOption interface {
+	apply(options *csvOptions)
+}
+
+// funcCSVOption implements csv option
+type funcCSVOption struct {
+	f func(options *csvOptions)
+}
+
+func (fo *funcCSVOption) apply(o *csvOptions) {
+	fo.f(o)
+}
+
+func newFuncCSVOption(f func(options *csvOptions)) *funcCSVOption {
+	return &funcCSVOption{f: f}
+}
+
+// WithCSVComma specifies the field delimiter of csv, defaults to ','.
+func WithCSVComma(r rune) CSVOption {
+	return newFuncCSVOption(func(o *csvOptions) {
+		o.comma = r
+	})
+}
+
+// WithCSVTimeLayout specifies the layout of time values, defaults to "2006-01-02 15:04:05".
+func WithCSVTimeLayout(s string) CSVOption {
+	return newFuncCSVOption(func(o *csvOptions) {
+		o.timeLayout = s
+	})
+}
+
+// WithCSVValidator specifies the validator of every row after converted into dest,
+// an error returned is wrapped into *CSVRowError.
+func WithCSVValidator(f func(line int, dest interface{}) error) CSVOption {
+	return newFuncCSVOption(func(o *csvOptions) {
+		o.validator = f
+	})
+}
+
+// CSVReader streams the rows of csv into structs.
+//
+// The columns are mapped to the fields by tag `csv`, or the snake case of the field name without tag,
+// a tag `csv:"-"` skips the field. NULL written by DBExport (`\N`) is converted to nil pointer or zero value.
+type CSVReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	line    int
+	options *csvOptions
+}
+
+// NewCSVReader returns a new csv reader which reads the header from r.
+// The encoding of r is detected: a UTF-8 BOM is skipped, and invalid UTF-8 is decoded as GBK (eg: exported by Excel in Chinese).
+func NewCSVReader(r io.Reader, options ...CSVOption) (*CSVReader, error) {
+	o := &csvOptions{
+		comma:      ',',
+		timeLayout: "2006-01-02 15:04:05",
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	cr := csv.NewReader(detectCSVEncoding(r))
+
+	cr.Comma = o.comma
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, v := range header {
+		columns[strings.TrimSpace(v)] = i
+	}
+
+	return &CSVReader{
+		r:       cr,
+		columns: columns,
+		line:    1,
+		options: o,
+	}, nil
+}
+
+// detectCSVEncoding returns a UTF-8 reader of r.
+func detectCSVEncoding(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, 4096)
+
+	b, _ := br.Peek(4096)
+
+	if bytes.HasPrefix(b, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+
+		return br
+	}
+
+	// the peeked bytes may end in the middle of a character
+	if utf8.Valid(trimIncompleteRune(b)) {
+		return br
+	}
+
+	return simplifiedchinese.GBK.NewDecoder().Reader(br)
+}
+
+// trimIncompleteRune trims the incomplete UTF-8 character at the end of b.
+func trimIncompleteRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+
+			break
+		}
+	}
+
+	return b
+}
+
+// Line returns the line number of the last row read.
+func (c *CSVReader) Line() int {
+	return c.line
+}
+
+// Read reads the next row into dest (a pointer to struct), it returns io.EOF when no more rows.
+func (c *CSVReader) Read(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errCSVInvalidDest
+	}
+
+	record, err := c.r.Read()
+
+	if err != nil {
+		return err
+	}
+
+	c.line++
+
+	e := v.Elem()
+	t := e.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		column := field.Tag.Get("csv")
+
+		if column == "-" {
+			continue
+		}
+
+		if column == "" {
+			column = SnakeCase(field.Name)
+		}
+
+		index, ok := c.columns[column]
+
+		if !ok || index >= len(record) {
+			continue
+		}
+
+		if err := setCSVValue(e.Field(i), record[index], c.options.timeLayout); err != nil {
+			return &CSVRowError{Line: c.line, Column: column, Err: err}
+		}
+	}
+
+	if c.options.validator != nil {
+		if err := c.options.validator(c.line, dest); err != nil {
+			return &CSVRowError{Line: c.line, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ReadCSV reads all the rows of r into dest (a pointer to slice of structs or struct pointers).
+// It stops at the first error, see CSVReader to skip the invalid rows.
+func ReadCSV(r io.Reader, dest interface{}, options ...CSVOption) error {
+	v := reflect.ValueOf(dest)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("yiigo: invalid csv dest, expects pointer to slice")
+	}
+
+	cr, err := NewCSVReader(r, options...)
+
+	if err != nil {
+		return err
+	}
+
+	s := v.Elem()
+	t := s.Type().Elem()
+
+	isPtr := t.Kind() == reflect.Ptr
+
+	if isPtr {
+		t = t.Elem()
+	}
+
+	for {
+		item := reflect.New(t)
+
+		if err := cr.Read(item.Interface()); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if isPtr {
+			s.Set(reflect.Append(s, item))
+		} else {
+			s.Set(reflect.Append(s, item.Elem()))
+		}
+	}
+}
+
+// setCSVValue converts s and sets it to v.
+func setCSVValue(v reflect.Value, s, timeLayout string) error {
+	if s == exportNull {
+		v.Set(reflect.Zero(v.Type()))
+
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+
+		if err := setCSVValue(p.Elem(), s, timeLayout); err != nil {
+			return err
+		}
+
+		v.Set(p)
+
+		return nil
+	}
+
+	if _, ok := v.Interface().(time.Time); ok {
+		if s == "" {
+			v.Set(reflect.Zero(v.Type()))
+
+			return nil
+		}
+
+		t, err := time.ParseInLocation(timeLayout, s, TimeLocation())
+
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+
+	// an empty cell of numbers or bool is zero value
+	if s == "" {
+		v.Set(reflect.Zero(v.Type()))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
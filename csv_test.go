@@ -0,0 +1,115 @@
+package yiigo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+type csvTestUser struct {
+	ID        int64
+	Name      string `csv:"user_name"`
+	Age       *int
+	Score     float64
+	Active    bool
+	CreatedAt time.Time
+	Ignored   string `csv:"-"`
+}
+
+func TestReadCSV(t *testing.T) {
+	age := 20
+
+	data := "\xef\xbb\xbfid,user_name,age,score,active,created_at,ignored\n" +
+		"1,tom,20,9.5,true,2019-06-01 10:00:00,x\n" +
+		"2,jerry,\\N,,false,,x\n"
+
+	got := make([]*csvTestUser, 0)
+
+	if err := ReadCSV(strings.NewReader(data), &got); err != nil {
+		t.Errorf("ReadCSV() error = %v", err)
+
+		return
+	}
+
+	want := []*csvTestUser{
+		{ID: 1, Name: "tom", Age: &age, Score: 9.5, Active: true, CreatedAt: time.Date(2019, 6, 1, 10, 0, 0, 0, time.Local)},
+		{ID: 2, Name: "jerry"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCSV() = %v, want %v", got, want)
+	}
+}
+
+func TestReadCSV_GBK(t *testing.T) {
+	b, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("id,user_name\n1,张三\n"))
+
+	got := make([]csvTestUser, 0)
+
+	if err := ReadCSV(bytes.NewReader(b), &got); err != nil {
+		t.Errorf("ReadCSV() error = %v", err)
+
+		return
+	}
+
+	if len(got) != 1 || got[0].Name != "张三" {
+		t.Errorf("ReadCSV() = %v, want name 张三", got)
+	}
+}
+
+func TestCSVReader_Read(t *testing.T) {
+	errTooYoung := errors.New("too young")
+
+	cr, err := NewCSVReader(strings.NewReader("id;age\n1;x\n2;10\n3;30\n"), WithCSVComma(';'), WithCSVValidator(func(line int, dest interface{}) error {
+		if *dest.(*csvTestUser).Age < 18 {
+			return errTooYoung
+		}
+
+		return nil
+	}))
+
+	if err != nil {
+		t.Errorf("NewCSVReader() error = %v", err)
+
+		return
+	}
+
+	ids := make([]int64, 0)
+	lines := make([]int, 0)
+
+	for {
+		u := new(csvTestUser)
+
+		if err := cr.Read(u); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			if e, ok := err.(*CSVRowError); ok {
+				lines = append(lines, e.Line)
+
+				continue
+			}
+
+			t.Errorf("CSVReader.Read() error = %v", err)
+
+			return
+		}
+
+		ids = append(ids, u.ID)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{3}) {
+		t.Errorf("CSVReader.Read() ids = %v, want [3]", ids)
+	}
+
+	if !reflect.DeepEqual(lines, []int{2, 3}) {
+		t.Errorf("CSVReader.Read() error lines = %v, want [2 3]", lines)
+	}
+}
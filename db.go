@@ -1,16 +1,25 @@
 package yiigo
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math/rand"
+	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 // Driver indicates the db drivers.
@@ -27,14 +36,28 @@ var (
 	dbmap sync.Map
 )
 
+var (
+	// dbTagName the struct tag which column names are read from
+	dbTagName = "db"
+	// dbNameMapper maps the field names without tag to column names, nil means using the field names as is
+	dbNameMapper func(string) string
+)
+
 var errInsertInvalidType = errors.New("yiigo: invalid data type of InsertSQL() / PGInsertSQL(), expects: struct, *struct, []struct, []*struct, yiigo.X, []yiigo.X")
 var errUpdateInvalidType = errors.New("yiigo: invalid data type of UpdateSQL() / PGUpdateSQL(), expects: struct, *struct, yiigo.X")
+var errSelectInvalidType = errors.New("yiigo: invalid data type of SelectColumns() / PGSelectColumns(), expects: struct, *struct, []struct, *[]struct, []*struct, *[]*struct")
 
 // dbOptions db options
 type dbOptions struct {
 	maxOpenConns    int
 	maxIdleConns    int
 	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+	retryAttempts   int
+	retryInterval   time.Duration
+	initStatements  []string
+	countQueries    bool
+	location        *time.Location
 }
 
 // DBOption configures how we set up the db
@@ -99,6 +122,209 @@ func WithDBConnMaxLifetime(d time.Duration) DBOption {
 	})
 }
 
+// WithDBConnMaxIdleTime specifies the `ConnMaxIdleTime` to db.
+// ConnMaxIdleTime sets the maximum amount of time a connection may be idle,
+// it should be less than the `wait_timeout` of server, so the idle connections are closed by the pool before killed by the server.
+//
+// If d <= 0, connections are not closed due to a connection's idle time.
+// It requires Go1.15+, and is ignored by earlier versions.
+func WithDBConnMaxIdleTime(d time.Duration) DBOption {
+	return newFuncDBOption(func(o *dbOptions) {
+		o.connMaxIdleTime = d
+	})
+}
+
+// WithDBConnectRetry specifies the maximum attempts to connect db on registering and the interval before the first retry,
+// which doubles after every retry up to 30s (defaults to 1s if interval <= 0), so a temporarily unavailable db (eg: restarting) doesn't fail the app boot.
+// After registered, the broken connections are reestablished by the pool on demand.
+func WithDBConnectRetry(attempts int, interval time.Duration) DBOption {
+	return newFuncDBOption(func(o *dbOptions) {
+		o.retryAttempts = attempts
+		o.retryInterval = interval
+	})
+}
+
+// WithDBInitStatements specifies the statements executed on each new connection of db,
+// eg: "SET SESSION time_zone = '+08:00'", "SET SESSION sql_mode = 'STRICT_TRANS_TABLES'".
+//
+// The statements run before the connection is handed to the pool,
+// and the connection is discarded if any of them fails.
+func WithDBInitStatements(stmts ...string) DBOption {
+	return newFuncDBOption(func(o *dbOptions) {
+		o.initStatements = stmts
+	})
+}
+
+// WithDBQueryCount specifies the queries of db are counted into the query budget of request (see DBQueryBudget).
+func WithDBQueryCount() DBOption {
+	return newFuncDBOption(func(o *dbOptions) {
+		o.countQueries = true
+	})
+}
+
+// WithDBTimeLocation specifies the `loc` of mysql dsn, which the DATETIME / TIMESTAMP values are parsed in (with `parseTime=true`),
+// it's ignored by postgres. It defaults to the timezone of application if it's set (see SetTimeLocation) and the dsn has no `loc`.
+func WithDBTimeLocation(loc *time.Location) DBOption {
+	return newFuncDBOption(func(o *dbOptions) {
+		o.location = loc
+	})
+}
+
+// mysqlDSNWithLocation returns the mysql dsn with `loc` replaced.
+func mysqlDSNWithLocation(dsn string, loc *time.Location) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+
+	if err != nil {
+		return "", err
+	}
+
+	cfg.Loc = loc
+
+	return cfg.FormatDSN(), nil
+}
+
+// mysqlDSNHasLocation reports whether the mysql dsn specifies `loc`.
+func mysqlDSNHasLocation(dsn string) bool {
+	// the params follow the last `/` (before the dbname), the password may contain `?`
+	params := dsn[strings.LastIndex(dsn, "/")+1:]
+
+	i := strings.Index(params, "?")
+
+	if i < 0 {
+		return false
+	}
+
+	for _, v := range strings.Split(params[i+1:], "&") {
+		if strings.HasPrefix(v, "loc=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dbConnector implements driver.Connector which executes the init statements on each new connection.
+type dbConnector struct {
+	dsn    string
+	driver driver.Driver
+	stmts  []string
+	count  bool
+}
+
+// Connect returns a connection to the database and executes the init statements on it.
+func (c *dbConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range c.stmts {
+		if err := execConnStatement(ctx, conn, stmt); err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("yiigo: db init statement `%s` error: %s", stmt, err.Error())
+		}
+	}
+
+	if c.count {
+		return &dbCountConn{Conn: conn}, nil
+	}
+
+	return conn, nil
+}
+
+// Driver returns the underlying driver of the connector.
+func (c *dbConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func execConnStatement(ctx context.Context, conn driver.Conn, stmt string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, stmt, nil)
+
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	s, err := conn.Prepare(stmt)
+
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	_, err = s.Exec(nil)
+
+	return err
+}
+
+// dbOpen opens the db and verifies it with a ping, the db is closed if the ping fails.
+func dbOpen(driverName, dsn string, o *dbOptions) (*sqlx.DB, error) {
+	if len(o.initStatements) == 0 && !o.countQueries {
+		// sqlx.Connect closes the db if the ping fails
+		return sqlx.Connect(driverName, dsn)
+	}
+
+	// sql.Open only validates the arguments, here we use it to lookup the registered driver
+	d, err := sql.Open(driverName, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &dbConnector{
+		dsn:    dsn,
+		driver: d.Driver(),
+		stmts:  o.initStatements,
+		count:  o.countQueries,
+	}
+
+	d.Close()
+
+	db := sqlx.NewDb(sql.OpenDB(c), driverName)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// dbMaxRetryInterval the maximum interval between the retries of connecting db.
+const dbMaxRetryInterval = 30 * time.Second
+
+// dbConnect opens the db, and retries with exponential backoff on failure as `WithDBConnectRetry` specified.
+func dbConnect(driverName, dsn string, o *dbOptions) (*sqlx.DB, error) {
+	interval := o.retryInterval
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for i := 1; ; i++ {
+		db, err := dbOpen(driverName, dsn, o)
+
+		if err == nil || i >= o.retryAttempts {
+			return db, err
+		}
+
+		if Logger != nil {
+			Logger.Warn("yiigo: db connect failed, retrying", zap.Int("attempt", i), zap.Duration("interval", interval), zap.Error(err))
+		}
+
+		time.Sleep(interval)
+
+		if interval *= 2; interval > dbMaxRetryInterval {
+			interval = dbMaxRetryInterval
+		}
+	}
+}
+
 func dbDial(driverName, dsn string, options ...DBOption) (*sqlx.DB, error) {
 	o := &dbOptions{
 		maxOpenConns:    20,
@@ -112,20 +338,36 @@ func dbDial(driverName, dsn string, options ...DBOption) (*sqlx.DB, error) {
 		}
 	}
 
-	db, err := sqlx.Connect(driverName, dsn)
+	if driverName == "mysql" {
+		loc := o.location
 
-	if err != nil {
-		return nil, err
+		if l, ok := appTimeLocation(); loc == nil && ok && !mysqlDSNHasLocation(dsn) {
+			loc = l
+		}
+
+		if loc != nil {
+			var err error
+
+			if dsn, err = mysqlDSNWithLocation(dsn, loc); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	if err := db.Ping(); err != nil {
+	db, err := dbConnect(driverName, dsn, o)
+
+	if err != nil {
 		return nil, err
 	}
 
+	db.Mapper = newDBMapper()
+
 	db.SetMaxOpenConns(o.maxOpenConns)
 	db.SetMaxIdleConns(o.maxIdleConns)
 	db.SetConnMaxLifetime(o.connMaxLifetime)
 
+	setDBConnMaxIdleTime(db, o.connMaxIdleTime)
+
 	return db, nil
 }
 
@@ -138,6 +380,7 @@ func dbDial(driverName, dsn string, options ...DBOption) (*sqlx.DB, error) {
 // The default `MaxOpenConns` is 20.
 // The default `MaxIdleConns` is 10.
 // The default `ConnMaxLifetime` is 60s.
+// The default `ConnectRetry` is 1 attempt (no retry).
 func RegisterDB(name string, driver Driver, dsn string, options ...DBOption) error {
 	driverName := ""
 
@@ -163,6 +406,34 @@ func RegisterDB(name string, driver Driver, dsn string, options ...DBOption) err
 	return nil
 }
 
+// SetDBMapper specifies the struct tag name (default `db`) and the name mapping func (eg: yiigo.SnakeCase) for fields without tag,
+// so the struct tag conventions of other ORMs can be reused. It applies to sqlx scanning of all dbs and to the sql builders
+// (InsertSQL, UpdateSQL, SelectColumns, etc.), and should be called before the dbs are registered.
+//
+// If f is nil, sqlx lowercases the field names and the sql builders use the field names as is.
+func SetDBMapper(tagName string, f func(string) string) {
+	if tagName == "" {
+		tagName = "db"
+	}
+
+	dbTagName = tagName
+	dbNameMapper = f
+
+	dbmap.Range(func(k, v interface{}) bool {
+		v.(*sqlx.DB).Mapper = newDBMapper()
+
+		return true
+	})
+}
+
+func newDBMapper() *reflectx.Mapper {
+	if dbNameMapper == nil {
+		return reflectx.NewMapperFunc(dbTagName, strings.ToLower)
+	}
+
+	return reflectx.NewMapperFunc(dbTagName, dbNameMapper)
+}
+
 // UseDB returns a db.
 func UseDB(name string) *sqlx.DB {
 	v, ok := dbmap.Load(name)
@@ -174,491 +445,1095 @@ func UseDB(name string) *sqlx.DB {
 	return v.(*sqlx.DB)
 }
 
-// InsertSQL returns mysql insert sql and binds.
-// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
-func InsertSQL(table string, data interface{}) (string, []interface{}) {
-	sql := ""
-	binds := make([]interface{}, 0)
+// CloseDB closes the registered db, it waits for the queries that have started to finish until `ctx` is done.
+// Closing the default db sets yiigo.DB to nil.
+func CloseDB(ctx context.Context, name string) error {
+	v, ok := dbmap.Load(name)
 
-	v := reflect.Indirect(reflect.ValueOf(data))
+	if !ok {
+		return fmt.Errorf("yiigo: db.%s is not registered", name)
+	}
 
-	switch v.Kind() {
-	case reflect.Map:
-		if x, ok := data.(X); ok {
-			sql, binds = singleInsertWithMap(MySQL, table, x)
-		}
-	case reflect.Struct:
-		sql, binds = singleInsertWithStruct(MySQL, table, v)
-	case reflect.Slice:
-		count := v.Len()
+	dbmap.Delete(name)
 
-		if count == 0 {
-			return sql, binds
+	if name == AsDefault && DB == v.(*sqlx.DB) {
+		DB = nil
+	}
+
+	return closeWithContext(ctx, v.(*sqlx.DB).Close)
+}
+
+// CloseAllDB closes all the registered dbs, it waits for the queries that have started to finish until `ctx` is done.
+func CloseAllDB(ctx context.Context) error {
+	var err error
+
+	dbmap.Range(func(k, v interface{}) bool {
+		if e := CloseDB(ctx, k.(string)); e != nil && err == nil {
+			err = e
 		}
 
-		e := v.Type().Elem()
+		return true
+	})
 
-		switch e.Kind() {
-		case reflect.Map:
-			x, ok := data.([]X)
+	return err
+}
 
-			if !ok {
-				panic(errInsertInvalidType)
-			}
+// DBTxFunc the function executed within a db transaction.
+type DBTxFunc func(ctx context.Context, tx *sqlx.Tx) error
 
-			sql, binds = batchInsertWithMap(MySQL, table, x, count)
-		case reflect.Struct:
-			sql, binds = batchInsertWithStruct(MySQL, table, v, count)
-		case reflect.Ptr:
-			if e.Elem().Kind() != reflect.Struct {
-				panic(errInsertInvalidType)
-			}
+// DBTransaction executes `f` within a transaction of db.
+//
+// The transaction is committed if `f` returns nil, otherwise it is rolled back.
+// When `ctx` is canceled or its deadline exceeded, the transaction is rolled back
+// and `ctx.Err()` (context.Canceled or context.DeadlineExceeded) is returned,
+// so a request timeout never leaves an open transaction behind.
+func DBTransaction(ctx context.Context, db *sqlx.DB, f DBTxFunc) error {
+	tx, err := db.BeginTxx(ctx, nil)
 
-			sql, binds = batchInsertWithStruct(MySQL, table, v, count)
-		default:
-			panic(errInsertInvalidType)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-	default:
-		panic(errInsertInvalidType)
+
+		return err
 	}
 
-	return sql, binds
-}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
 
-// UpdateSQL returns mysql update sql and binds.
-// param query expects eg: "UPDATE `table` SET ? WHERE `id` = ?".
-// param data expects: `struct`, `*struct`, `yiigo.X`.
-func UpdateSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
-	sql := ""
-	binds := make([]interface{}, 0)
+			panic(r)
+		}
+	}()
 
-	v := reflect.Indirect(reflect.ValueOf(data))
+	if err := f(ctx, tx); err != nil {
+		tx.Rollback()
 
-	switch v.Kind() {
-	case reflect.Map:
-		x, ok := data.(X)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
-		if !ok {
-			panic(errUpdateInvalidType)
+		return err
+	}
+
+	if ctx.Err() != nil {
+		tx.Rollback()
+
+		return ctx.Err()
+	}
+
+	if err := tx.Commit(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 
-		sql, binds = updateWithMap(MySQL, query, x, args...)
-	case reflect.Struct:
-		sql, binds = updateWithStruct(MySQL, query, v, args...)
-	default:
-		panic(errUpdateInvalidType)
+		return err
 	}
 
-	return sql, binds
+	return nil
 }
 
-// PGInsertSQL returns postgres insert sql and binds.
-// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
-func PGInsertSQL(table string, data interface{}) (string, []interface{}) {
-	sql := ""
-	binds := make([]interface{}, 0)
+// DBTransactionWithRetry executes `f` within a transaction of db like DBTransaction, and re-executes the whole `f`
+// in a new transaction (at most `attempts` times in total) when it fails on a deadlock or serialization failure,
+// which are mysql error 1213 and postgres error 40001 / 40P01. Retrying just the failed statement is incorrect,
+// since the transaction has already been rolled back by the server.
+//
+// The attempts are delayed by an exponential backoff starting from 10ms, up to 1s.
+func DBTransactionWithRetry(ctx context.Context, db *sqlx.DB, attempts int, f DBTxFunc) error {
+	backoff := 10 * time.Millisecond
 
-	v := reflect.Indirect(reflect.ValueOf(data))
+	for i := 1; ; i++ {
+		err := DBTransaction(ctx, db, f)
 
-	switch v.Kind() {
-	case reflect.Map:
-		if x, ok := data.(X); ok {
-			sql, binds = singleInsertWithMap(Postgres, table, x)
+		if err == nil || i >= attempts || !isTxRetryable(err) {
+			return err
 		}
-	case reflect.Struct:
-		sql, binds = singleInsertWithStruct(Postgres, table, v)
-	case reflect.Slice:
-		count := v.Len()
 
-		if count == 0 {
-			return sql, binds
+		// full jitter, avoids the conflicting transactions retry at the same moment
+		delay := time.Duration(rand.Int63n(int64(backoff)) + 1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
 		}
 
-		e := v.Type().Elem()
+		if backoff *= 2; backoff > time.Second {
+			backoff = time.Second
+		}
+	}
+}
 
-		switch e.Kind() {
-		case reflect.Map:
-			x, ok := data.([]X)
+// isTxRetryable reports whether the transaction failed on a deadlock or serialization failure.
+func isTxRetryable(err error) bool {
+	switch e := driverError(err).(type) {
+	case *mysql.MySQLError:
+		return e.Number == 1213
+	case *pq.Error:
+		return e.Code == "40001" || e.Code == "40P01"
+	}
 
-			if !ok {
-				panic(errInsertInvalidType)
-			}
+	return false
+}
 
-			sql, binds = batchInsertWithMap(Postgres, table, x, count)
-		case reflect.Struct:
-			sql, binds = batchInsertWithStruct(Postgres, table, v, count)
-		case reflect.Ptr:
-			if e.Elem().Kind() != reflect.Struct {
-				panic(errInsertInvalidType)
-			}
+// DBFanOutError records the errors of each db in a fan-out, keyed by the db name.
+type DBFanOutError map[string]error
 
-			sql, binds = batchInsertWithStruct(Postgres, table, v, count)
-		default:
-			panic(errInsertInvalidType)
-		}
-	default:
-		panic(errInsertInvalidType)
+// Error returns the errors of all the failed dbs.
+func (e DBFanOutError) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for k, v := range e {
+		msgs = append(msgs, fmt.Sprintf("db.%s: %s", k, v.Error()))
 	}
 
-	return sql, binds
+	sort.Strings(msgs)
+
+	return fmt.Sprintf("yiigo: db fan-out error: %s", strings.Join(msgs, "; "))
 }
 
-// PGUpdateSQL returns postgres update sql and binds.
-// param query expects eg: "UPDATE `table` SET $1 WHERE `id` = $2".
-// param data expects: `struct`, `*struct`, `yiigo.X`.
-func PGUpdateSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
-	sql := ""
-	binds := make([]interface{}, 0)
+// DBFanOut calls `f` for each of the registered dbs concurrently, at most `concurrency` at a time (<= 0 means unlimited).
+// Returns a DBFanOutError holding the error of each failed db, or nil if all succeed.
+func DBFanOut(ctx context.Context, names []string, concurrency int, f func(ctx context.Context, name string, db *sqlx.DB) error) error {
+	if concurrency <= 0 || concurrency > len(names) {
+		concurrency = len(names)
+	}
 
-	v := reflect.Indirect(reflect.ValueOf(data))
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
 
-	switch v.Kind() {
-	case reflect.Map:
-		x, ok := data.(X)
+	errs := make(DBFanOutError)
+	sem := make(chan struct{}, concurrency)
+
+	for _, name := range names {
+		v, ok := dbmap.Load(name)
 
 		if !ok {
-			panic(errUpdateInvalidType)
+			errs[name] = fmt.Errorf("yiigo: db.%s is not registered", name)
+
+			continue
 		}
 
-		sql, binds = updateWithMap(Postgres, query, x, args...)
-	case reflect.Struct:
-		sql, binds = updateWithStruct(Postgres, query, v, args...)
+		wg.Add(1)
+		sem <- struct{}{}
+
+		name, db := name, v.(*sqlx.DB)
+
+		SafeGo(func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := callSafe(func() error { return f(ctx, name, db) }); err != nil {
+				mutex.Lock()
+				errs[name] = err
+				mutex.Unlock()
+			}
+		})
 	}
 
-	return sql, binds
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
 }
 
-func singleInsertWithMap(driver Driver, table string, data X) (string, []interface{}) {
-	fieldNum := len(data)
+// DBFanOutSelect executes the same query on each of the registered dbs concurrently (see DBFanOut),
+// and appends the rows of all the succeeded dbs to dest.
+// param dest expects: `*[]struct`, `*[]*struct`.
+func DBFanOutSelect(ctx context.Context, names []string, concurrency int, dest interface{}, query string, args ...interface{}) error {
+	v := reflect.ValueOf(dest)
 
-	columns := make([]string, 0, fieldNum)
-	placeholders := make([]string, 0, fieldNum)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("yiigo: invalid dest of DBFanOutSelect(), expects: *[]struct, *[]*struct")
+	}
 
-	sql := ""
-	binds := make([]interface{}, 0, fieldNum)
+	var mutex sync.Mutex
 
-	switch driver {
-	case MySQL:
-		for k, v := range data {
-			columns = append(columns, fmt.Sprintf("`%s`", k))
-			placeholders = append(placeholders, "?")
-			binds = append(binds, v)
+	result := v.Elem()
+
+	return DBFanOut(ctx, names, concurrency, func(ctx context.Context, name string, db *sqlx.DB) error {
+		rows := reflect.New(result.Type())
+
+		if err := db.SelectContext(ctx, rows.Interface(), query, args...); err != nil {
+			return err
 		}
 
-		sql = fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	case Postgres:
-		bindIndex := 0
+		mutex.Lock()
+		result.Set(reflect.AppendSlice(result, rows.Elem()))
+		mutex.Unlock()
 
-		for k, v := range data {
-			bindIndex++
+		return nil
+	})
+}
 
-			columns = append(columns, fmt.Sprintf(`"%s"`, k))
-			placeholders = append(placeholders, fmt.Sprintf("$%d", bindIndex))
-			binds = append(binds, v)
-		}
+// InsertSQL returns mysql insert sql and binds.
+// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
+// The columns of struct are derived from the `db` tags, and a zero field with `db:"name,omitempty"` is skipped
+// by a single insert (so the db default applies), but not by a batch insert whose rows must have the same columns.
+func InsertSQL(table string, data interface{}) (string, []interface{}) {
+	return insertSQL(MySQL, table, data)
+}
+
+// InsertIgnoreSQL returns mysql `INSERT IGNORE` sql and binds, the rows conflicting with a unique key are skipped.
+// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
+func InsertIgnoreSQL(table string, data interface{}) (string, []interface{}) {
+	sql, binds := insertSQL(MySQL, table, data)
+
+	return replaceInsertVerb(sql, "INSERT IGNORE INTO"), binds
+}
 
-		sql = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) RETURNING "id"`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+// ReplaceSQL returns mysql `REPLACE` sql and binds, the rows conflicting with a unique key are deleted before inserting.
+// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
+func ReplaceSQL(table string, data interface{}) (string, []interface{}) {
+	sql, binds := insertSQL(MySQL, table, data)
+
+	return replaceInsertVerb(sql, "REPLACE INTO"), binds
+}
+
+// replaceInsertVerb replaces the leading `INSERT INTO` of sql with verb.
+func replaceInsertVerb(sql, verb string) string {
+	if !strings.HasPrefix(sql, "INSERT INTO") {
+		return sql
 	}
 
-	return sql, binds
+	return verb + strings.TrimPrefix(sql, "INSERT INTO")
 }
 
-func singleInsertWithStruct(driver Driver, table string, v reflect.Value) (string, []interface{}) {
-	fieldNum := v.NumField()
+// UpdateSQL returns mysql update sql and binds.
+// param query expects eg: "UPDATE `table` SET ? WHERE `id` = ?".
+// param data expects: `struct`, `*struct`, `yiigo.X`, a zero field of struct with `db:"name,omitempty"` is not updated.
+func UpdateSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
+	return updateSQL(MySQL, query, data, args...)
+}
 
-	columns := make([]string, 0, fieldNum)
-	placeholders := make([]string, 0, fieldNum)
+// PGInsertSQL returns postgres insert sql and binds.
+// param data expects: `struct`, `*struct`, `[]struct`, `[]*struct`, `yiigo.X`, `[]yiigo.X`.
+func PGInsertSQL(table string, data interface{}) (string, []interface{}) {
+	return insertSQL(Postgres, table, data)
+}
+
+// UpdateFieldsSQL returns mysql update sql and binds like UpdateSQL, which only updates the columns of fields.
+// Returns an empty sql if no columns are left to update.
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func UpdateFieldsSQL(query string, data interface{}, fields []string, args ...interface{}) (string, []interface{}) {
+	return filterUpdateSQL(MySQL, query, data, fieldsUpdateFilter(fields), args...)
+}
+
+// UpdateNonZeroSQL returns mysql update sql and binds like UpdateSQL, which only updates the columns of non-zero values.
+// Returns an empty sql if no columns are left to update.
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func UpdateNonZeroSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
+	return filterUpdateSQL(MySQL, query, data, nonZeroUpdateFilter, args...)
+}
+
+// PGUpdateSQL returns postgres update sql and binds.
+// param query expects eg: "UPDATE `table` SET $1 WHERE `id` = $2".
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func PGUpdateSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
+	return updateSQL(Postgres, query, data, args...)
+}
+
+// PGUpdateFieldsSQL returns postgres update sql and binds like PGUpdateSQL, which only updates the columns of fields.
+// Returns an empty sql if no columns are left to update.
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func PGUpdateFieldsSQL(query string, data interface{}, fields []string, args ...interface{}) (string, []interface{}) {
+	return filterUpdateSQL(Postgres, query, data, fieldsUpdateFilter(fields), args...)
+}
 
+// PGUpdateNonZeroSQL returns postgres update sql and binds like PGUpdateSQL, which only updates the columns of non-zero values.
+// Returns an empty sql if no columns are left to update.
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func PGUpdateNonZeroSQL(query string, data interface{}, args ...interface{}) (string, []interface{}) {
+	return filterUpdateSQL(Postgres, query, data, nonZeroUpdateFilter, args...)
+}
+
+// SelectColumns returns the mysql select columns derived from the `db` tags of dest, eg: "`id`, `name`".
+// param dest expects: `struct`, `*struct`, `[]struct`, `*[]struct`, `[]*struct`, `*[]*struct`.
+func SelectColumns(dest interface{}) string {
+	return selectColumns(MySQL, dest)
+}
+
+// PGSelectColumns returns the postgres select columns derived from the `db` tags of dest, eg: `"id", "name"`.
+// param dest expects: `struct`, `*struct`, `[]struct`, `*[]struct`, `[]*struct`, `*[]*struct`.
+func PGSelectColumns(dest interface{}) string {
+	return selectColumns(Postgres, dest)
+}
+
+// dialect describes how a driver quotes identifiers and writes bind placeholders.
+type dialect interface {
+	// quote returns the quoted identifier.
+	quote(identifier string) string
+	// placeholder returns the placeholder of the n-th (starts from 1) bind.
+	placeholder(n int) string
+	// returning returns the clause appended to a single row insert.
+	returning() string
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) quote(identifier string) string {
+	return fmt.Sprintf("`%s`", identifier)
+}
+
+func (mysqlDialect) placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) returning() string {
+	return ""
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) quote(identifier string) string {
+	return fmt.Sprintf(`"%s"`, identifier)
+}
+
+func (postgresDialect) placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (postgresDialect) returning() string {
+	return ` RETURNING "id"`
+}
+
+// dialect returns the sql dialect of driver, defaults to mysql.
+func (d Driver) dialect() dialect {
+	if d == Postgres {
+		return postgresDialect{}
+	}
+
+	return mysqlDialect{}
+}
+
+func insertSQL(driver Driver, table string, data interface{}) (string, []interface{}) {
 	sql := ""
-	binds := make([]interface{}, 0, fieldNum)
+	binds := make([]interface{}, 0)
 
-	t := v.Type()
+	v := reflect.Indirect(reflect.ValueOf(data))
 
-	switch driver {
-	case MySQL:
-		for i := 0; i < fieldNum; i++ {
-			column := t.Field(i).Tag.Get("db")
+	switch v.Kind() {
+	case reflect.Map:
+		if x, ok := data.(X); ok {
+			sql, binds = singleInsertWithMap(driver, table, x)
+		}
+	case reflect.Struct:
+		sql, binds = singleInsertWithStruct(driver, table, v)
+	case reflect.Slice:
+		count := v.Len()
 
-			if column == "-" {
-				continue
+		if count == 0 {
+			return sql, binds
+		}
+
+		e := v.Type().Elem()
+
+		switch e.Kind() {
+		case reflect.Map:
+			x, ok := data.([]X)
+
+			if !ok {
+				panic(errInsertInvalidType)
 			}
 
-			if column == "" {
-				column = t.Field(i).Name
+			sql, binds = batchInsertWithMap(driver, table, x, count)
+		case reflect.Struct:
+			sql, binds = batchInsertWithStruct(driver, table, v, count)
+		case reflect.Ptr:
+			if e.Elem().Kind() != reflect.Struct {
+				panic(errInsertInvalidType)
 			}
 
-			columns = append(columns, fmt.Sprintf("`%s`", column))
-			placeholders = append(placeholders, "?")
-			binds = append(binds, v.Field(i).Interface())
+			sql, binds = batchInsertWithStruct(driver, table, v, count)
+		default:
+			panic(errInsertInvalidType)
 		}
+	default:
+		panic(errInsertInvalidType)
+	}
 
-		sql = fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	case Postgres:
-		bindIndex := 0
+	return sql, binds
+}
 
-		for i := 0; i < fieldNum; i++ {
-			column := t.Field(i).Tag.Get("db")
+func updateSQL(driver Driver, query string, data interface{}, args ...interface{}) (string, []interface{}) {
+	return filterUpdateSQL(driver, query, data, nil, args...)
+}
 
-			if column == "-" {
-				continue
-			}
+// updateFilter reports whether the column should be updated.
+type updateFilter func(column string, v reflect.Value) bool
 
-			bindIndex++
+// fieldsUpdateFilter updates the columns of fields only.
+func fieldsUpdateFilter(fields []string) updateFilter {
+	m := make(map[string]struct{}, len(fields))
 
-			if column == "" {
-				column = t.Field(i).Name
-			}
+	for _, v := range fields {
+		m[v] = struct{}{}
+	}
+
+	return func(column string, v reflect.Value) bool {
+		_, ok := m[column]
+
+		return ok
+	}
+}
+
+// nonZeroUpdateFilter updates the columns of non-zero values only.
+func nonZeroUpdateFilter(column string, v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return false
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return !t.IsZero()
+	}
+
+	return !isEmptyValue(v)
+}
+
+func filterUpdateSQL(driver Driver, query string, data interface{}, filter updateFilter, args ...interface{}) (string, []interface{}) {
+	sql := ""
+	binds := make([]interface{}, 0)
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+
+	switch v.Kind() {
+	case reflect.Map:
+		x, ok := data.(X)
 
-			columns = append(columns, fmt.Sprintf(`"%s"`, column))
-			placeholders = append(placeholders, fmt.Sprintf("$%d", bindIndex))
-			binds = append(binds, v.Field(i).Interface())
+		if !ok {
+			panic(errUpdateInvalidType)
 		}
 
-		sql = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) RETURNING "id"`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		sql, binds = updateWithMap(driver, query, x, filter, args...)
+	case reflect.Struct:
+		sql, binds = updateWithStruct(driver, query, v, filter, args...)
+	default:
+		panic(errUpdateInvalidType)
 	}
 
 	return sql, binds
 }
 
-func batchInsertWithMap(driver Driver, table string, data []X, count int) (string, []interface{}) {
-	fieldNum := len(data[0])
+// structField the column of a struct field (see SetDBMapper).
+type structField struct {
+	index     []int
+	column    string
+	omitEmpty bool
+}
 
-	fields := make([]string, 0, fieldNum)
-	columns := make([]string, 0, fieldNum)
-	placeholders := make([]string, 0, fieldNum)
+// structFields returns the column fields of a struct type, the unexported fields and the fields with `db:"-"` are skipped,
+// and the untagged embedded structs are flattened.
+func structFields(t reflect.Type) []structField {
+	fieldNum := t.NumField()
 
-	sql := ""
-	binds := make([]interface{}, 0, fieldNum*count)
+	fields := make([]structField, 0, fieldNum)
 
-	switch driver {
-	case MySQL:
-		for k := range data[0] {
-			fields = append(fields, k)
+	for i := 0; i < fieldNum; i++ {
+		field := t.Field(i)
 
-			columns = append(columns, fmt.Sprintf("`%s`", k))
-		}
+		tag := field.Tag.Get(dbTagName)
 
-		for _, x := range data {
-			phrs := make([]string, 0, fieldNum)
+		if field.Anonymous && tag == "" {
+			ft := field.Type
 
-			for _, v := range fields {
-				phrs = append(phrs, "?")
-				binds = append(binds, x[v])
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
 			}
 
-			placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
+			if ft.Kind() == reflect.Struct {
+				for _, v := range structFields(ft) {
+					v.index = append([]int{i}, v.index...)
+
+					fields = append(fields, v)
+				}
+
+				continue
+			}
 		}
 
-		sql = fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	case Postgres:
-		for k := range data[0] {
-			fields = append(fields, k)
+		// skip unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		column := tag
+		opts := ""
 
-			columns = append(columns, fmt.Sprintf(`"%s"`, k))
+		// the options, eg: `db:"name,omitempty"`
+		if j := strings.Index(tag, ","); j >= 0 {
+			column, opts = tag[:j], tag[j:]
 		}
 
-		bindIndex := 0
+		if column == "-" {
+			continue
+		}
 
-		for _, x := range data {
-			phrs := make([]string, 0, fieldNum)
+		if column == "" {
+			column = field.Name
 
-			for _, v := range fields {
-				bindIndex++
+			if dbNameMapper != nil {
+				column = dbNameMapper(column)
+			}
+		}
+
+		fields = append(fields, structField{
+			index:     []int{i},
+			column:    column,
+			omitEmpty: strings.Contains(opts+",", ",omitempty,"),
+		})
+	}
+
+	return fields
+}
+
+// structColumns returns the column names of a struct type.
+func structColumns(t reflect.Type) []string {
+	fields := structFields(t)
 
-				phrs = append(phrs, fmt.Sprintf("$%d", bindIndex))
-				binds = append(binds, x[v])
+	columns := make([]string, 0, len(fields))
+
+	for _, v := range fields {
+		columns = append(columns, v.column)
+	}
+
+	return columns
+}
+
+// value returns the field value of struct v, a nil embedded pointer yields the zero value of the field.
+func (f structField) value(v reflect.Value) reflect.Value {
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(f.index[i:]).Type)
 			}
 
-			placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
+			v = v.Elem()
 		}
 
-		sql = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES %s`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		v = v.Field(x)
 	}
 
-	return sql, binds
+	return v
 }
 
-func batchInsertWithStruct(driver Driver, table string, v reflect.Value, count int) (string, []interface{}) {
-	first := reflect.Indirect(v.Index(0))
+// omit reports whether the field should be skipped by single insert and update, since it has the
+// `omitempty` option and holds a zero value (a zero time.Time included), eg: `db:"created_at,omitempty"`.
+func (f structField) omit(v reflect.Value) bool {
+	if !f.omitEmpty {
+		return false
+	}
+
+	if tm, ok := v.Interface().(time.Time); ok {
+		return tm.IsZero()
+	}
+
+	return isEmptyValue(v)
+}
+
+func selectColumns(driver Driver, dest interface{}) string {
+	t := reflect.TypeOf(dest)
+
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		panic(errSelectInvalidType)
+	}
+
+	d := driver.dialect()
 
-	fieldNum := first.NumField()
+	columns := make([]string, 0, t.NumField())
+
+	for _, v := range structColumns(t) {
+		columns = append(columns, d.quote(v))
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+func singleInsertWithMap(driver Driver, table string, data X) (string, []interface{}) {
+	d := driver.dialect()
+
+	fieldNum := len(data)
 
 	columns := make([]string, 0, fieldNum)
 	placeholders := make([]string, 0, fieldNum)
+	binds := make([]interface{}, 0, fieldNum)
 
-	sql := ""
-	binds := make([]interface{}, 0, fieldNum*count)
+	for k, v := range data {
+		binds = append(binds, v)
 
-	t := first.Type()
+		columns = append(columns, d.quote(k))
+		placeholders = append(placeholders, d.placeholder(len(binds)))
+	}
 
-	switch driver {
-	case MySQL:
-		for i := 0; i < count; i++ {
-			phrs := make([]string, 0, fieldNum)
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)%s", d.quote(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "), d.returning())
 
-			for j := 0; j < fieldNum; j++ {
-				column := t.Field(j).Tag.Get("db")
+	return sql, binds
+}
 
-				if column == "-" {
-					continue
-				}
+func singleInsertWithStruct(driver Driver, table string, v reflect.Value) (string, []interface{}) {
+	d := driver.dialect()
 
-				if i == 0 {
-					if column == "" {
-						column = t.Field(j).Name
-					}
+	fields := structFields(v.Type())
 
-					columns = append(columns, fmt.Sprintf("`%s`", column))
-				}
+	columns := make([]string, 0, len(fields))
+	placeholders := make([]string, 0, len(fields))
+	binds := make([]interface{}, 0, len(fields))
 
-				phrs = append(phrs, "?")
-				binds = append(binds, reflect.Indirect(v.Index(i)).Field(j).Interface())
-			}
+	for _, f := range fields {
+		fv := f.value(v)
 
-			placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
+		if f.omit(fv) {
+			continue
 		}
 
-		sql = fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	case Postgres:
-		bindIndex := 0
+		binds = append(binds, fv.Interface())
 
-		for i := 0; i < count; i++ {
-			phrs := make([]string, 0, fieldNum)
+		columns = append(columns, d.quote(f.column))
+		placeholders = append(placeholders, d.placeholder(len(binds)))
+	}
 
-			for j := 0; j < fieldNum; j++ {
-				column := t.Field(j).Tag.Get("db")
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)%s", d.quote(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "), d.returning())
 
-				if column == "-" {
-					continue
-				}
+	return sql, binds
+}
 
-				bindIndex++
+func batchInsertWithMap(driver Driver, table string, data []X, count int) (string, []interface{}) {
+	d := driver.dialect()
 
-				if i == 0 {
-					if column == "" {
-						column = t.Field(j).Name
-					}
+	fieldNum := len(data[0])
 
-					columns = append(columns, fmt.Sprintf(`"%s"`, column))
-				}
+	fields := make([]string, 0, fieldNum)
+	columns := make([]string, 0, fieldNum)
+	placeholders := make([]string, 0, fieldNum)
+	binds := make([]interface{}, 0, fieldNum*count)
 
-				phrs = append(phrs, fmt.Sprintf("$%d", bindIndex))
-				binds = append(binds, reflect.Indirect(v.Index(i)).Field(j).Interface())
-			}
+	for k := range data[0] {
+		fields = append(fields, k)
+
+		columns = append(columns, d.quote(k))
+	}
+
+	for _, x := range data {
+		phrs := make([]string, 0, fieldNum)
+
+		for _, v := range fields {
+			binds = append(binds, x[v])
+
+			phrs = append(phrs, d.placeholder(len(binds)))
+		}
+
+		placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.quote(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	return sql, binds
+}
+
+func batchInsertWithStruct(driver Driver, table string, v reflect.Value, count int) (string, []interface{}) {
+	d := driver.dialect()
+
+	fields := structFields(reflect.Indirect(v.Index(0)).Type())
+
+	fieldNum := len(fields)
+
+	columns := make([]string, 0, fieldNum)
+	placeholders := make([]string, 0, count)
+	binds := make([]interface{}, 0, fieldNum*count)
+
+	for _, f := range fields {
+		columns = append(columns, d.quote(f.column))
+	}
+
+	for i := 0; i < count; i++ {
+		row := reflect.Indirect(v.Index(i))
 
-			placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
+		phrs := make([]string, 0, fieldNum)
+
+		for _, f := range fields {
+			binds = append(binds, f.value(row).Interface())
+
+			phrs = append(phrs, d.placeholder(len(binds)))
 		}
 
-		sql = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES %s`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		placeholders = append(placeholders, fmt.Sprintf("(%s)", strings.Join(phrs, ", ")))
 	}
 
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.quote(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
 	return sql, binds
 }
 
-func updateWithMap(driver Driver, query string, data X, args ...interface{}) (string, []interface{}) {
+func updateWithMap(driver Driver, query string, data X, filter updateFilter, args ...interface{}) (string, []interface{}) {
+	d := driver.dialect()
+
 	dataLen := len(data)
-	argsLen := len(args)
 
-	sql := ""
 	sets := make([]string, 0, dataLen)
-	binds := make([]interface{}, 0, dataLen+argsLen)
+	binds := make([]interface{}, 0, dataLen+len(args))
 
-	switch driver {
-	case MySQL:
-		for k, v := range data {
-			sets = append(sets, fmt.Sprintf("`%s` = ?", k))
-			binds = append(binds, v)
+	for k, v := range data {
+		if filter != nil && !filter(k, reflect.ValueOf(v)) {
+			continue
 		}
 
-		sql = strings.Replace(query, "?", strings.Join(sets, ", "), 1)
-		binds = append(binds, args...)
-	case Postgres:
-		bindIndex := 0
+		binds = append(binds, v)
 
-		for k, v := range data {
-			bindIndex++
+		sets = append(sets, fmt.Sprintf("%s = %s", d.quote(k), d.placeholder(len(binds))))
+	}
+
+	return buildUpdate(d, query, sets, binds, args...)
+}
+
+func updateWithStruct(driver Driver, query string, v reflect.Value, filter updateFilter, args ...interface{}) (string, []interface{}) {
+	d := driver.dialect()
+
+	fields := structFields(v.Type())
+
+	sets := make([]string, 0, len(fields))
+	binds := make([]interface{}, 0, len(fields)+len(args))
+
+	for _, f := range fields {
+		fv := f.value(v)
 
-			sets = append(sets, fmt.Sprintf(`"%s" = $%d`, k, bindIndex))
-			binds = append(binds, v)
+		if f.omit(fv) {
+			continue
 		}
 
+		if filter != nil && !filter(f.column, fv) {
+			continue
+		}
+
+		binds = append(binds, fv.Interface())
+
+		sets = append(sets, fmt.Sprintf("%s = %s", d.quote(f.column), d.placeholder(len(binds))))
+	}
+
+	return buildUpdate(d, query, sets, binds, args...)
+}
+
+// buildUpdate replaces the first placeholder of query with the `SET` clause,
+// and renumbers the placeholders of args (if the dialect numbers them) to follow the `SET` binds.
+// Returns an empty sql if there are no columns to update.
+func buildUpdate(d dialect, query string, sets []string, binds []interface{}, args ...interface{}) (string, []interface{}) {
+	if len(sets) == 0 {
+		return "", binds
+	}
+
+	setLen := len(binds)
+	argsLen := len(args)
+
+	if d.placeholder(1) != d.placeholder(2) {
 		oldnew := make([]string, 0, argsLen*2)
 
 		for i := 1; i <= argsLen; i++ {
-			oldnew = append(oldnew, fmt.Sprintf("$%d", i+1), fmt.Sprintf("$%d", dataLen+i))
+			oldnew = append(oldnew, d.placeholder(i+1), d.placeholder(setLen+i))
 		}
 
 		r := strings.NewReplacer(oldnew...)
 		query = r.Replace(query)
-
-		sql = strings.Replace(query, "$1", strings.Join(sets, ", "), 1)
-		binds = append(binds, args...)
 	}
 
+	sql := strings.Replace(query, d.placeholder(1), strings.Join(sets, ", "), 1)
+	binds = append(binds, args...)
+
 	return sql, binds
 }
 
-func updateWithStruct(driver Driver, query string, v reflect.Value, args ...interface{}) (string, []interface{}) {
-	fieldNum := v.NumField()
-	argsLen := len(args)
-
-	sql := ""
-	sets := make([]string, 0, fieldNum)
-	binds := make([]interface{}, 0, fieldNum+argsLen)
+// InsertAndFetch inserts a single row into table and reads it back into dest within a transaction,
+// so the columns filled by db defaults (eg: `created_at`) are returned as well.
+// The row is read back by the primary key `id`, which is the `LastInsertId` for mysql and `RETURNING "id"` for postgres.
+// param data expects: `struct`, `*struct`, `yiigo.X`.
+func InsertAndFetch(ctx context.Context, db *sqlx.DB, table string, data interface{}, dest interface{}) error {
+	driver := driverOf(db)
+	d := driver.dialect()
 
-	t := v.Type()
+	query, binds := insertSQL(driver, table, data)
+	fetch := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", selectColumns(driver, dest), d.quote(table), d.quote("id"), d.placeholder(1))
 
-	switch driver {
-	case MySQL:
-		for i := 0; i < fieldNum; i++ {
-			column := t.Field(i).Tag.Get("db")
+	err := DBTransaction(ctx, db, func(ctx context.Context, tx *sqlx.Tx) error {
+		var id int64
 
-			if column == "-" {
-				continue
+		if driver == Postgres {
+			if err := tx.QueryRowxContext(ctx, query, binds...).Scan(&id); err != nil {
+				return err
 			}
 
-			if column == "" {
-				column = t.Field(i).Name
-			}
+			return tx.GetContext(ctx, dest, fetch, id)
+		}
 
-			sets = append(sets, fmt.Sprintf("`%s` = ?", column))
-			binds = append(binds, v.Field(i).Interface())
+		r, err := tx.ExecContext(ctx, query, binds...)
+
+		if err != nil {
+			return err
 		}
 
-		sql = strings.Replace(query, "?", strings.Join(sets, ", "), 1)
-		binds = append(binds, args...)
-	case Postgres:
-		bindIndex := 0
+		id, err = r.LastInsertId()
 
-		for i := 0; i < fieldNum; i++ {
-			column := t.Field(i).Tag.Get("db")
+		if err != nil {
+			return err
+		}
 
-			if column == "-" {
-				continue
-			}
+		return tx.GetContext(ctx, dest, fetch, id)
+	})
 
-			bindIndex++
+	return dbError(err)
+}
 
-			if column == "" {
-				column = t.Field(i).Name
-			}
+// FindMaps executes a query and scans each row into a yiigo.X, the `[]byte` column values are converted to `string`.
+// param db expects: `*sqlx.DB`, `*sqlx.Tx`.
+func FindMaps(db sqlx.Queryer, query string, args ...interface{}) ([]X, error) {
+	rows, err := db.Queryx(query, args...)
 
-			sets = append(sets, fmt.Sprintf(`"%s" = $%d`, column, bindIndex))
-			binds = append(binds, v.Field(i).Interface())
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	result := make([]X, 0)
+
+	for rows.Next() {
+		m := make(map[string]interface{})
+
+		if err := rows.MapScan(m); err != nil {
+			return nil, dbError(err)
 		}
 
-		oldnew := make([]string, 0, argsLen*2)
+		result = append(result, bytesToString(m))
+	}
 
-		for i := 1; i <= argsLen; i++ {
-			oldnew = append(oldnew, fmt.Sprintf("$%d", i+1), fmt.Sprintf("$%d", bindIndex+i))
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return result, nil
+}
+
+// FindOneMap executes a query and scans the first row into a yiigo.X, the `[]byte` column values are converted to `string`.
+// Returns sql.ErrNoRows (coded 404, see IsDBNotFound) if the query selects no rows.
+// param db expects: `*sqlx.DB`, `*sqlx.Tx`.
+func FindOneMap(db sqlx.Queryer, query string, args ...interface{}) (X, error) {
+	m := make(map[string]interface{})
+
+	if err := db.QueryRowx(query, args...).MapScan(m); err != nil {
+		return nil, dbError(err)
+	}
+
+	return bytesToString(m), nil
+}
+
+// ErrDBMultipleRows returned by FindOneStrict when the query selects more than one row.
+var ErrDBMultipleRows = errors.New("yiigo: sql: query returned more than one row")
+
+// FindOneStrict scans the only row selected by the query into dest, like sqlx `Get` but without masking duplicates.
+// Returns sql.ErrNoRows (coded 404, see IsDBNotFound) if no rows selected and ErrDBMultipleRows if more than one,
+// so make sure the query has no `LIMIT 1`.
+// param db expects: `*sqlx.DB`, `*sqlx.Tx`.
+func FindOneStrict(db sqlx.Queryer, dest interface{}, query string, args ...interface{}) error {
+	return dbError(findOneStrict(db, dest, query, args...))
+}
+
+func findOneStrict(db sqlx.Queryer, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.Queryx(query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
 		}
 
-		r := strings.NewReplacer(oldnew...)
-		query = r.Replace(query)
+		return sql.ErrNoRows
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(dest))
 
-		sql = strings.Replace(query, "$1", strings.Join(sets, ", "), 1)
-		binds = append(binds, args...)
+	// struct scans by columns, except a scanner (eg: sql.NullString) or time.Time
+	if _, ok := dest.(sql.Scanner); !ok && v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}) {
+		err = rows.StructScan(dest)
+	} else {
+		err = rows.Scan(dest)
 	}
 
-	return sql, binds
+	if err != nil {
+		return err
+	}
+
+	if rows.Next() {
+		return ErrDBMultipleRows
+	}
+
+	return rows.Err()
+}
+
+// bytesToString converts the `[]byte` values of a scanned row to `string`,
+// since drivers (eg: mysql) return text columns as raw bytes when scanning into interface{}.
+func bytesToString(m map[string]interface{}) X {
+	x := make(X, len(m))
+
+	for k, v := range m {
+		if b, ok := v.([]byte); ok {
+			x[k] = string(b)
+
+			continue
+		}
+
+		x[k] = v
+	}
+
+	return x
+}
+
+// TruncateTable empties the table.
+func TruncateTable(db *sqlx.DB, table string) error {
+	_, err := db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", driverOf(db).dialect().quote(table)))
+
+	return dbError(err)
+}
+
+// TableExists reports whether the table exists in the current database (mysql) or schema (postgres).
+func TableExists(db *sqlx.DB, table string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+
+	if driverOf(db) == Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	}
+
+	var count int
+
+	if err := db.Get(&count, query, table); err != nil {
+		return false, dbError(err)
+	}
+
+	return count > 0, nil
+}
+
+// TableColumns returns the column names of the table in ordinal position.
+func TableColumns(db *sqlx.DB, table string) ([]string, error) {
+	query := "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+
+	if driverOf(db) == Postgres {
+		query = "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position"
+	}
+
+	columns := make([]string, 0)
+
+	if err := db.Select(&columns, query, table); err != nil {
+		return nil, dbError(err)
+	}
+
+	return columns, nil
+}
+
+// driverOf returns the driver of a registered db.
+func driverOf(db *sqlx.DB) Driver {
+	if db.DriverName() == "postgres" {
+		return Postgres
+	}
+
+	return MySQL
+}
+
+// mysqlDuplicateKeyRegexp matches the key name of mysql error 1062,
+// eg: "Duplicate entry 'foo' for key 'uniq_name'" or "Duplicate entry 'foo' for key 'user.uniq_name'" (MySQL 8.0).
+var mysqlDuplicateKeyRegexp = regexp.MustCompile(`for key '(?:[^'.]+\.)?([^']+)'$`)
+
+// IsDuplicateKey reports whether err is a unique constraint violation,
+// which is mysql error 1062 (ER_DUP_ENTRY) or postgres error 23505 (unique_violation).
+func IsDuplicateKey(err error) bool {
+	switch e := driverError(err).(type) {
+	case *mysql.MySQLError:
+		return e.Number == 1062
+	case *pq.Error:
+		return e.Code == "23505"
+	}
+
+	return false
+}
+
+// DuplicateKeyName returns the name of the violated unique key (index or constraint) if err is a unique constraint violation,
+// otherwise returns an empty string.
+func DuplicateKeyName(err error) string {
+	if !IsDuplicateKey(err) {
+		return ""
+	}
+
+	switch e := driverError(err).(type) {
+	case *mysql.MySQLError:
+		if m := mysqlDuplicateKeyRegexp.FindStringSubmatch(e.Message); len(m) == 2 {
+			return m[1]
+		}
+	case *pq.Error:
+		return e.Constraint
+	}
+
+	return ""
+}
+
+// IsDBNotFound reports whether err is sql.ErrNoRows, which the db helpers return annotated with the code 404.
+func IsDBNotFound(err error) bool {
+	return ErrorCause(err) == sql.ErrNoRows
+}
+
+// dbError annotates the error of db helpers with the stack trace and the code of response,
+// which is 404 (http.StatusNotFound) for sql.ErrNoRows and 409 (http.StatusConflict) for a duplicate key.
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+
+	code := 0
+
+	switch {
+	case err == sql.ErrNoRows:
+		code = http.StatusNotFound
+	case IsDuplicateKey(err):
+		code = http.StatusConflict
+	}
+
+	return newError(err, "", code)
+}
+
+// driverError returns the mysql or postgres error wrapped by err (eg: yiigo.Error or fmt.Errorf with `%w`),
+// otherwise returns err itself.
+func driverError(err error) error {
+	for e := err; e != nil; {
+		switch e.(type) {
+		case *mysql.MySQLError, *pq.Error:
+			return e
+		}
+
+		u, ok := e.(interface{ Unwrap() error })
+
+		if !ok {
+			break
+		}
+
+		e = u.Unwrap()
+	}
+
+	return err
 }
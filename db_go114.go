@@ -0,0 +1,13 @@
+//go:build !go1.15
+// +build !go1.15
+
+package yiigo
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// setDBConnMaxIdleTime is a no-op, since sql.DB.SetConnMaxIdleTime is added in Go1.15.
+func setDBConnMaxIdleTime(db *sqlx.DB, d time.Duration) {}
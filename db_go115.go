@@ -0,0 +1,14 @@
+//go:build go1.15
+// +build go1.15
+
+package yiigo
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func setDBConnMaxIdleTime(db *sqlx.DB, d time.Duration) {
+	db.SetConnMaxIdleTime(d)
+}
@@ -1,8 +1,21 @@
 package yiigo
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func TestInsertSQL(t *testing.T) {
@@ -73,6 +86,115 @@ func TestInsertSQL(t *testing.T) {
 	}
 }
 
+func TestInsertIgnoreSQL(t *testing.T) {
+	type Person struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	got, got1 := InsertIgnoreSQL("person", []Person{{ID: 1, Name: "IIInsomnia"}, {ID: 2, Name: "test"}})
+
+	if want := "INSERT IGNORE INTO `person` (`id`, `name`) VALUES (?, ?), (?, ?)"; got != want {
+		t.Errorf("InsertIgnoreSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{1, "IIInsomnia", 2, "test"}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("InsertIgnoreSQL() got1 = %v, want %v", got1, want1)
+	}
+}
+
+func TestReplaceSQL(t *testing.T) {
+	got, got1 := ReplaceSQL("person", X{"id": 1})
+
+	if want := "REPLACE INTO `person` (`id`) VALUES (?)"; got != want {
+		t.Errorf("ReplaceSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{1}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("ReplaceSQL() got1 = %v, want %v", got1, want1)
+	}
+}
+
+func TestInsertSQLOmitEmpty(t *testing.T) {
+	type Person struct {
+		ID        int       `db:"id,omitempty"`
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at,omitempty"`
+	}
+
+	got, got1 := InsertSQL("person", &Person{Name: "IIInsomnia"})
+
+	if want := "INSERT INTO `person` (`name`) VALUES (?)"; got != want {
+		t.Errorf("InsertSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{"IIInsomnia"}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("InsertSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	got, _ = InsertSQL("person", []Person{{Name: "IIInsomnia"}})
+
+	if want := "INSERT INTO `person` (`id`, `name`, `created_at`) VALUES (?, ?, ?)"; got != want {
+		t.Errorf("InsertSQL() batch got = %v, want %v", got, want)
+	}
+
+	got, _ = UpdateSQL("UPDATE `person` SET ? WHERE `id` = ?", Person{Name: "test"}, 1)
+
+	if want := "UPDATE `person` SET `name` = ? WHERE `id` = ?"; got != want {
+		t.Errorf("UpdateSQL() got = %v, want %v", got, want)
+	}
+}
+
+func TestInsertSQLEmbedded(t *testing.T) {
+	type timestamps struct {
+		CreatedAt time.Time `db:"created_at"`
+	}
+	type Person struct {
+		timestamps
+		ID     int    `db:"id"`
+		Name   string `db:"name"`
+		remark string
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p := &Person{timestamps: timestamps{CreatedAt: now}, ID: 1, Name: "IIInsomnia", remark: "test"}
+
+	got, got1 := InsertSQL("person", p)
+
+	if want := "INSERT INTO `person` (`created_at`, `id`, `name`) VALUES (?, ?, ?)"; got != want {
+		t.Errorf("InsertSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{now, 1, "IIInsomnia"}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("InsertSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	got, got1 = PGInsertSQL("person", []Person{*p})
+
+	if want := `INSERT INTO "person" ("created_at", "id", "name") VALUES ($1, $2, $3)`; got != want {
+		t.Errorf("PGInsertSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{now, 1, "IIInsomnia"}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("PGInsertSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	got, got1 = UpdateSQL("UPDATE `person` SET ? WHERE `id` = ?", p, 1)
+
+	if want := "UPDATE `person` SET `created_at` = ?, `id` = ?, `name` = ? WHERE `id` = ?"; got != want {
+		t.Errorf("UpdateSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{now, 1, "IIInsomnia", 1}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("UpdateSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	if got := SelectColumns(p); got != "`created_at`, `id`, `name`" {
+		t.Errorf("SelectColumns() = %v, want `created_at`, `id`, `name`", got)
+	}
+}
+
 func TestUpdateSQL(t *testing.T) {
 	type args struct {
 		query string
@@ -118,6 +240,55 @@ func TestUpdateSQL(t *testing.T) {
 	}
 }
 
+func TestUpdateFieldsSQL(t *testing.T) {
+	type Person struct {
+		ID     int    `db:"id"`
+		Name   string `db:"name"`
+		Gender string `db:"gender"`
+		Age    int    `db:"age"`
+	}
+
+	p := &Person{ID: 1, Name: "IIInsomnia", Age: 0}
+
+	got, got1 := UpdateFieldsSQL("UPDATE `person` SET ? WHERE `id` = ?", p, []string{"name", "age"}, 1)
+
+	if want := "UPDATE `person` SET `name` = ?, `age` = ? WHERE `id` = ?"; got != want {
+		t.Errorf("UpdateFieldsSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{"IIInsomnia", 0, 1}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("UpdateFieldsSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	got, got1 = PGUpdateNonZeroSQL(`UPDATE "person" SET $1 WHERE "id" = $2`, p, 1)
+
+	if want := `UPDATE "person" SET "id" = $1, "name" = $2 WHERE "id" = $3`; got != want {
+		t.Errorf("PGUpdateNonZeroSQL() got = %v, want %v", got, want)
+	}
+
+	if want1 := []interface{}{1, "IIInsomnia", 1}; !reflect.DeepEqual(got1, want1) {
+		t.Errorf("PGUpdateNonZeroSQL() got1 = %v, want %v", got1, want1)
+	}
+
+	got, _ = UpdateNonZeroSQL("UPDATE `person` SET ? WHERE `id` = ?", X{"name": "", "age": 30, "gender": nil}, 1)
+
+	if want := "UPDATE `person` SET `age` = ? WHERE `id` = ?"; got != want {
+		t.Errorf("UpdateNonZeroSQL() got = %v, want %v", got, want)
+	}
+
+	got, got1 = PGUpdateNonZeroSQL(`UPDATE "person" SET $1 WHERE "id" = $2`, X{"name": ""}, 1)
+
+	if got != "" || len(got1) != 0 {
+		t.Errorf("PGUpdateNonZeroSQL() got = %v, got1 = %v, want empty", got, got1)
+	}
+
+	got, got1 = UpdateFieldsSQL("UPDATE `person` SET ? WHERE `id` = ?", p, []string{"remark"}, 1)
+
+	if got != "" || len(got1) != 0 {
+		t.Errorf("UpdateFieldsSQL() got = %v, got1 = %v, want empty", got, got1)
+	}
+}
+
 func TestPGInsertSQL(t *testing.T) {
 	type args struct {
 		table string
@@ -229,3 +400,683 @@ func TestPGUpdateSQL(t *testing.T) {
 		})
 	}
 }
+
+func Test_bytesToString(t *testing.T) {
+	type args struct {
+		m map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want X
+	}{
+		{
+			name: "t1",
+			args: args{
+				m: map[string]interface{}{
+					"id":     int64(1),
+					"name":   []byte("IIInsomnia"),
+					"gender": []byte("M"),
+					"age":    nil,
+				},
+			},
+			want: X{
+				"id":     int64(1),
+				"name":   "IIInsomnia",
+				"gender": "M",
+				"age":    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bytesToString(tt.args.m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bytesToString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	type Base struct {
+		ID int `db:"id"`
+	}
+	type Person struct {
+		Base
+		Name   string `db:"name"`
+		Gender string `db:"gender"`
+		Age    int    `db:"age"`
+		Remark string `db:"-"`
+	}
+	type args struct {
+		dest interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{dest: &Person{}},
+			want: "`id`, `name`, `gender`, `age`",
+		},
+		{
+			name: "t2",
+			args: args{dest: &[]*Person{}},
+			want: "`id`, `name`, `gender`, `age`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectColumns(tt.args.dest); got != tt.want {
+				t.Errorf("SelectColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPGSelectColumns(t *testing.T) {
+	type Person struct {
+		ID     int    `db:"id"`
+		Name   string `db:"name"`
+		Gender string `db:"gender"`
+		Age    int    `db:"age"`
+	}
+	type args struct {
+		dest interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{dest: []Person{}},
+			want: `"id", "name", "gender", "age"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PGSelectColumns(tt.args.dest); got != tt.want {
+				t.Errorf("PGSelectColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeyName(t *testing.T) {
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'IIInsomnia' for key 'uniq_name'"}},
+			want: "uniq_name",
+		},
+		{
+			name: "t2",
+			args: args{err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'IIInsomnia' for key 'person.uniq_name'"}},
+			want: "uniq_name",
+		},
+		{
+			name: "t3",
+			args: args{err: &pq.Error{Code: "23505", Constraint: "person_name_key"}},
+			want: "person_name_key",
+		},
+		{
+			name: "t4",
+			args: args{err: &mysql.MySQLError{Number: 1045, Message: "Access denied for user 'root'@'localhost'"}},
+			want: "",
+		},
+		{
+			name: "t5",
+			args: args{err: errors.New("Duplicate entry")},
+			want: "",
+		},
+		{
+			name: "t6",
+			args: args{err: WrapError(&pq.Error{Code: "23505", Constraint: "person_name_key"}, "insert person")},
+			want: "person_name_key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DuplicateKeyName(tt.args.err); got != tt.want {
+				t.Errorf("DuplicateKeyName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dbError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantCode     int
+		wantNotFound bool
+		wantDup      bool
+	}{
+		{name: "t1", err: sql.ErrNoRows, wantCode: 404, wantNotFound: true},
+		{name: "t2", err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'IIInsomnia' for key 'uniq_name'"}, wantCode: 409, wantDup: true},
+		{name: "t3", err: &pq.Error{Code: "23505", Constraint: "person_name_key"}, wantCode: 409, wantDup: true},
+		{name: "t4", err: errors.New("connection refused"), wantCode: 0},
+		{name: "t5", err: ErrorWithCode(sql.ErrNoRows, 400), wantCode: 400, wantNotFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dbError(tt.err)
+
+			if got := ErrorCode(err); got != tt.wantCode {
+				t.Errorf("dbError() code = %d, want %d", got, tt.wantCode)
+			}
+
+			if got := IsDBNotFound(err); got != tt.wantNotFound {
+				t.Errorf("IsDBNotFound() = %v, want %v", got, tt.wantNotFound)
+			}
+
+			if got := IsDuplicateKey(err); got != tt.wantDup {
+				t.Errorf("IsDuplicateKey() = %v, want %v", got, tt.wantDup)
+			}
+		})
+	}
+
+	if err := dbError(nil); err != nil {
+		t.Errorf("dbError(nil) = %v, want nil", err)
+	}
+}
+
+func TestDBFanOutError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    DBFanOutError
+		want string
+	}{
+		{
+			name: "t1",
+			e: DBFanOutError{
+				"foo": errors.New("connection refused"),
+				"bar": errors.New("i/o timeout"),
+			},
+			want: "yiigo: db fan-out error: db.bar: i/o timeout; db.foo: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("DBFanOutError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDBMapper(t *testing.T) {
+	type Person struct {
+		ID        int    `json:"id"`
+		UserName  string `json:"user_name"`
+		CreatedAt int64
+		Remark    string `json:"-"`
+	}
+
+	SetDBMapper("json", SnakeCase)
+	defer SetDBMapper("db", nil)
+
+	want := "`id`, `user_name`, `created_at`"
+
+	if got := SelectColumns(&Person{}); got != want {
+		t.Errorf("SelectColumns() = %v, want %v", got, want)
+	}
+}
+
+func Test_isTxRetryable(t *testing.T) {
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "t1",
+			args: args{err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}},
+			want: true,
+		},
+		{
+			name: "t2",
+			args: args{err: &pq.Error{Code: "40001"}},
+			want: true,
+		},
+		{
+			name: "t3",
+			args: args{err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'IIInsomnia' for key 'uniq_name'"}},
+			want: false,
+		},
+		{
+			name: "t4",
+			args: args{err: errors.New("deadlock")},
+			want: false,
+		},
+		{
+			name: "t5",
+			args: args{err: WrapError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}, "update stock")},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTxRetryable(tt.args.err); got != tt.want {
+				t.Errorf("isTxRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mysqlDSNWithLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+
+	if err != nil {
+		t.Skip(err)
+	}
+
+	got, err := mysqlDSNWithLocation("root:root@tcp(localhost:3306)/test?parseTime=true&loc=Local", loc)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "root:root@tcp(localhost:3306)/test?loc=Asia%2FShanghai&parseTime=true"; got != want {
+		t.Errorf("mysqlDSNWithLocation() = %s, want %s", got, want)
+	}
+}
+
+// flakyDBDriver fails to open the first `failures` connections.
+type flakyDBDriver struct {
+	failures int32
+	opens    int32
+}
+
+func (d *flakyDBDriver) Open(name string) (driver.Conn, error) {
+	if atomic.AddInt32(&d.opens, 1) <= d.failures {
+		return nil, errors.New("connection refused")
+	}
+
+	return flakyDBConn{}, nil
+}
+
+type flakyDBConn struct{}
+
+func (flakyDBConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (flakyDBConn) Close() error              { return nil }
+func (flakyDBConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+// scriptDBDriver opens connections which record the statements and answer the queries with the scripted rows.
+type scriptDBDriver struct {
+	mutex    sync.Mutex
+	opens    int
+	stmts    []string
+	insertID int64
+	rows     func(query string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+func (d *scriptDBDriver) Open(name string) (driver.Conn, error) {
+	d.mutex.Lock()
+	d.opens++
+	d.mutex.Unlock()
+
+	return &scriptDBConn{driver: d}, nil
+}
+
+func (d *scriptDBDriver) record(query string) {
+	d.mutex.Lock()
+	d.stmts = append(d.stmts, query)
+	d.mutex.Unlock()
+}
+
+func (d *scriptDBDriver) statements() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return append([]string{}, d.stmts...)
+}
+
+type scriptDBConn struct {
+	driver *scriptDBDriver
+}
+
+func (c *scriptDBConn) Prepare(query string) (driver.Stmt, error) {
+	return &scriptDBStmt{driver: c.driver, query: query}, nil
+}
+func (c *scriptDBConn) Close() error              { return nil }
+func (c *scriptDBConn) Begin() (driver.Tx, error) { return scriptDBTx{}, nil }
+
+type scriptDBTx struct{}
+
+func (scriptDBTx) Commit() error   { return nil }
+func (scriptDBTx) Rollback() error { return nil }
+
+type scriptDBStmt struct {
+	driver *scriptDBDriver
+	query  string
+}
+
+func (s *scriptDBStmt) Close() error  { return nil }
+func (s *scriptDBStmt) NumInput() int { return -1 }
+
+func (s *scriptDBStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.record(s.query)
+
+	return scriptDBResult(s.driver.insertID), nil
+}
+
+func (s *scriptDBStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.record(s.query)
+
+	rows := &scriptDBRows{}
+
+	if s.driver.rows != nil {
+		rows.columns, rows.values = s.driver.rows(s.query, args)
+	}
+
+	return rows, nil
+}
+
+type scriptDBResult int64
+
+func (r scriptDBResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r scriptDBResult) RowsAffected() (int64, error) { return 1, nil }
+
+type scriptDBRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *scriptDBRows) Columns() []string { return r.columns }
+func (r *scriptDBRows) Close() error      { return nil }
+
+func (r *scriptDBRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+func TestDBConnectRetry(t *testing.T) {
+	d := &flakyDBDriver{failures: 2}
+
+	sql.Register("yiigo-flaky", d)
+
+	if _, err := dbConnect("yiigo-flaky", "", &dbOptions{retryAttempts: 2, retryInterval: time.Millisecond}); err == nil {
+		t.Fatal("dbConnect() with 2 attempts, want error")
+	}
+
+	atomic.StoreInt32(&d.opens, 0)
+
+	db, err := dbConnect("yiigo-flaky", "", &dbOptions{retryAttempts: 3, retryInterval: time.Millisecond})
+
+	if err != nil {
+		t.Fatalf("dbConnect() with 3 attempts error = %v", err)
+	}
+
+	db.Close()
+
+	if opens := atomic.LoadInt32(&d.opens); opens != 3 {
+		t.Errorf("dbConnect() opens = %d, want 3", opens)
+	}
+}
+
+func TestCloseDB(t *testing.T) {
+	db := sqlx.NewDb(sql.OpenDB(&dbConnector{driver: &flakyDBDriver{}}), "mysql")
+
+	dbmap.Store(AsDefault, db)
+	DB = db
+
+	if err := CloseDB(context.Background(), AsDefault); err != nil {
+		t.Fatalf("CloseDB() error = %v", err)
+	}
+
+	if DB != nil {
+		t.Error("CloseDB() yiigo.DB is not nil after closing the default db")
+	}
+
+	if _, ok := dbmap.Load(AsDefault); ok {
+		t.Error("CloseDB() the default db is still registered")
+	}
+}
+
+func Test_mysqlDSNHasLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want bool
+	}{
+		{name: "t1", dsn: "root:secret@tcp(127.0.0.1:3306)/test?charset=utf8mb4&parseTime=True", want: false},
+		{name: "t2", dsn: "root:secret@tcp(127.0.0.1:3306)/test?charset=utf8mb4&loc=Local", want: true},
+		{name: "t3", dsn: "root:se?loc=x@tcp(127.0.0.1:3306)/test", want: false},
+		{name: "t4", dsn: "root:secret@tcp(127.0.0.1:3306)/test", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysqlDSNHasLocation(tt.dsn); got != tt.want {
+				t.Errorf("mysqlDSNHasLocation() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertAndFetch(t *testing.T) {
+	type Person struct {
+		ID        int64  `db:"id"`
+		Name      string `db:"name"`
+		CreatedAt string `db:"created_at"`
+	}
+	tests := []struct {
+		name       string
+		driverName string
+		insertID   int64
+		wantInsert string
+	}{
+		{name: "t1", driverName: "mysql", insertID: 42, wantInsert: "INSERT INTO `person` (`name`) VALUES (?)"},
+		{name: "t2", driverName: "postgres", wantInsert: `INSERT INTO "person" ("name") VALUES ($1) RETURNING "id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &scriptDBDriver{
+				insertID: tt.insertID,
+				rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+					if strings.HasPrefix(query, "INSERT") {
+						return []string{"id"}, [][]driver.Value{{int64(42)}}
+					}
+
+					// the row is read back by the inserted id only
+					if len(args) != 1 || args[0] != int64(42) {
+						return []string{"id", "name", "created_at"}, nil
+					}
+
+					return []string{"id", "name", "created_at"}, [][]driver.Value{{int64(42), "IIInsomnia", "2020-01-01 00:00:00"}}
+				},
+			}
+
+			db := sqlx.NewDb(sql.OpenDB(&dbConnector{driver: d}), tt.driverName)
+
+			defer db.Close()
+
+			got := new(Person)
+
+			if err := InsertAndFetch(context.Background(), db, "person", X{"name": "IIInsomnia"}, got); err != nil {
+				t.Fatalf("InsertAndFetch() error = %v", err)
+			}
+
+			want := &Person{ID: 42, Name: "IIInsomnia", CreatedAt: "2020-01-01 00:00:00"}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("InsertAndFetch() got = %v, want %v", got, want)
+			}
+
+			if stmts := d.statements(); len(stmts) != 2 || stmts[0] != tt.wantInsert {
+				t.Errorf("InsertAndFetch() statements = %q, want insert %q", stmts, tt.wantInsert)
+			}
+		})
+	}
+}
+
+func TestFindOneStrict(t *testing.T) {
+	type Person struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	tests := []struct {
+		name         string
+		values       [][]driver.Value
+		want         *Person
+		wantErr      error
+		wantNotFound bool
+	}{
+		{name: "t1", values: [][]driver.Value{{int64(1), "IIInsomnia"}}, want: &Person{ID: 1, Name: "IIInsomnia"}},
+		{name: "t2", values: nil, want: &Person{}, wantErr: sql.ErrNoRows, wantNotFound: true},
+		{name: "t3", values: [][]driver.Value{{int64(1), "IIInsomnia"}, {int64(2), "shenghui"}}, want: &Person{ID: 1, Name: "IIInsomnia"}, wantErr: ErrDBMultipleRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &scriptDBDriver{
+				rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+					return []string{"id", "name"}, tt.values
+				},
+			}
+
+			db := sqlx.NewDb(sql.OpenDB(&dbConnector{driver: d}), "mysql")
+
+			defer db.Close()
+
+			got := new(Person)
+			err := FindOneStrict(db, got, "SELECT id, name FROM person WHERE name = ?", "IIInsomnia")
+
+			if cause := ErrorCause(err); cause != tt.wantErr {
+				t.Fatalf("FindOneStrict() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := IsDBNotFound(err); got != tt.wantNotFound {
+				t.Errorf("IsDBNotFound() = %v, want %v", got, tt.wantNotFound)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindOneStrict() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableHelpers(t *testing.T) {
+	tests := []struct {
+		name         string
+		driverName   string
+		wantTruncate string
+		wantExists   string
+		wantColumns  string
+	}{
+		{
+			name:         "t1",
+			driverName:   "mysql",
+			wantTruncate: "TRUNCATE TABLE `order`",
+			wantExists:   "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+			wantColumns:  "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position",
+		},
+		{
+			name:         "t2",
+			driverName:   "postgres",
+			wantTruncate: `TRUNCATE TABLE "order"`,
+			wantExists:   "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1",
+			wantColumns:  "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &scriptDBDriver{
+				rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+					// the table name is bound as an argument, never quoted into the query
+					if len(args) != 1 || args[0] != "order" {
+						t.Errorf("query `%s` args = %v, want [order]", query, args)
+					}
+
+					if strings.HasPrefix(query, "SELECT COUNT(*)") {
+						return []string{"COUNT(*)"}, [][]driver.Value{{int64(1)}}
+					}
+
+					return []string{"column_name"}, [][]driver.Value{{"id"}, {"user_id"}}
+				},
+			}
+
+			db := sqlx.NewDb(sql.OpenDB(&dbConnector{driver: d}), tt.driverName)
+
+			defer db.Close()
+
+			if err := TruncateTable(db, "order"); err != nil {
+				t.Fatalf("TruncateTable() error = %v", err)
+			}
+
+			exists, err := TableExists(db, "order")
+
+			if err != nil || !exists {
+				t.Errorf("TableExists() got = %v, %v, want true", exists, err)
+			}
+
+			columns, err := TableColumns(db, "order")
+
+			if err != nil || !reflect.DeepEqual(columns, []string{"id", "user_id"}) {
+				t.Errorf("TableColumns() got = %v, %v, want [id user_id]", columns, err)
+			}
+
+			want := []string{tt.wantTruncate, tt.wantExists, tt.wantColumns}
+
+			if stmts := d.statements(); !reflect.DeepEqual(stmts, want) {
+				t.Errorf("statements = %q, want %q", stmts, want)
+			}
+		})
+	}
+}
+
+func TestDBInitStatements(t *testing.T) {
+	d := new(scriptDBDriver)
+
+	db := sql.OpenDB(&dbConnector{driver: d, stmts: []string{"SET time_zone = '+08:00'", "SET sql_mode = 'STRICT_ALL_TABLES'"}})
+
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// hold both connections, so the second one is a new connection instead of the idle one
+	for i := 0; i < 2; i++ {
+		conn, err := db.Conn(ctx)
+
+		if err != nil {
+			t.Fatalf("db.Conn() error = %v", err)
+		}
+
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, "UPDATE user SET age = ?", 20); err != nil {
+			t.Fatalf("conn.ExecContext() error = %v", err)
+		}
+	}
+
+	want := []string{
+		"SET time_zone = '+08:00'", "SET sql_mode = 'STRICT_ALL_TABLES'", "UPDATE user SET age = ?",
+		"SET time_zone = '+08:00'", "SET sql_mode = 'STRICT_ALL_TABLES'", "UPDATE user SET age = ?",
+	}
+
+	if stmts := d.statements(); d.opens != 2 || !reflect.DeepEqual(stmts, want) {
+		t.Errorf("statements of %d connections = %q, want %q", d.opens, stmts, want)
+	}
+}
@@ -0,0 +1,218 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// dedupResponse the response recorded by the request executed, which is shared by the identical requests.
+type dedupResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (d *dedupResponse) Header() http.Header {
+	return d.header
+}
+
+func (d *dedupResponse) Write(b []byte) (int, error) {
+	if d.status == 0 {
+		d.status = http.StatusOK
+	}
+
+	return d.body.Write(b)
+}
+
+func (d *dedupResponse) WriteHeader(status int) {
+	if d.status == 0 {
+		d.status = status
+	}
+}
+
+// writeTo writes the response to w.
+func (d *dedupResponse) writeTo(w http.ResponseWriter) {
+	header := w.Header()
+
+	for k, v := range d.header {
+		header[k] = append([]string(nil), v...)
+	}
+
+	status := d.status
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	w.Write(d.body.Bytes())
+}
+
+// detachedContext keeps the values of parent but not its cancellation,
+// so the shared handler isn't canceled when the request which started it is gone.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// dedupOptions request deduplication options
+type dedupOptions struct {
+	maxBodySize int64
+	timeout     time.Duration
+}
+
+// DedupOption configures how we deduplicate the requests
+type DedupOption interface {
+	apply(options *dedupOptions)
+}
+
+// funcDedupOption implements dedup option
+type funcDedupOption struct {
+	f func(options *dedupOptions)
+}
+
+func (fo *funcDedupOption) apply(o *dedupOptions) {
+	fo.f(o)
+}
+
+func newFuncDedupOption(f func(options *dedupOptions)) *funcDedupOption {
+	return &funcDedupOption{f: f}
+}
+
+// WithDedupMaxBodySize specifies the maximum bytes of request body, which is read to hash, a larger one is responded 413.
+func WithDedupMaxBodySize(n int64) DedupOption {
+	return newFuncDedupOption(func(o *dedupOptions) {
+		o.maxBodySize = n
+	})
+}
+
+// WithDedupTimeout specifies the timeout of the shared handler, which isn't canceled by any single request.
+func WithDedupTimeout(d time.Duration) DedupOption {
+	return newFuncDedupOption(func(o *dedupOptions) {
+		o.timeout = d
+	})
+}
+
+// DedupRequests returns a middleware which collapses the identical concurrent requests (the same user, method, url and body hash)
+// into one, the others wait for it and share its response, eg: to protect the expensive report endpoints from double-clicks.
+// The user of request is returned by `user` (eg: the user id from session), and the request isn't deduplicated if it's empty,
+// so the responses are never shared between users.
+//
+// The shared handler runs with a context detached from the requests (keeping the values of the first one) and limited by the timeout,
+// so a request which disconnects only stops waiting, the others still get the response.
+// The response is buffered in memory, so it shouldn't be used for the streaming endpoints (eg: SSE and exports).
+//
+//	http.Handle("/reports", yiigo.DedupRequests(userID)(reportHandler))
+//
+// The default `MaxBodySize` is 1MB.
+// The default `Timeout` is 60s.
+func DedupRequests(user func(r *http.Request) string, options ...DedupOption) func(http.Handler) http.Handler {
+	o := &dedupOptions{
+		maxBodySize: 1 << 20,
+		timeout:     60 * time.Second,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	group := new(singleflight.Group)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uid := user(r)
+
+			if uid == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h := sha256.New()
+
+			if r.Body != nil {
+				body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, o.maxBodySize))
+
+				if err != nil {
+					status := http.StatusBadRequest
+
+					if int64(len(body)) >= o.maxBodySize {
+						status = http.StatusRequestEntityTooLarge
+					}
+
+					http.Error(w, http.StatusText(status), status)
+
+					return
+				}
+
+				r.Body.Close()
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+				h.Write(body)
+			}
+
+			key := uid + "\n" + r.Method + "\n" + r.URL.RequestURI() + "\n" + hex.EncodeToString(h.Sum(nil))
+
+			ch := group.DoChan(key, func() (interface{}, error) {
+				ctx, cancel := context.WithTimeout(detachedContext{parent: r.Context()}, o.timeout)
+
+				defer cancel()
+
+				resp := &dedupResponse{header: make(http.Header)}
+
+				// a panic is recovered, otherwise the identical requests would wait forever
+				err := callSafe(func() error {
+					next.ServeHTTP(resp, r.WithContext(ctx))
+
+					return nil
+				})
+
+				if err != nil {
+					logPanicError(err)
+
+					return nil, err
+				}
+
+				return resp, nil
+			})
+
+			select {
+			case <-r.Context().Done():
+				// the client is gone, the others still wait for the shared handler
+				return
+			case v := <-ch:
+				if v.Err != nil {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+					return
+				}
+
+				v.Val.(*dedupResponse).writeTo(w)
+			}
+		})
+	}
+}
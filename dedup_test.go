@@ -0,0 +1,160 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedupRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		users       []string
+		body        string
+		panics      bool
+		cancelFirst bool
+		wantCalls   int32
+		wantCodes   []int
+	}{
+		{
+			name:      "identical",
+			users:     []string{"1", "1", "1"},
+			body:      `{"month":"2024-01"}`,
+			wantCalls: 1,
+			wantCodes: []int{200, 200, 200},
+		},
+		{
+			name:      "user isolation",
+			users:     []string{"1", "2", "1", "2"},
+			body:      `{"month":"2024-01"}`,
+			wantCalls: 2,
+			wantCodes: []int{200, 200, 200, 200},
+		},
+		{
+			name:      "empty user passes through",
+			users:     []string{"", "", ""},
+			body:      `{"month":"2024-01"}`,
+			wantCalls: 3,
+			wantCodes: []int{200, 200, 200},
+		},
+		{
+			name:      "panic",
+			users:     []string{"1", "1"},
+			body:      `{"month":"2024-01"}`,
+			panics:    true,
+			wantCalls: 1,
+			wantCodes: []int{500, 500},
+		},
+		{
+			name:        "first client disconnects",
+			users:       []string{"1", "1", "1"},
+			body:        `{"month":"2024-01"}`,
+			cancelFirst: true,
+			wantCalls:   1,
+			wantCodes:   []int{0, 200, 200},
+		},
+		{
+			name:      "body too large",
+			users:     []string{"1"},
+			body:      strings.Repeat("x", 100),
+			wantCalls: 0,
+			wantCodes: []int{413},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+
+			started := make(chan struct{})
+			startOnce := sync.Once{}
+
+			handler := DedupRequests(func(r *http.Request) string {
+				return r.Header.Get("X-User")
+			}, WithDedupMaxBodySize(64))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+
+				startOnce.Do(func() { close(started) })
+
+				// hold the request, so the identical ones arrive while it's running
+				time.Sleep(50 * time.Millisecond)
+
+				if tt.panics {
+					panic("report failed")
+				}
+
+				if r.Context().Err() != nil {
+					w.WriteHeader(http.StatusServiceUnavailable)
+
+					return
+				}
+
+				w.Write([]byte("user:" + r.Header.Get("X-User")))
+			}))
+
+			var wg sync.WaitGroup
+
+			recorders := make([]*httptest.ResponseRecorder, len(tt.users))
+
+			for i, uid := range tt.users {
+				ctx, cancel := context.WithCancel(context.Background())
+
+				r := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(tt.body)).WithContext(ctx)
+				r.Header.Set("X-User", uid)
+
+				recorders[i] = httptest.NewRecorder()
+
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+					defer cancel()
+
+					handler.ServeHTTP(recorders[i], r)
+				}(i)
+
+				if i == 0 && tt.wantCalls > 0 {
+					// the first request starts the handler
+					<-started
+
+					if tt.cancelFirst {
+						cancel()
+					}
+				}
+			}
+
+			wg.Wait()
+
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("DedupRequests() calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			for i, w := range recorders {
+				want := tt.wantCodes[i]
+
+				if want == 0 {
+					// the disconnected client gets nothing
+					if w.Body.Len() > 0 {
+						t.Errorf("DedupRequests() response[%d] = %s, want empty", i, w.Body.String())
+					}
+
+					continue
+				}
+
+				if w.Code != want {
+					t.Errorf("DedupRequests() response[%d] code = %d, want %d", i, w.Code, want)
+				}
+
+				if want == http.StatusOK {
+					if body := "user:" + tt.users[i]; w.Body.String() != body {
+						t.Errorf("DedupRequests() response[%d] = %s, want %s", i, w.Body.String(), body)
+					}
+				}
+			}
+		})
+	}
+}
@@ -22,7 +22,7 @@ var Env *env
 // ErrEnvNil returned when config not found.
 var ErrEnvNil = errors.New("yiigo: env config not found")
 
-// UseEnv use `toml` config file.
+// UseEnv use `toml` config file, and applies `app.timezone` (see SetTimeLocation) and `app.json_codec` (see RegisterJSONCodec).
 func UseEnv(file string) error {
 	path, err := filepath.Abs(file)
 
@@ -38,7 +38,30 @@ func UseEnv(file string) error {
 
 	Env = &env{tree: tomlTree}
 
-	return nil
+	return useEnvSettings()
+}
+
+// UseEnvBytes use `toml` config content, eg: the config embedded into binary by `//go:embed env.toml`.
+// The timezone and JSON codec are applied as UseEnv does.
+func UseEnvBytes(b []byte) error {
+	tomlTree, err := toml.LoadBytes(b)
+
+	if err != nil {
+		return err
+	}
+
+	Env = &env{tree: tomlTree}
+
+	return useEnvSettings()
+}
+
+// useEnvSettings applies the settings of yiigo in env config.
+func useEnvSettings() error {
+	if err := useEnvTimeLocation(); err != nil {
+		return err
+	}
+
+	return useEnvJSONCodec()
 }
 
 // String returns a value of string.
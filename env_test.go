@@ -1023,3 +1023,13 @@ func Test_env_Unmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestUseEnvBytes(t *testing.T) {
+	if err := UseEnvBytes([]byte("[app]\nenv = \"embed\"\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Env.String("app.env"); got != "embed" {
+		t.Errorf("UseEnvBytes() app.env = %s, want embed", got)
+	}
+}
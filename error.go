@@ -0,0 +1,160 @@
+package yiigo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// Error an error with a code and the stack trace of where it's created, see WrapError, ErrorWithCode and ErrorWithStack.
+// Format it with `%+v` to print the stack trace.
+type Error struct {
+	code  int
+	msg   string
+	cause error
+	stack []uintptr
+}
+
+// Error returns the message of error.
+func (e *Error) Error() string {
+	if e.msg == "" {
+		return e.cause.Error()
+	}
+
+	return e.msg + ": " + e.cause.Error()
+}
+
+// Code returns the code of error, or the code of its cause if not set.
+func (e *Error) Code() int {
+	if e.code != 0 {
+		return e.code
+	}
+
+	return ErrorCode(e.cause)
+}
+
+// Cause returns the error wrapped.
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// Unwrap returns the error wrapped, for errors.Is and errors.As.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Format formats the error, `%+v` prints the stack trace.
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.Error())
+			io.WriteString(s, e.StackTrace())
+
+			return
+		}
+
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// StackTrace returns the stack trace of where the error is created.
+func (e *Error) StackTrace() string {
+	var b strings.Builder
+
+	frames := runtime.CallersFrames(e.stack)
+
+	for {
+		frame, more := frames.Next()
+
+		fmt.Fprintf(&b, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// newError returns a new Error of cause, the stack trace is inherited if cause has one.
+func newError(cause error, msg string, code int) *Error {
+	e := &Error{
+		code:  code,
+		msg:   msg,
+		cause: cause,
+	}
+
+	if v, ok := cause.(*Error); ok {
+		e.stack = v.stack
+	} else {
+		pcs := make([]uintptr, 32)
+		n := runtime.Callers(3, pcs)
+		e.stack = pcs[:n]
+	}
+
+	return e
+}
+
+// WrapError annotates err with msg and the stack trace, it returns nil if err is nil.
+func WrapError(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return newError(err, msg, 0)
+}
+
+// ErrorWithCode annotates err with code (eg: the error code of response) and the stack trace, it returns nil if err is nil.
+func ErrorWithCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+
+	return newError(err, "", code)
+}
+
+// ErrorWithStack annotates err with the stack trace, it returns err as is if it has one already, or nil if err is nil.
+func ErrorWithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+
+	return newError(err, "", 0)
+}
+
+// ErrorCode returns the code of err, or 0 if err has no code.
+func ErrorCode(err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.Code()
+	}
+
+	return 0
+}
+
+// ErrorCause returns the underlying error of err, which is not an Error.
+func ErrorCause(err error) error {
+	for {
+		e, ok := err.(*Error)
+
+		if !ok {
+			return err
+		}
+
+		err = e.cause
+	}
+}
+
+// httpStatusError returns the error of a non-200 response, whose code is the status code.
+func httpStatusError(code int) error {
+	return newError(fmt.Errorf("error http code: %d", code), "", code)
+}
@@ -0,0 +1,46 @@
+package yiigo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWrapError(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	err := WrapError(ErrorWithCode(cause, 50001), "query user")
+
+	if got := err.Error(); got != "query user: connection refused" {
+		t.Errorf("WrapError() = %s, want %s", got, "query user: connection refused")
+	}
+
+	if code := ErrorCode(err); code != 50001 {
+		t.Errorf("ErrorCode() = %d, want 50001", code)
+	}
+
+	if ErrorCause(err) != cause {
+		t.Errorf("ErrorCause() = %v, want %v", ErrorCause(err), cause)
+	}
+
+	if s := fmt.Sprintf("%+v", err); !strings.Contains(s, "TestWrapError") {
+		t.Errorf("WrapError() %%+v has no stack trace: %s", s)
+	}
+
+	if WrapError(nil, "nothing") != nil {
+		t.Error("WrapError(nil) != nil")
+	}
+
+	if s := ErrorWithStack(err); s != err {
+		t.Error("ErrorWithStack() re-captured the stack trace")
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	err := httpStatusError(502)
+
+	if err.Error() != "error http code: 502" || ErrorCode(err) != 502 {
+		t.Errorf("httpStatusError() = %v, code %d", err, ErrorCode(err))
+	}
+}
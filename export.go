@@ -0,0 +1,644 @@
+package yiigo
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExportFormat indicates the data format of export.
+type ExportFormat int
+
+const (
+	ExportCSV    ExportFormat = 1 // comma-separated values with a header row, NULL is written as `\N`, a value starting with `\` is escaped with another `\`.
+	ExportNDJSON ExportFormat = 2 // newline delimited JSON, one object per row.
+)
+
+// exportNull the CSV representation of NULL, a string starting with `\` is escaped with another `\`,
+// so the literal string `\N` is exported as `\\N` and never read as NULL.
+const exportNull = `\N`
+
+// exportTimeLayout the layout which time values are exported in.
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+var errExportInvalidFormat = errors.New("yiigo: invalid export format, expects: yiigo.ExportCSV, yiigo.ExportNDJSON")
+
+// exportOptions export options
+type exportOptions struct {
+	gzip      bool
+	batchSize int
+}
+
+// ExportOption configures how we export or import the data
+type ExportOption interface {
+	apply(options *exportOptions)
+}
+
+// funcExportOption implements export option
+type funcExportOption struct {
+	f func(options *exportOptions)
+}
+
+func (fo *funcExportOption) apply(o *exportOptions) {
+	fo.f(o)
+}
+
+func newFuncExportOption(f func(options *exportOptions)) *funcExportOption {
+	return &funcExportOption{f: f}
+}
+
+// WithExportGzip specifies the exported data to be gzip compressed.
+// On import, gzip compressed data is detected automatically.
+func WithExportGzip(b bool) ExportOption {
+	return newFuncExportOption(func(o *exportOptions) {
+		o.gzip = b
+	})
+}
+
+// WithExportBatchSize specifies the number of rows read per query by DBExportTable,
+// and inserted per statement by DBImport.
+func WithExportBatchSize(n int) ExportOption {
+	return newFuncExportOption(func(o *exportOptions) {
+		o.batchSize = n
+	})
+}
+
+func newExportOptions(options ...ExportOption) *exportOptions {
+	o := &exportOptions{batchSize: 1000}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	if o.batchSize <= 0 {
+		o.batchSize = 1000
+	}
+
+	return o
+}
+
+// exportWriter writes the rows in a format.
+type exportWriter interface {
+	header(columns []string) error
+	row(values []interface{}) error
+	flush() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) header(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvExportWriter) row(values []interface{}) error {
+	record := make([]string, 0, len(values))
+
+	for _, v := range values {
+		switch t := v.(type) {
+		case nil:
+			record = append(record, exportNull)
+		case string:
+			record = append(record, escapeExportCSV(t))
+		default:
+			record = append(record, escapeExportCSV(fmt.Sprint(t)))
+		}
+	}
+
+	return c.w.Write(record)
+}
+
+// escapeExportCSV escapes a value starting with `\`, see exportNull.
+func escapeExportCSV(s string) string {
+	if strings.HasPrefix(s, `\`) {
+		return `\` + s
+	}
+
+	return s
+}
+
+// unescapeExportCSV returns the value of an exported CSV field, and whether it's NULL.
+func unescapeExportCSV(s string) (string, bool) {
+	if s == exportNull {
+		return "", true
+	}
+
+	if strings.HasPrefix(s, `\\`) {
+		return s[1:], false
+	}
+
+	return s, false
+}
+
+func (c *csvExportWriter) flush() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	columns []string
+	e       *json.Encoder
+}
+
+func (n *ndjsonExportWriter) header(columns []string) error {
+	n.columns = columns
+
+	return nil
+}
+
+func (n *ndjsonExportWriter) row(values []interface{}) error {
+	x := make(X, len(values))
+
+	for i, v := range values {
+		x[n.columns[i]] = v
+	}
+
+	return n.e.Encode(x)
+}
+
+func (n *ndjsonExportWriter) flush() error {
+	return nil
+}
+
+func newExportWriter(w io.Writer, format ExportFormat) (exportWriter, error) {
+	switch format {
+	case ExportCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case ExportNDJSON:
+		return &ndjsonExportWriter{e: json.NewEncoder(w)}, nil
+	}
+
+	return nil, errExportInvalidFormat
+}
+
+// exportValue converts a scanned value to the exported one.
+func exportValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(exportTimeLayout)
+	}
+
+	return v
+}
+
+// exportRows writes the rows to ew and returns the number of rows written and the last value of column `id` (if exists).
+func exportRows(ew exportWriter, rows *sqlx.Rows, withHeader bool) (int, interface{}, error) {
+	columns, err := rows.Columns()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if withHeader {
+		if err := ew.header(columns); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	idIndex := -1
+
+	for i, v := range columns {
+		if v == "id" {
+			idIndex = i
+		}
+	}
+
+	count := 0
+
+	var lastID interface{}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, lastID, err
+		}
+
+		record := make([]interface{}, len(values))
+
+		for i, v := range values {
+			record[i] = exportValue(v)
+		}
+
+		if err := ew.row(record); err != nil {
+			return count, lastID, err
+		}
+
+		if idIndex >= 0 {
+			lastID = values[idIndex]
+		}
+
+		count++
+	}
+
+	return count, lastID, rows.Err()
+}
+
+// closeExportGzip closes the gzip writer and reports its error to err if no error yet,
+// since a failed trailer leaves a truncated gzip stream.
+func closeExportGzip(gw *gzip.Writer, err *error) {
+	if cerr := gw.Close(); *err == nil {
+		*err = cerr
+	}
+}
+
+// DBExport streams the rows of the query to w in format.
+func DBExport(w io.Writer, db *sqlx.DB, format ExportFormat, query string, args []interface{}, options ...ExportOption) (err error) {
+	o := newExportOptions(options...)
+
+	if o.gzip {
+		gw := gzip.NewWriter(w)
+
+		defer closeExportGzip(gw, &err)
+
+		w = gw
+	}
+
+	ew, err := newExportWriter(w, format)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Queryx(query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	if _, _, err := exportRows(ew, rows, true); err != nil {
+		return err
+	}
+
+	return ew.flush()
+}
+
+// exportFlusher flushes the rows to the client every n rows, so the rows buffered are bounded
+// and the reading of rows is held back by a slow client.
+type exportFlusher struct {
+	exportWriter
+	n     int
+	count int
+	sink  func() error
+}
+
+func (f *exportFlusher) row(values []interface{}) error {
+	if err := f.exportWriter.row(values); err != nil {
+		return err
+	}
+
+	f.count++
+
+	if f.count%f.n != 0 {
+		return nil
+	}
+
+	return f.flush()
+}
+
+func (f *exportFlusher) flush() error {
+	if err := f.exportWriter.flush(); err != nil {
+		return err
+	}
+
+	return f.sink()
+}
+
+// acceptsGzip reports whether the `Accept-Encoding` of request accepts gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(v, ";")
+
+		if strings.TrimSpace(params[0]) != "gzip" {
+			continue
+		}
+
+		for _, p := range params[1:] {
+			if q := strings.TrimSpace(p); strings.HasPrefix(q, "q=") {
+				if f, err := strconv.ParseFloat(q[2:], 64); err == nil && f == 0 {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// DBExportResponse streams the rows of the query to the http response in format, eg: for the export endpoints.
+// The rows are flushed to the client every batch size rows and the writes block on a slow client, so the memory is bounded,
+// and the query is canceled once the client disconnects. The response is gzip compressed if the client accepts it
+// and it's not compressed by a middleware (`Content-Encoding` is set), `WithExportGzip` is ignored.
+// The filename of attachment is set to `Content-Disposition` if not empty.
+//
+// Since the response is committed once the first rows are flushed, an error after that aborts the download of client.
+func DBExportResponse(w http.ResponseWriter, r *http.Request, db sqlx.QueryerContext, format ExportFormat, filename, query string, args []interface{}, options ...ExportOption) (err error) {
+	o := newExportOptions(options...)
+
+	var contentType string
+
+	switch format {
+	case ExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		return errExportInvalidFormat
+	}
+
+	rows, err := db.QueryxContext(r.Context(), query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	// the headers are set after the query succeeds, so the caller can still respond an error
+	header := w.Header()
+
+	header.Set("Content-Type", contentType)
+
+	if filename != "" {
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q; filename*=UTF-8''%s", filename, strings.Replace(url.QueryEscape(filename), "+", "%20", -1)))
+	}
+
+	var (
+		out     io.Writer = w
+		gw      *gzip.Writer
+		flusher http.Flusher
+	)
+
+	if f, ok := w.(http.Flusher); ok {
+		flusher = f
+	}
+
+	if header.Get("Content-Encoding") == "" && acceptsGzip(r) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+
+		gw = gzip.NewWriter(w)
+
+		defer closeExportGzip(gw, &err)
+
+		out = gw
+	}
+
+	ew, _ := newExportWriter(out, format)
+
+	f := &exportFlusher{
+		exportWriter: ew,
+		n:            o.batchSize,
+		sink: func() error {
+			if gw != nil {
+				if err := gw.Flush(); err != nil {
+					return err
+				}
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			return nil
+		},
+	}
+
+	if _, _, err := exportRows(f, rows, true); err != nil {
+		return err
+	}
+
+	return f.exportWriter.flush()
+}
+
+// ExportResponse streams the rows of the query to the http response in format, see DBExportResponse.
+func (q *QueryBuilder) ExportResponse(w http.ResponseWriter, r *http.Request, db sqlx.QueryerContext, format ExportFormat, filename string, options ...ExportOption) error {
+	query, binds, err := q.ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	return DBExportResponse(w, r, db, format, filename, query, binds, options...)
+}
+
+// DBExportTable streams all the rows of table to w in format.
+// The table is read in batches ordered by the primary key `id`, so a large table never holds a long running query.
+func DBExportTable(w io.Writer, db *sqlx.DB, table string, format ExportFormat, options ...ExportOption) (err error) {
+	o := newExportOptions(options...)
+
+	if o.gzip {
+		gw := gzip.NewWriter(w)
+
+		defer closeExportGzip(gw, &err)
+
+		w = gw
+	}
+
+	ew, err := newExportWriter(w, format)
+
+	if err != nil {
+		return err
+	}
+
+	d := driverOf(db).dialect()
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s > %s ORDER BY %s LIMIT %d", d.quote(table), d.quote("id"), d.placeholder(1), d.quote("id"), o.batchSize)
+
+	var lastID interface{} = 0
+
+	for first := true; ; first = false {
+		rows, err := db.Queryx(query, lastID)
+
+		if err != nil {
+			return err
+		}
+
+		count, id, err := exportRows(ew, rows, first)
+
+		rows.Close()
+
+		if err != nil {
+			return err
+		}
+
+		if count < o.batchSize {
+			break
+		}
+
+		if id == nil {
+			return fmt.Errorf("yiigo: table `%s` has no column `id` to export in batches", table)
+		}
+
+		lastID = id
+	}
+
+	return ew.flush()
+}
+
+// DBImport reads the rows in format from r (which is exported by DBExport or DBExportTable) and inserts them into table in batches.
+// Returns the number of rows inserted.
+func DBImport(r io.Reader, db *sqlx.DB, table string, format ExportFormat, options ...ExportOption) (int, error) {
+	o := newExportOptions(options...)
+
+	br := bufio.NewReader(r)
+
+	// gzip magic number
+	if b, err := br.Peek(2); err == nil && b[0] == 0x1f && b[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+
+		if err != nil {
+			return 0, err
+		}
+
+		defer gr.Close()
+
+		r = gr
+	} else {
+		r = br
+	}
+
+	driver := driverOf(db)
+
+	count := 0
+
+	err := readExportRows(r, format, o.batchSize, func(data []X) error {
+		query, binds := insertSQL(driver, table, data)
+
+		// postgres single insert has `RETURNING "id"`, which is fine for Exec
+		if _, err := db.Exec(query, binds...); err != nil {
+			return err
+		}
+
+		count += len(data)
+
+		return nil
+	})
+
+	return count, err
+}
+
+// readExportRows reads the rows in format from r, and calls f with every `batchSize` rows.
+func readExportRows(r io.Reader, format ExportFormat, batchSize int, f func(data []X) error) error {
+	batch := make([]X, 0, batchSize)
+
+	add := func(x X) error {
+		batch = append(batch, x)
+
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		err := f(batch)
+
+		batch = make([]X, 0, batchSize)
+
+		return err
+	}
+
+	switch format {
+	case ExportCSV:
+		cr := csv.NewReader(r)
+
+		columns, err := cr.Read()
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		for {
+			record, err := cr.Read()
+
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+
+				return err
+			}
+
+			x := make(X, len(columns))
+
+			for i, v := range record {
+				value, null := unescapeExportCSV(v)
+
+				if null {
+					x[columns[i]] = nil
+
+					continue
+				}
+
+				x[columns[i]] = value
+			}
+
+			if err := add(x); err != nil {
+				return err
+			}
+		}
+	case ExportNDJSON:
+		jd := json.NewDecoder(r)
+		jd.UseNumber()
+
+		for {
+			x := make(X)
+
+			if err := jd.Decode(&x); err != nil {
+				if err == io.EOF {
+					break
+				}
+
+				return err
+			}
+
+			for k, v := range x {
+				// keep the precision of big numbers, eg: bigint ids
+				if n, ok := v.(json.Number); ok {
+					x[k] = string(n)
+				}
+			}
+
+			if err := add(x); err != nil {
+				return err
+			}
+		}
+	default:
+		return errExportInvalidFormat
+	}
+
+	if len(batch) > 0 {
+		return f(batch)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package yiigo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_csvExportWriter(t *testing.T) {
+	type args struct {
+		columns []string
+		rows    [][]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{
+				columns: []string{"id", "name", "remark"},
+				rows: [][]interface{}{
+					{int64(1), "IIInsomnia", nil},
+					{int64(2), "test, \"quoted\"", "ok"},
+				},
+			},
+			want: "id,name,remark\n1,IIInsomnia,\\N\n2,\"test, \"\"quoted\"\"\",ok\n",
+		},
+		{
+			name: "t2",
+			args: args{
+				columns: []string{"id", "path"},
+				rows: [][]interface{}{
+					{int64(1), `\N`},
+					{int64(2), `\tmp`},
+					{int64(3), nil},
+				},
+			},
+			want: "id,path\n1,\\\\N\n2,\\\\tmp\n3,\\N\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ew, _ := newExportWriter(&buf, ExportCSV)
+
+			ew.header(tt.args.columns)
+
+			for _, v := range tt.args.rows {
+				ew.row(v)
+			}
+
+			ew.flush()
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("csvExportWriter got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readExportRows(t *testing.T) {
+	type args struct {
+		data      string
+		format    ExportFormat
+		batchSize int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]X
+	}{
+		{
+			name: "t1",
+			args: args{
+				data:      "id,name,remark\n1,IIInsomnia,\\N\n2,test,ok\n3,foo,bar\n",
+				format:    ExportCSV,
+				batchSize: 2,
+			},
+			want: [][]X{
+				{
+					{"id": "1", "name": "IIInsomnia", "remark": nil},
+					{"id": "2", "name": "test", "remark": "ok"},
+				},
+				{
+					{"id": "3", "name": "foo", "remark": "bar"},
+				},
+			},
+		},
+		{
+			name: "t3",
+			args: args{
+				data:      "id,path\n1,\\\\N\n2,\\\\tmp\n3,\\N\n",
+				format:    ExportCSV,
+				batchSize: 10,
+			},
+			want: [][]X{
+				{
+					{"id": "1", "path": `\N`},
+					{"id": "2", "path": `\tmp`},
+					{"id": "3", "path": nil},
+				},
+			},
+		},
+		{
+			name: "t2",
+			args: args{
+				data:      "{\"id\":1,\"name\":\"IIInsomnia\",\"remark\":null}\n{\"id\":9007199254740993,\"name\":\"test\",\"remark\":\"ok\"}\n",
+				format:    ExportNDJSON,
+				batchSize: 10,
+			},
+			want: [][]X{
+				{
+					{"id": "1", "name": "IIInsomnia", "remark": nil},
+					{"id": "9007199254740993", "name": "test", "remark": "ok"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([][]X, 0)
+
+			err := readExportRows(strings.NewReader(tt.args.data), tt.args.format, tt.args.batchSize, func(data []X) error {
+				got = append(got, data)
+
+				return nil
+			})
+
+			if err != nil {
+				t.Errorf("readExportRows() error = %v", err)
+
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readExportRows() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_acceptsGzip(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "t1", header: "gzip, deflate, br", want: true},
+		{name: "t2", header: "deflate, gzip;q=0.5", want: true},
+		{name: "t3", header: "gzip;q=0", want: false},
+		{name: "t4", header: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.header)
+
+			if got := acceptsGzip(r); got != tt.want {
+				t.Errorf("acceptsGzip() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_exportFlusher(t *testing.T) {
+	var buf bytes.Buffer
+
+	ew, _ := newExportWriter(&buf, ExportCSV)
+
+	flushes := 0
+
+	f := &exportFlusher{
+		exportWriter: ew,
+		n:            2,
+		sink: func() error {
+			flushes++
+
+			return nil
+		},
+	}
+
+	for i := 0; i < 5; i++ {
+		f.row([]interface{}{i})
+	}
+
+	if flushes != 2 {
+		t.Errorf("exportFlusher flushes = %d, want 2", flushes)
+	}
+
+	if want := "0\n1\n2\n3\n"; buf.String() != want {
+		t.Errorf("exportFlusher got = %q, want %q", buf.String(), want)
+	}
+}
+
+// failWriter fails all the writes.
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_closeExportGzip(t *testing.T) {
+	f := func() (err error) {
+		gw := gzip.NewWriter(failWriter{})
+
+		defer closeExportGzip(gw, &err)
+
+		// buffered by gzip, so the error is only from closing
+		_, err = gw.Write([]byte("id,name\n"))
+
+		return err
+	}
+
+	if err := f(); err == nil {
+		t.Error("closeExportGzip() error = nil, want the error of close")
+	}
+}
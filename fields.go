@@ -0,0 +1,162 @@
+package yiigo
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ParseFields parses the field selection of a query, eg: "id,name" of `?fields=id,name`.
+func ParseFields(s string) []string {
+	fields := make([]string, 0)
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			fields = append(fields, v)
+		}
+	}
+
+	return fields
+}
+
+// SelectFields returns v (a struct, or a slice of structs) as X (or []X) for response, keeping the fields
+// selected by fields (all if empty) and visible to roles, the keys are the names of `json` tag.
+//
+// A field with tag `role:"admin,staff"` is only visible to the roles listed, others are visible to all.
+// The fields of embedded structs are promoted, and `omitempty` is honored.
+//
+//	type User struct {
+//		ID    int64  `json:"id"`
+//		Name  string `json:"name"`
+//		Phone string `json:"phone" role:"admin"`
+//	}
+//
+//	yiigo.SelectFields(users, yiigo.ParseFields(r.URL.Query().Get("fields")), "public")
+func SelectFields(v interface{}, fields []string, roles ...string) interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	selected := make(map[string]struct{}, len(fields))
+
+	for _, f := range fields {
+		selected[f] = struct{}{}
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return selectStructFields(rv, selected, roles)
+	case reflect.Slice, reflect.Array:
+		data := make([]X, 0, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			ev := reflect.Indirect(rv.Index(i))
+
+			if ev.Kind() != reflect.Struct {
+				continue
+			}
+
+			data = append(data, selectStructFields(ev, selected, roles))
+		}
+
+		return data
+	}
+
+	return v
+}
+
+func selectStructFields(rv reflect.Value, selected map[string]struct{}, roles []string) X {
+	data := X{}
+
+	collectStructFields(data, rv, selected, roles)
+
+	return data
+}
+
+func collectStructFields(data X, rv reflect.Value, selected map[string]struct{}, roles []string) {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		tag := field.Tag.Get("json")
+
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+
+		fv := rv.Field(i)
+
+		if field.Anonymous && name == "" {
+			if ev := reflect.Indirect(fv); ev.Kind() == reflect.Struct {
+				collectStructFields(data, ev, selected, roles)
+			}
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if len(selected) > 0 {
+			if _, ok := selected[name]; !ok {
+				continue
+			}
+		}
+
+		if !fieldVisible(field.Tag.Get("role"), roles) {
+			continue
+		}
+
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(fv) {
+			continue
+		}
+
+		data[name] = fv.Interface()
+	}
+}
+
+// fieldVisible reports whether the field with tag `role` is visible to roles.
+func fieldVisible(tag string, roles []string) bool {
+	if tag == "" {
+		return true
+	}
+
+	for _, v := range strings.Split(tag, ",") {
+		for _, role := range roles {
+			if strings.TrimSpace(v) == role {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isEmptyValue reports whether v is empty as `omitempty` of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package yiigo
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fieldsTestBase struct {
+	ID int64 `json:"id"`
+}
+
+type fieldsTestUser struct {
+	fieldsTestBase
+	Name     string `json:"name"`
+	Phone    string `json:"phone" role:"admin,staff"`
+	Password string `json:"-"`
+	Remark   string `json:"remark,omitempty"`
+}
+
+func TestParseFields(t *testing.T) {
+	if got := ParseFields(" id, name,,"); !reflect.DeepEqual(got, []string{"id", "name"}) {
+		t.Errorf("ParseFields() = %v, want [id name]", got)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	user := &fieldsTestUser{
+		fieldsTestBase: fieldsTestBase{ID: 1},
+		Name:           "yiigo",
+		Phone:          "13800138000",
+		Password:       "secret",
+	}
+
+	type args struct {
+		v      interface{}
+		fields []string
+		roles  []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want interface{}
+	}{
+		{
+			name: "t1",
+			args: args{v: user},
+			want: X{"id": int64(1), "name": "yiigo"},
+		},
+		{
+			name: "t2",
+			args: args{v: user, roles: []string{"admin"}},
+			want: X{"id": int64(1), "name": "yiigo", "phone": "13800138000"},
+		},
+		{
+			name: "t3",
+			args: args{v: []*fieldsTestUser{user}, fields: []string{"name", "phone"}, roles: []string{"staff"}},
+			want: []X{{"name": "yiigo", "phone": "13800138000"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectFields(tt.args.v, tt.args.fields, tt.args.roles...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrInvalidTransition returned when a transition is not allowed by the state machine.
+	ErrInvalidTransition = errors.New("yiigo: invalid state transition")
+	// ErrStateConflict returned when no row is updated, since it's not in the from state (eg: changed concurrently) or doesn't exist.
+	ErrStateConflict = errors.New("yiigo: state conflicts, no row in the from state")
+)
+
+// StateGuard checks a transition with its data, the transition is rejected if it returns an error.
+type StateGuard func(ctx context.Context, from, to string, data X) error
+
+// StateHook is called after a transition succeeds.
+type StateHook func(ctx context.Context, from, to string, data X)
+
+// StateMachine a finite state machine of the status field of a model, which enforces the allowed transitions on update.
+//
+//	orderFSM := yiigo.NewStateMachine("status").
+//		Allow("pending", "paid", "canceled").
+//		Allow("paid", "shipped", "refunded").
+//		Guard("paid", "refunded", checkRefundable).
+//		OnTransit(notifyUser)
+//
+//	// UPDATE `order` SET `status` = ?, `paid_at` = ? WHERE `id` = ? AND `status` = ?
+//	err := orderFSM.Update(ctx, db, "UPDATE `order` SET ? WHERE `id` = ?", yiigo.X{"paid_at": now}, "pending", "paid", id)
+type StateMachine struct {
+	column      string
+	transitions map[string]map[string][]StateGuard
+	hooks       []StateHook
+	mutex       sync.RWMutex
+}
+
+// NewStateMachine returns a new state machine of the status column.
+func NewStateMachine(column string) *StateMachine {
+	return &StateMachine{
+		column:      column,
+		transitions: make(map[string]map[string][]StateGuard),
+	}
+}
+
+// Allow allows the transitions from a state to the states of to.
+func (m *StateMachine) Allow(from string, to ...string) *StateMachine {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.transitions[from]; !ok {
+		m.transitions[from] = make(map[string][]StateGuard)
+	}
+
+	for _, v := range to {
+		if _, ok := m.transitions[from][v]; !ok {
+			m.transitions[from][v] = nil
+		}
+	}
+
+	return m
+}
+
+// Guard adds a guard to the transition, which is allowed if not yet.
+func (m *StateMachine) Guard(from, to string, guard StateGuard) *StateMachine {
+	m.Allow(from, to)
+
+	m.mutex.Lock()
+	m.transitions[from][to] = append(m.transitions[from][to], guard)
+	m.mutex.Unlock()
+
+	return m
+}
+
+// OnTransit adds a hook called after every successful transition.
+func (m *StateMachine) OnTransit(hook StateHook) *StateMachine {
+	m.mutex.Lock()
+	m.hooks = append(m.hooks, hook)
+	m.mutex.Unlock()
+
+	return m
+}
+
+// Can reports whether the transition is allowed, the guards are not checked.
+func (m *StateMachine) Can(from, to string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, ok := m.transitions[from][to]
+
+	return ok
+}
+
+// Check returns ErrInvalidTransition if the transition is not allowed, or the error of its guards.
+func (m *StateMachine) Check(ctx context.Context, from, to string, data X) error {
+	m.mutex.RLock()
+	guards, ok := m.transitions[from][to]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return ErrInvalidTransition
+	}
+
+	for _, guard := range guards {
+		if err := guard(ctx, from, to, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update checks the transition, and updates the status of the row from `from` to `to` with the other columns of data,
+// the status condition is appended to the `WHERE` clause, so a concurrent transition of the row results in ErrStateConflict.
+// The hooks are called after the row is updated.
+//
+// param query expects eg: "UPDATE `table` SET ? WHERE `id` = ?", which should end with the `WHERE` clause.
+func (m *StateMachine) Update(ctx context.Context, db sqlx.ExtContext, query string, data X, from, to string, args ...interface{}) error {
+	if err := m.Check(ctx, from, to, data); err != nil {
+		return err
+	}
+
+	driver := MySQL
+
+	if db.DriverName() == "postgres" {
+		driver = Postgres
+	}
+
+	d := driver.dialect()
+
+	sets := make(X, len(data)+1)
+
+	for k, v := range data {
+		sets[k] = v
+	}
+
+	sets[m.column] = to
+
+	query = fmt.Sprintf("%s AND %s = %s", query, d.quote(m.column), d.placeholder(len(args)+2))
+
+	binds := make([]interface{}, 0, len(args)+1)
+	binds = append(binds, args...)
+	binds = append(binds, from)
+
+	sql, binds := updateSQL(driver, query, sets, binds...)
+
+	r, err := db.ExecContext(ctx, sql, binds...)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := r.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrStateConflict
+	}
+
+	m.mutex.RLock()
+	hooks := m.hooks
+	m.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(ctx, from, to, data)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type fsmTestResult int64
+
+func (r fsmTestResult) LastInsertId() (int64, error) { return 0, nil }
+func (r fsmTestResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type fsmTestDB struct {
+	sqlx.ExtContext
+	affected int64
+	query    string
+	args     []interface{}
+}
+
+func (db *fsmTestDB) DriverName() string { return "mysql" }
+
+func (db *fsmTestDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.query, db.args = query, args
+
+	return fsmTestResult(db.affected), nil
+}
+
+func TestStateMachineCheck(t *testing.T) {
+	errNotRefundable := errors.New("not refundable")
+
+	m := NewStateMachine("status").
+		Allow("pending", "paid", "canceled").
+		Guard("paid", "refunded", func(ctx context.Context, from, to string, data X) error {
+			if data["amount"] == 0 {
+				return errNotRefundable
+			}
+
+			return nil
+		})
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		data X
+		want error
+	}{
+		{name: "t1", from: "pending", to: "paid"},
+		{name: "t2", from: "paid", to: "pending", want: ErrInvalidTransition},
+		{name: "t3", from: "paid", to: "refunded", data: X{"amount": 0}, want: errNotRefundable},
+		{name: "t4", from: "paid", to: "refunded", data: X{"amount": 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Check(context.Background(), tt.from, tt.to, tt.data); err != tt.want {
+				t.Errorf("StateMachine.Check() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachineUpdate(t *testing.T) {
+	var transited []string
+
+	m := NewStateMachine("status").
+		Allow("pending", "paid").
+		OnTransit(func(ctx context.Context, from, to string, data X) {
+			transited = append(transited, from+"->"+to)
+		})
+
+	db := &fsmTestDB{affected: 1}
+
+	if err := m.Update(context.Background(), db, "UPDATE `order` SET ? WHERE `id` = ?", nil, "pending", "paid", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.query != "UPDATE `order` SET `status` = ? WHERE `id` = ? AND `status` = ?" {
+		t.Errorf("StateMachine.Update() query = %s", db.query)
+	}
+
+	if !reflect.DeepEqual(db.args, []interface{}{"paid", 1, "pending"}) {
+		t.Errorf("StateMachine.Update() args = %v", db.args)
+	}
+
+	if !reflect.DeepEqual(transited, []string{"pending->paid"}) {
+		t.Errorf("StateMachine.Update() hooks = %v", transited)
+	}
+
+	db.affected = 0
+
+	if err := m.Update(context.Background(), db, "UPDATE `order` SET ? WHERE `id` = ?", nil, "pending", "paid", 1); err != ErrStateConflict {
+		t.Errorf("StateMachine.Update() error = %v, want %v", err, ErrStateConflict)
+	}
+}
@@ -0,0 +1,134 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GraphQLError an error of graphql response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Error returns the message of error.
+func (e *GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+
+	path := make([]string, 0, len(e.Path))
+
+	for _, v := range e.Path {
+		path = append(path, fmt.Sprint(v))
+	}
+
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// GraphQLErrors the errors of graphql response
+type GraphQLErrors []*GraphQLError
+
+// Error returns the messages of all the errors.
+func (e GraphQLErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, v := range e {
+		msgs = append(msgs, v.Error())
+	}
+
+	return fmt.Sprintf("yiigo: graphql error: %s", strings.Join(msgs, "; "))
+}
+
+type graphqlRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName,omitempty"`
+	Variables     X      `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQLClient graphql client
+type GraphQLClient struct {
+	endpoint string
+	client   *HTTPClient
+}
+
+// NewGraphQLClient returns a new graphql client of endpoint, uses the default http client if client is nil.
+func NewGraphQLClient(endpoint string, client *HTTPClient) *GraphQLClient {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	return &GraphQLClient{
+		endpoint: endpoint,
+		client:   client,
+	}
+}
+
+// Do executes a query or mutation with variables, and decodes the `data` of response into dest (can be nil).
+// If the response has `errors`, the data is still decoded and GraphQLErrors is returned.
+// The `errors` of a non-200 response are returned as GraphQLErrors wrapped with the status code (see ErrorCode and ErrorCause).
+func (g *GraphQLClient) Do(query string, variables X, dest interface{}, options ...HTTPRequestOption) error {
+	return g.do(query, "", variables, dest, options...)
+}
+
+// DoOperation executes the named operation of a document which contains multiple operations, see Do.
+func (g *GraphQLClient) DoOperation(query, operationName string, variables X, dest interface{}, options ...HTTPRequestOption) error {
+	return g.do(query, operationName, variables, dest, options...)
+}
+
+func (g *GraphQLClient) do(query, operationName string, variables X, dest interface{}, options ...HTTPRequestOption) error {
+	body, err := jsonCodec.Marshal(&graphqlRequest{
+		Query:         query,
+		OperationName: operationName,
+		Variables:     variables,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	options = append([]HTTPRequestOption{
+		WithRequestHeader("Content-Type", "application/json"),
+		WithRequestHeader("Accept", "application/json"),
+	}, options...)
+
+	b, err := g.client.Post(g.endpoint, body, append(options, withErrorBody())...)
+
+	if err != nil {
+		// a non-200 response may carry the graphql errors, which are returned with the status code
+		if code := ErrorCode(err); code != 0 && len(b) > 0 {
+			resp := new(graphqlResponse)
+
+			if jsonCodec.Unmarshal(b, resp) == nil && len(resp.Errors) > 0 {
+				return ErrorWithCode(resp.Errors, code)
+			}
+		}
+
+		return err
+	}
+
+	resp := new(graphqlResponse)
+
+	if err := jsonCodec.Unmarshal(b, resp); err != nil {
+		return err
+	}
+
+	if dest != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := jsonCodec.Unmarshal(resp.Data, dest); err != nil {
+			return err
+		}
+	}
+
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGraphQLClient_Do(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(graphqlRequest)
+
+		json.NewDecoder(r.Body).Decode(req)
+
+		if req.Variables["id"] == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"Variable \"$id\" of required type \"ID!\" was not provided."}]}`))
+
+			return
+		}
+
+		if req.Variables["id"] == "1" {
+			w.Write([]byte(`{"data":{"user":{"id":"1","name":"IIInsomnia"}}}`))
+
+			return
+		}
+
+		w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"user not found","path":["user"]}]}`))
+	}))
+
+	defer ts.Close()
+
+	type result struct {
+		User *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	client := NewGraphQLClient(ts.URL, nil)
+
+	query := `query ($id: ID!) { user(id: $id) { id name } }`
+
+	r1 := new(result)
+
+	if err := client.Do(query, X{"id": "1"}, r1); err != nil {
+		t.Errorf("GraphQLClient.Do() error = %v", err)
+
+		return
+	}
+
+	if r1.User == nil || r1.User.Name != "IIInsomnia" {
+		t.Errorf("GraphQLClient.Do() got = %v, want IIInsomnia", r1.User)
+	}
+
+	r2 := new(result)
+
+	err := client.Do(query, X{"id": "2"}, r2)
+
+	want := "yiigo: graphql error: user not found (path: user)"
+
+	if err == nil || err.Error() != want {
+		t.Errorf("GraphQLClient.Do() error = %v, want %v", err, want)
+	}
+
+	err = client.Do(query, nil, nil)
+
+	if _, ok := ErrorCause(err).(GraphQLErrors); !ok || ErrorCode(err) != http.StatusBadRequest {
+		t.Errorf("GraphQLClient.Do() error = %v, want GraphQLErrors with code 400", err)
+	}
+}
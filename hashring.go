@@ -0,0 +1,182 @@
+package yiigo
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// defaultHashRingReplicas the default virtual nodes per node, the same as ketama.
+const defaultHashRingReplicas = 160
+
+// HashRing a consistent hash ring with virtual nodes, for client-side sharding of caches and queues.
+// When a node is added or removed, only the keys of its neighbours are remapped.
+type HashRing struct {
+	replicas int
+	hashes   []uint32
+	ring     map[uint32]string
+	nodes    map[string]struct{}
+	mutex    sync.RWMutex
+}
+
+// NewHashRing returns a new hash ring of nodes, with replicas virtual nodes per node (defaults to 160 if replicas <= 0).
+func NewHashRing(replicas int, nodes ...string) *HashRing {
+	if replicas <= 0 {
+		replicas = defaultHashRingReplicas
+	}
+
+	r := &HashRing{
+		replicas: replicas,
+		ring:     make(map[uint32]string),
+		nodes:    make(map[string]struct{}),
+	}
+
+	r.Add(nodes...)
+
+	return r
+}
+
+// hashRingKey hashes a key to the ring.
+func hashRingKey(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+
+	return binary.LittleEndian.Uint32(sum[:4])
+}
+
+// Add adds the nodes to the ring.
+func (r *HashRing) Add(nodes ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, node := range nodes {
+		if _, ok := r.nodes[node]; ok {
+			continue
+		}
+
+		r.nodes[node] = struct{}{}
+
+		for i := 0; i < r.replicas; i++ {
+			h := hashRingKey(node + "#" + strconv.Itoa(i))
+
+			// keep the first node on a (rare) collision
+			if _, ok := r.ring[h]; ok {
+				continue
+			}
+
+			r.ring[h] = node
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+}
+
+// Remove removes the nodes from the ring.
+func (r *HashRing) Remove(nodes ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := false
+
+	for _, node := range nodes {
+		if _, ok := r.nodes[node]; !ok {
+			continue
+		}
+
+		delete(r.nodes, node)
+
+		removed = true
+	}
+
+	if !removed {
+		return
+	}
+
+	hashes := r.hashes[:0]
+
+	for _, h := range r.hashes {
+		if _, ok := r.nodes[r.ring[h]]; ok {
+			hashes = append(hashes, h)
+
+			continue
+		}
+
+		delete(r.ring, h)
+	}
+
+	r.hashes = hashes
+}
+
+// Get returns the node of key, or empty string if the ring is empty.
+func (r *HashRing) Get(key string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	return r.ring[r.hashes[r.search(key)]]
+}
+
+// GetN returns n distinct nodes of key clockwise, eg: the replicas of a key. It returns all the nodes if n exceeds.
+func (r *HashRing) GetN(key string, n int) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+
+	nodes := make([]string, 0, n)
+
+	if n <= 0 {
+		return nodes
+	}
+
+	seen := make(map[string]struct{}, n)
+
+	for i, start := 0, r.search(key); i < len(r.hashes) && len(nodes) < n; i++ {
+		node := r.ring[r.hashes[(start+i)%len(r.hashes)]]
+
+		if _, ok := seen[node]; ok {
+			continue
+		}
+
+		seen[node] = struct{}{}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// search returns the index of the first virtual node clockwise from key.
+func (r *HashRing) search(key string) int {
+	h := hashRingKey(key)
+
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return i
+}
+
+// Nodes returns the nodes of the ring, sorted.
+func (r *HashRing) Nodes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodes))
+
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+
+	return nodes
+}
@@ -0,0 +1,55 @@
+package yiigo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHashRing(t *testing.T) {
+	r := NewHashRing(0, "redis-1", "redis-2", "redis-3")
+
+	before := make(map[string]string, 10000)
+	counts := make(map[string]int)
+
+	for i := 0; i < 10000; i++ {
+		key := "user:" + strconv.Itoa(i)
+		node := r.Get(key)
+
+		before[key] = node
+		counts[node]++
+	}
+
+	for node, n := range counts {
+		if n < 2000 || n > 4700 {
+			t.Errorf("HashRing.Get() node %s has %d keys, want about 3333", node, n)
+		}
+	}
+
+	r.Remove("redis-2")
+
+	for key, node := range before {
+		got := r.Get(key)
+
+		if got == "redis-2" {
+			t.Errorf("HashRing.Get(%s) = redis-2, which is removed", key)
+		}
+
+		// only the keys of the removed node move
+		if node != "redis-2" && got != node {
+			t.Errorf("HashRing.Get(%s) = %s, want %s", key, got, node)
+		}
+	}
+
+	if got := r.Nodes(); !reflect.DeepEqual(got, []string{"redis-1", "redis-3"}) {
+		t.Errorf("HashRing.Nodes() = %v, want [redis-1 redis-3]", got)
+	}
+
+	if got := r.GetN("user:1", 5); len(got) != 2 || got[0] != r.Get("user:1") || got[0] == got[1] {
+		t.Errorf("HashRing.GetN() = %v, want 2 distinct nodes starting with %s", got, r.Get("user:1"))
+	}
+
+	if got := NewHashRing(10).Get("user:1"); got != "" {
+		t.Errorf("HashRing.Get() = %v, want empty", got)
+	}
+}
@@ -0,0 +1,323 @@
+package yiigo
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// httpCacheRetention how long a response with validators (ETag / Last-Modified) is kept for revalidation after it expires.
+const httpCacheRetention = 24 * time.Hour
+
+// HTTPCache stores the cached GET responses of http client, the key is the request url.
+// The requests with credentials (`Authorization`, cookies, etc, see WithHTTPCacheCredentialHeaders) are not cached, and the responses with `Cache-Control: private` or `no-store` are not stored.
+// A response with `Vary` is only returned to the requests with the same values of the headers, and `Vary: *` is not stored.
+// Use NewHTTPMemoryCache for a process local cache, or NewHTTPRedisCache to share the cache between processes.
+type HTTPCache interface {
+	// Get returns the cached value of key.
+	Get(key string) ([]byte, bool)
+	// Set caches the value of key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// httpCacheEntry a cached response
+type httpCacheEntry struct {
+	Body         []byte `json:"body"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	ExpiresAt    int64  `json:"expires_at"`
+	// the request headers named by `Vary` of response and their values
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// matches reports whether the entry can be returned to the request, see `Vary`.
+func (e *httpCacheEntry) matches(o *httpRequestOptions) bool {
+	for k, v := range e.Vary {
+		if o.header(k) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// httpCacheVary returns the request headers named by `Vary` of response and their values, ok is false for `Vary: *`.
+func httpCacheVary(header http.Header, o *httpRequestOptions) (map[string]string, bool) {
+	var vary map[string]string
+
+	for _, line := range header[http.CanonicalHeaderKey("Vary")] {
+		for _, v := range strings.Split(line, ",") {
+			name := strings.TrimSpace(v)
+
+			if name == "" {
+				continue
+			}
+
+			if name == "*" {
+				return nil, false
+			}
+
+			if vary == nil {
+				vary = make(map[string]string)
+			}
+
+			vary[http.CanonicalHeaderKey(name)] = o.header(name)
+		}
+	}
+
+	return vary, true
+}
+
+// fresh reports whether the entry can be returned without revalidation.
+func (e *httpCacheEntry) fresh() bool {
+	return time.Now().UnixNano() < e.ExpiresAt
+}
+
+// cachedGet returns the fresh cached response of url, or revalidates the stale one with `If-None-Match` / `If-Modified-Since`.
+func (h *HTTPClient) cachedGet(url string, o *httpRequestOptions) ([]byte, error) {
+	var entry *httpCacheEntry
+
+	if v, ok := h.cache.Get(url); ok {
+		e := new(httpCacheEntry)
+
+		if err := jsonCodec.Unmarshal(v, e); err == nil && e.matches(o) {
+			entry = e
+		}
+	}
+
+	if entry != nil {
+		if entry.fresh() {
+			return entry.Body, nil
+		}
+
+		if entry.ETag != "" {
+			o.headers["If-None-Match"] = entry.ETag
+		}
+
+		if entry.LastModified != "" {
+			o.headers["If-Modified-Since"] = entry.LastModified
+		}
+	}
+
+	resp, b, err := h.send("GET", url, nil, o)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		entry = &httpCacheEntry{
+			Body:         b,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+	case http.StatusNotModified:
+		if entry == nil {
+			return nil, httpStatusError(resp.StatusCode)
+		}
+	default:
+		return nil, httpStatusError(resp.StatusCode)
+	}
+
+	ttl, store := httpCacheTTL(resp.Header, o.cacheTTL)
+
+	if store {
+		entry.Vary, store = httpCacheVary(resp.Header, o)
+	}
+
+	if store {
+		entry.ExpiresAt = time.Now().Add(ttl).UnixNano()
+
+		retention := ttl
+
+		if entry.ETag != "" || entry.LastModified != "" {
+			retention += httpCacheRetention
+		}
+
+		if v, err := jsonCodec.Marshal(entry); err == nil && retention > 0 {
+			h.cache.Set(url, v, retention)
+		}
+	}
+
+	return entry.Body, nil
+}
+
+// httpCacheTTL returns the ttl of a response and whether it can be stored.
+// A response with `no-store` or `private` is never stored, otherwise an explicit ttl takes precedence over the `max-age` of `Cache-Control`.
+func httpCacheTTL(header http.Header, explicit time.Duration) (time.Duration, bool) {
+	var (
+		ttl        time.Duration
+		revalidate bool
+	)
+
+	for _, v := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive := strings.ToLower(strings.TrimSpace(v))
+
+		switch {
+		case directive == "no-store", directive == "private", strings.HasPrefix(directive, "private="):
+			return 0, false
+		case directive == "no-cache":
+			// stored for revalidation only
+			revalidate = true
+		case strings.HasPrefix(directive, "max-age="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && n > 0 {
+				ttl = time.Duration(n) * time.Second
+			}
+		}
+	}
+
+	if explicit > 0 {
+		return explicit, true
+	}
+
+	if revalidate {
+		return 0, true
+	}
+
+	return ttl, true
+}
+
+// defaultHTTPMemoryCacheEntries the default max entries of NewHTTPMemoryCache.
+const defaultHTTPMemoryCacheEntries = 1000
+
+// httpMemoryCache a process local HTTPCache, the least recently used item is evicted when it's full.
+type httpMemoryCache struct {
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	mutex      sync.Mutex
+}
+
+type httpMemoryCacheItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewHTTPMemoryCache returns a process local HTTPCache which holds maxEntries items at most,
+// the least recently used item is evicted when it's full, and the expired items are evicted on access.
+//
+// The default `maxEntries` is 1000 if maxEntries <= 0.
+func NewHTTPMemoryCache(maxEntries int) HTTPCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultHTTPMemoryCacheEntries
+	}
+
+	return &httpMemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *httpMemoryCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.items[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*httpMemoryCacheItem)
+
+	if time.Now().After(item.expiresAt) {
+		c.remove(elem)
+
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+
+	return item.value, true
+}
+
+func (c *httpMemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*httpMemoryCacheItem)
+
+		item.value = value
+		item.expiresAt = time.Now().Add(ttl)
+
+		c.ll.MoveToFront(elem)
+
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&httpMemoryCacheItem{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	})
+
+	if c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *httpMemoryCache) remove(elem *list.Element) {
+	c.ll.Remove(elem)
+
+	delete(c.items, elem.Value.(*httpMemoryCacheItem).key)
+}
+
+// httpRedisCache a HTTPCache backed by redis
+type httpRedisCache struct {
+	pool   *RedisPoolResource
+	prefix string
+}
+
+// NewHTTPRedisCache returns a HTTPCache backed by the redis pool, the keys are prefixed by `prefix`.
+// The cache misses and sets are skipped silently when redis is unavailable.
+func NewHTTPRedisCache(pool *RedisPoolResource, prefix string) HTTPCache {
+	return &httpRedisCache{
+		pool:   pool,
+		prefix: prefix,
+	}
+}
+
+func (c *httpRedisCache) Get(key string) ([]byte, bool) {
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return nil, false
+	}
+
+	defer c.pool.Put(conn)
+
+	b, err := redis.Bytes(conn.Do("GET", c.prefix+key))
+
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+func (c *httpRedisCache) Set(key string, value []byte, ttl time.Duration) {
+	conn, err := c.pool.Get()
+
+	if err != nil {
+		return
+	}
+
+	defer c.pool.Put(conn)
+
+	ms := int64(ttl / time.Millisecond)
+
+	if ms <= 0 {
+		return
+	}
+
+	conn.Do("SET", c.prefix+key, value, "PX", ms)
+}
@@ -0,0 +1,230 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_httpCacheTTL(t *testing.T) {
+	type args struct {
+		cacheControl string
+		explicit     time.Duration
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      time.Duration
+		wantStore bool
+	}{
+		{
+			name:      "t1",
+			args:      args{cacheControl: "public, max-age=60"},
+			want:      60 * time.Second,
+			wantStore: true,
+		},
+		{
+			name:      "t2",
+			args:      args{cacheControl: "no-store"},
+			want:      0,
+			wantStore: false,
+		},
+		{
+			name:      "t3",
+			args:      args{cacheControl: "no-store", explicit: 10 * time.Second},
+			want:      0,
+			wantStore: false,
+		},
+		{
+			name:      "t4",
+			args:      args{cacheControl: "private, max-age=60", explicit: 10 * time.Second},
+			want:      0,
+			wantStore: false,
+		},
+		{
+			name:      "t5",
+			args:      args{cacheControl: "max-age=60", explicit: 10 * time.Second},
+			want:      10 * time.Second,
+			wantStore: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Cache-Control", tt.args.cacheControl)
+
+			got, gotStore := httpCacheTTL(header, tt.args.explicit)
+
+			if got != tt.want || gotStore != tt.wantStore {
+				t.Errorf("httpCacheTTL() = %v, %v, want %v, %v", got, gotStore, tt.want, tt.wantStore)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_cachedGet(t *testing.T) {
+	requests := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte("hello world"))
+	}))
+
+	defer ts.Close()
+
+	client := NewHTTPClient(WithHTTPCache(NewHTTPMemoryCache(0)))
+
+	for i := 0; i < 2; i++ {
+		b, err := client.Get(ts.URL)
+
+		if err != nil {
+			t.Errorf("HTTPClient.Get() error = %v", err)
+
+			return
+		}
+
+		if string(b) != "hello world" {
+			t.Errorf("HTTPClient.Get() = %s, want hello world", b)
+		}
+	}
+
+	b, err := client.Get(ts.URL, WithRequestCacheTTL(time.Minute))
+
+	if err != nil || string(b) != "hello world" {
+		t.Errorf("HTTPClient.Get() = %s, %v, want hello world", b, err)
+	}
+
+	// served from the fresh cache
+	client.Get(ts.URL)
+
+	if requests != 3 {
+		t.Errorf("server requests = %d, want 3", requests)
+	}
+}
+
+func TestHTTPClient_cachedGetCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		options []HTTPClientOption
+	}{
+		{name: "t1", header: "Authorization"},
+		{name: "t2", header: "X-Api-Key"},
+		{name: "t3", header: "X-Tenant-Token", options: []HTTPClientOption{WithHTTPCacheCredentialHeaders("X-Tenant-Token")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Write([]byte("user:" + r.Header.Get(tt.header)))
+			}))
+
+			defer ts.Close()
+
+			client := NewHTTPClient(append(tt.options, WithHTTPCache(NewHTTPMemoryCache(0)))...)
+
+			for _, auth := range []string{"alice", "bob", "alice"} {
+				b, err := client.Get(ts.URL, WithRequestHeader(tt.header, auth))
+
+				if err != nil {
+					t.Fatalf("HTTPClient.Get() error = %v", err)
+				}
+
+				if want := "user:" + auth; string(b) != want {
+					t.Errorf("HTTPClient.Get() = %s, want %s", b, want)
+				}
+			}
+
+			if requests != 3 {
+				t.Errorf("HTTPClient.Get() with %s requests = %d, want 3", tt.header, requests)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_cachedGetVary(t *testing.T) {
+	requests := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte("lang:" + r.Header.Get("Accept-Language")))
+	}))
+
+	defer ts.Close()
+
+	client := NewHTTPClient(WithHTTPCache(NewHTTPMemoryCache(0)))
+
+	tests := []struct {
+		lang     string
+		requests int
+	}{
+		{lang: "en", requests: 1},
+		{lang: "en", requests: 1},
+		{lang: "zh", requests: 2},
+		{lang: "zh", requests: 2},
+	}
+
+	for _, tt := range tests {
+		b, err := client.Get(ts.URL, WithRequestHeader("accept-language", tt.lang))
+
+		if err != nil {
+			t.Fatalf("HTTPClient.Get() error = %v", err)
+		}
+
+		if want := "lang:" + tt.lang; string(b) != want {
+			t.Errorf("HTTPClient.Get() = %s, want %s", b, want)
+		}
+
+		if requests != tt.requests {
+			t.Errorf("HTTPClient.Get() requests = %d, want %d", requests, tt.requests)
+		}
+	}
+}
+
+func TestHTTPMemoryCache(t *testing.T) {
+	c := NewHTTPMemoryCache(2)
+
+	c.Set("a", []byte("a"), time.Minute)
+	c.Set("b", []byte("b"), time.Minute)
+
+	// a is used recently, b is evicted
+	c.Get("a")
+	c.Set("c", []byte("c"), time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("httpMemoryCache.Get(b) ok = true, want evicted")
+	}
+
+	for _, k := range []string{"a", "c"} {
+		if v, ok := c.Get(k); !ok || string(v) != k {
+			t.Errorf("httpMemoryCache.Get(%s) = %s, %v", k, v, ok)
+		}
+	}
+
+	c.Set("d", []byte("d"), -time.Second)
+
+	if _, ok := c.Get("d"); ok {
+		t.Error("httpMemoryCache.Get(d) ok = true, want expired")
+	}
+
+	if n := len(c.(*httpMemoryCache).items); n != 1 {
+		t.Errorf("httpMemoryCache items = %d, want 1", n)
+	}
+}
@@ -0,0 +1,271 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultFallbackDelay the delay before racing the next address, the same as net.Dialer.
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// newResolver returns a resolver which queries the dns servers (eg: "8.8.8.8:53") at random,
+// returns nil (the default resolver) if addrs is empty.
+func newResolver(addrs []string, timeout time.Duration) *net.Resolver {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := &net.Dialer{Timeout: timeout}
+
+			return d.DialContext(ctx, network, addrs[rand.Intn(len(addrs))])
+		},
+	}
+}
+
+// dnsEntry the resolved ips of a host
+type dnsEntry struct {
+	ips       []net.IP
+	expiresAt time.Time
+}
+
+// dnsCache caches the resolved ips of hosts for a fixed ttl,
+// since the go resolver does not expose the ttl of dns records.
+type dnsCache struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+	entries  map[string]*dnsEntry
+	mutex    sync.RWMutex
+}
+
+func newDNSCache(resolver *net.Resolver, ttl time.Duration) *dnsCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &dnsCache{
+		resolver: resolver,
+		ttl:      ttl,
+		entries:  make(map[string]*dnsEntry),
+	}
+}
+
+// lookup returns the ips of host, a stale entry is returned if the dns lookup fails.
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	c.mutex.RLock()
+	entry, ok := c.entries[host]
+	c.mutex.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.ips, nil
+	}
+
+	addrs, err := c.resolver.LookupIPAddr(ctx, host)
+
+	// a resolver may return no addresses without an error, which isn't cached
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("yiigo: no addresses resolved for %s", host)
+	}
+
+	if err != nil {
+		if ok {
+			return entry.ips, nil
+		}
+
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+
+	for _, v := range addrs {
+		ips = append(ips, v.IP)
+	}
+
+	c.mutex.Lock()
+	c.entries[host] = &dnsEntry{
+		ips:       ips,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+	c.mutex.Unlock()
+
+	return ips, nil
+}
+
+// interleaveIPs orders the ips by alternating the address families (IPv6 first), as RFC 8305 suggests.
+func interleaveIPs(ips []net.IP) []net.IP {
+	v4 := make([]net.IP, 0, len(ips))
+	v6 := make([]net.IP, 0, len(ips))
+
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	result := make([]net.IP, 0, len(ips))
+
+	for i := 0; i < len(v4) || i < len(v6); i++ {
+		if i < len(v6) {
+			result = append(result, v6[i])
+		}
+
+		if i < len(v4) {
+			result = append(result, v4[i])
+		}
+	}
+
+	return result
+}
+
+// networkIPs returns the ips of the address family of network, eg: the IPv4 ones for `tcp4`.
+func networkIPs(network string, ips []net.IP) []net.IP {
+	var v4 bool
+
+	switch network {
+	case "tcp4", "udp4", "ip4":
+		v4 = true
+	case "tcp6", "udp6", "ip6":
+		v4 = false
+	default:
+		return ips
+	}
+
+	result := make([]net.IP, 0, len(ips))
+
+	for _, ip := range ips {
+		if (ip.To4() != nil) == v4 {
+			result = append(result, ip)
+		}
+	}
+
+	return result
+}
+
+// cachedDialer dials the addresses resolved by dns cache, racing the next address
+// every `fallbackDelay` until one connects (Happy Eyeballs).
+type cachedDialer struct {
+	dialer        *net.Dialer
+	cache         *dnsCache
+	fallbackDelay time.Duration
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+func (d *cachedDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := d.cache.lookup(ctx, host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ips = interleaveIPs(networkIPs(network, ips))
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("yiigo: no %s addresses resolved for %s", network, host)
+	}
+
+	if len(ips) == 1 {
+		return d.dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	results := make(chan dialResult, len(ips))
+
+	launched := 0
+
+	launch := func() {
+		ip := ips[launched]
+		launched++
+
+		SafeGo(func() {
+			var conn net.Conn
+
+			// a panic is reported as the dial error, so the race never waits for a missing result
+			err := callSafe(func() (err error) {
+				conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+
+				return
+			})
+
+			results <- dialResult{conn: conn, err: err}
+		})
+	}
+
+	launch()
+
+	timer := time.NewTimer(d.fallbackDelay)
+
+	defer timer.Stop()
+
+	var lastErr error
+
+	for failed := 0; failed < len(ips); {
+		select {
+		case r := <-results:
+			if r.err == nil {
+				// close the connections which win after this one
+				if pending := launched - failed - 1; pending > 0 {
+					SafeGo(func() {
+						for i := 0; i < pending; i++ {
+							if r := <-results; r.conn != nil {
+								r.conn.Close()
+							}
+						}
+					})
+				}
+
+				return r.conn, nil
+			}
+
+			lastErr = r.err
+			failed++
+
+			// the attempt failed, no need to wait for the fallback delay
+			if launched < len(ips) && launched == failed {
+				launch()
+
+				// the timer may have fired, it's drained before reset
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+
+				timer.Reset(d.fallbackDelay)
+			}
+		case <-timer.C:
+			if launched < len(ips) {
+				launch()
+
+				timer.Reset(d.fallbackDelay)
+			}
+		}
+	}
+
+	return nil, lastErr
+}
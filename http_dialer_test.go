@@ -0,0 +1,92 @@
+package yiigo
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_interleaveIPs(t *testing.T) {
+	type args struct {
+		ips []net.IP
+	}
+	tests := []struct {
+		name string
+		args args
+		want []net.IP
+	}{
+		{
+			name: "t1",
+			args: args{
+				ips: []net.IP{
+					net.ParseIP("192.0.2.1"),
+					net.ParseIP("192.0.2.2"),
+					net.ParseIP("192.0.2.3"),
+					net.ParseIP("2001:db8::1"),
+				},
+			},
+			want: []net.IP{
+				net.ParseIP("2001:db8::1"),
+				net.ParseIP("192.0.2.1"),
+				net.ParseIP("192.0.2.2"),
+				net.ParseIP("192.0.2.3"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interleaveIPs(tt.args.ips); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interleaveIPs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_networkIPs(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("2001:db8::1"),
+		net.ParseIP("192.0.2.1"),
+	}
+
+	tests := []struct {
+		network string
+		want    []net.IP
+	}{
+		{network: "tcp", want: ips},
+		{network: "tcp4", want: []net.IP{net.ParseIP("192.0.2.1")}},
+		{network: "tcp6", want: []net.IP{net.ParseIP("2001:db8::1")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			if got := networkIPs(tt.network, ips); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("networkIPs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_DNSCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	}))
+
+	defer ts.Close()
+
+	client := NewHTTPClient(WithHTTPDNSCache(time.Minute), WithHTTPDialFallbackDelay(50*time.Millisecond))
+
+	b, err := client.Get(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1))
+
+	if err != nil {
+		t.Errorf("HTTPClient.Get() error = %v", err)
+
+		return
+	}
+
+	if string(b) != "hello world" {
+		t.Errorf("HTTPClient.Get() = %s, want hello world", b)
+	}
+}
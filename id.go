@@ -0,0 +1,110 @@
+package yiigo
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// ID an int64 id which is encoded as a JSON string, since JavaScript loses the precision of integers beyond 2^53,
+// eg: the ids of SegmentIDGenerator. It's decoded from both a JSON string and number,
+// so the ids sent back by clients are bound as they are, and scanned from and stored to the db as BIGINT.
+//
+//	type User struct {
+//		ID   yiigo.ID `db:"id" json:"id"`
+//		Name string   `db:"name" json:"name"`
+//	}
+type ID int64
+
+// String returns the decimal string of id.
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// Int64 returns the int64 of id.
+func (id ID) Int64() int64 {
+	return int64(id)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (id ID) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 22)
+
+	b = append(b, '"')
+	b = strconv.AppendInt(b, int64(id), 10)
+	b = append(b, '"')
+
+	return b, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts a string, a number and null (leaves id unchanged).
+func (id *ID) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		b = b[1 : len(b)-1]
+
+		// an empty string is treated as zero, eg: an unset form field
+		if len(b) == 0 {
+			*id = 0
+
+			return nil
+		}
+	}
+
+	v, err := strconv.ParseInt(string(b), 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("yiigo: invalid id %s", b)
+	}
+
+	*id = ID(v)
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, which is used by map keys and form/query binding.
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *ID) UnmarshalText(b []byte) error {
+	v, err := strconv.ParseInt(string(b), 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("yiigo: invalid id %s", b)
+	}
+
+	*id = ID(v)
+
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner.
+func (id *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*id = 0
+	case int64:
+		*id = ID(v)
+	case []byte:
+		return id.UnmarshalText(v)
+	case string:
+		return id.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("yiigo: can't scan %T into id", src)
+	}
+
+	return nil
+}
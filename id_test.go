@@ -0,0 +1,85 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIDMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(X{"id": ID(9007199254740993)})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"id":"9007199254740993"}`; string(b) != want {
+		t.Errorf("ID.MarshalJSON() = %s, want %s", b, want)
+	}
+}
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    ID
+		wantErr bool
+	}{
+		{name: "string", data: `{"id":"9007199254740993"}`, want: 9007199254740993},
+		{name: "number", data: `{"id":9007199254740993}`, want: 9007199254740993},
+		{name: "empty", data: `{"id":""}`, want: 0},
+		{name: "null", data: `{"id":null}`, want: 0},
+		{name: "invalid", data: `{"id":"abc"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := new(struct {
+				ID ID `json:"id"`
+			})
+
+			err := json.Unmarshal([]byte(tt.data), v)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ID.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !tt.wantErr && v.ID != tt.want {
+				t.Errorf("ID.UnmarshalJSON() = %v, want %v", v.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestIDScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    ID
+		wantErr bool
+	}{
+		{name: "int64", src: int64(42), want: 42},
+		{name: "bytes", src: []byte("42"), want: 42},
+		{name: "nil", src: nil, want: 0},
+		{name: "float", src: 4.2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id ID
+
+			err := id.Scan(tt.src)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ID.Scan() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if id != tt.want {
+				t.Errorf("ID.Scan() = %v, want %v", id, tt.want)
+			}
+		})
+	}
+}
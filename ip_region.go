@@ -0,0 +1,300 @@
+package yiigo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrIPRegionNotFound returned when the ip isn't in the ip region db.
+var ErrIPRegionNotFound = errors.New("yiigo: ip region not found")
+
+// IPRegion the region of an ip, the unknown parts are empty.
+type IPRegion struct {
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+	Province string `json:"province"`
+	City     string `json:"city"`
+	ISP      string `json:"isp"`
+}
+
+// ipRange a range of IPv4 addresses in the same region.
+type ipRange struct {
+	start  uint32
+	end    uint32
+	region *IPRegion
+}
+
+// ipRegionOptions ip region db options
+type ipRegionOptions struct {
+	reload time.Duration
+}
+
+// IPRegionOption configures how we load the ip region db
+type IPRegionOption interface {
+	apply(options *ipRegionOptions)
+}
+
+// funcIPRegionOption implements ip region option
+type funcIPRegionOption struct {
+	f func(options *ipRegionOptions)
+}
+
+func (fo *funcIPRegionOption) apply(o *ipRegionOptions) {
+	fo.f(o)
+}
+
+func newFuncIPRegionOption(f func(options *ipRegionOptions)) *funcIPRegionOption {
+	return &funcIPRegionOption{f: f}
+}
+
+// WithIPRegionReload specifies the interval of checking the db file, which is reloaded on lookup once it's modified.
+func WithIPRegionReload(d time.Duration) IPRegionOption {
+	return newFuncIPRegionOption(func(o *ipRegionOptions) {
+		o.reload = d
+	})
+}
+
+// IPRegionDB looks up the regions of IPv4 addresses from the ip2region source data,
+// which is a line of `start_ip|end_ip|country|region|province|city|isp` per range, and "0" means unknown.
+type IPRegionDB struct {
+	path    string
+	options *ipRegionOptions
+	ranges  []*ipRange
+	modTime time.Time
+	checked time.Time
+	mutex   sync.RWMutex
+}
+
+// NewIPRegionDB returns a new ip region db loaded from the file, eg: ip2region's `data/ip.merge.txt`.
+func NewIPRegionDB(path string, options ...IPRegionOption) (*IPRegionDB, error) {
+	db := &IPRegionDB{
+		path:    path,
+		options: new(ipRegionOptions),
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(db.options)
+		}
+	}
+
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewIPRegionDBBytes returns a new ip region db loaded from the content, eg: the data embedded into binary.
+func NewIPRegionDBBytes(b []byte) (*IPRegionDB, error) {
+	ranges, err := parseIPRanges(bytes.NewReader(b))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPRegionDB{
+		options: new(ipRegionOptions),
+		ranges:  ranges,
+	}, nil
+}
+
+// Reload reloads the db file, the current data is kept if it fails.
+func (db *IPRegionDB) Reload() error {
+	if db.path == "" {
+		return nil
+	}
+
+	f, err := os.Open(db.path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	ranges, err := parseIPRanges(f)
+
+	if err != nil {
+		return err
+	}
+
+	db.mutex.Lock()
+	db.ranges = ranges
+	db.modTime = info.ModTime()
+	db.checked = time.Now()
+	db.mutex.Unlock()
+
+	return nil
+}
+
+// Lookup returns the region of an IPv4 address.
+func (db *IPRegionDB) Lookup(ip string) (*IPRegion, error) {
+	v := net.ParseIP(ip).To4()
+
+	if v == nil {
+		return nil, fmt.Errorf("yiigo: invalid ipv4 address %s", ip)
+	}
+
+	db.checkReload()
+
+	n := binary.BigEndian.Uint32(v)
+
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return db.ranges[i].end >= n
+	})
+
+	if i == len(db.ranges) || db.ranges[i].start > n {
+		return nil, ErrIPRegionNotFound
+	}
+
+	return db.ranges[i].region, nil
+}
+
+// checkReload reloads the db file if it's modified since the last check, at most once per interval.
+func (db *IPRegionDB) checkReload() {
+	if db.path == "" || db.options.reload <= 0 {
+		return
+	}
+
+	db.mutex.Lock()
+
+	if time.Since(db.checked) < db.options.reload {
+		db.mutex.Unlock()
+
+		return
+	}
+
+	db.checked = time.Now()
+	modTime := db.modTime
+
+	db.mutex.Unlock()
+
+	info, err := os.Stat(db.path)
+
+	if err != nil || info.ModTime().Equal(modTime) {
+		return
+	}
+
+	if err := db.Reload(); err != nil && Logger != nil {
+		Logger.Error("yiigo: reload ip region db", zap.String("path", db.path), zap.Error(err))
+	}
+}
+
+func parseIPRanges(r io.Reader) ([]*ipRange, error) {
+	ranges := make([]*ipRange, 0)
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Split(text, "|")
+
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("yiigo: invalid ip region at line %d", line)
+		}
+
+		start, end := net.ParseIP(fields[0]).To4(), net.ParseIP(fields[1]).To4()
+
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("yiigo: invalid ip range at line %d", line)
+		}
+
+		for i := 2; i < len(fields); i++ {
+			if fields[i] == "0" {
+				fields[i] = ""
+			}
+		}
+
+		ranges = append(ranges, &ipRange{
+			start: binary.BigEndian.Uint32(start),
+			end:   binary.BigEndian.Uint32(end),
+			region: &IPRegion{
+				Country:  fields[2],
+				Region:   fields[3],
+				Province: fields[4],
+				City:     fields[5],
+				ISP:      fields[6],
+			},
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+
+	return ranges, nil
+}
+
+// ipRegionKey the context key of the ip region.
+type ipRegionKey struct{}
+
+// AnnotateIPRegion returns a middleware which looks up the region of client ip and puts it into the request context, see RequestIPRegion.
+// The client ip is taken from `RemoteAddr`, so put it behind the middleware which sets `RemoteAddr` from the headers of trusted proxies.
+//
+//	http.Handle("/orders", yiigo.AnnotateIPRegion(ipdb)(orderHandler))
+func AnnotateIPRegion(db *IPRegionDB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			region, err := db.Lookup(requestIP(r))
+
+			if err != nil {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ipRegionKey{}, region)))
+		})
+	}
+}
+
+// RequestIPRegion returns the region of client ip annotated by AnnotateIPRegion, it's nil if not found.
+func RequestIPRegion(ctx context.Context) *IPRegion {
+	region, _ := ctx.Value(ipRegionKey{}).(*IPRegion)
+
+	return region
+}
+
+// requestIP returns the ip of `RemoteAddr`.
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,111 @@
+package yiigo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testIPRegionData = `0.0.0.0|0.255.255.255|0|0|0|内网IP|内网IP
+1.0.1.0|1.0.3.255|中国|0|福建省|福州市|电信
+1.0.8.0|1.0.15.255|中国|0|广东省|广州市|电信
+`
+
+func TestIPRegionDB_Lookup(t *testing.T) {
+	db, err := NewIPRegionDBBytes([]byte(testIPRegionData))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ip      string
+		want    *IPRegion
+		wantErr bool
+	}{
+		{name: "t1", ip: "1.0.2.1", want: &IPRegion{Country: "中国", Province: "福建省", City: "福州市", ISP: "电信"}},
+		{name: "t2", ip: "1.0.15.255", want: &IPRegion{Country: "中国", Province: "广东省", City: "广州市", ISP: "电信"}},
+		{name: "t3", ip: "0.1.2.3", want: &IPRegion{City: "内网IP", ISP: "内网IP"}},
+		{name: "t4", ip: "1.0.5.1", wantErr: true},
+		{name: "t5", ip: "::1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.Lookup(tt.ip)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IPRegionDB.Lookup() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IPRegionDB.Lookup() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPRegionDB_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yiigo_ip")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ip.merge.txt")
+
+	if err := ioutil.WriteFile(path, []byte(testIPRegionData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewIPRegionDB(path, WithIPRegionReload(time.Nanosecond))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("1.0.1.0|1.0.3.255|中国|0|福建省|厦门市|电信\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// make sure the modified time changes on the file systems of coarse time
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	region, err := db.Lookup("1.0.2.1")
+
+	if err != nil || region.City != "厦门市" {
+		t.Errorf("IPRegionDB.Lookup() got = %v, %v, want 厦门市 after reload", region, err)
+	}
+}
+
+func TestAnnotateIPRegion(t *testing.T) {
+	db, err := NewIPRegionDBBytes([]byte(testIPRegionData))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *IPRegion
+
+	h := AnnotateIPRegion(db)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestIPRegion(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "1.0.8.8:52000"
+
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got == nil || got.City != "广州市" {
+		t.Errorf("RequestIPRegion() got = %v, want 广州市", got)
+	}
+}
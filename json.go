@@ -0,0 +1,67 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONCodec the JSON codec which yiigo marshals and unmarshals with, defaults to encoding/json.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// stdJSONCodec the JSONCodec of encoding/json
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	jsonCodec JSONCodec = stdJSONCodec{}
+
+	jsonCodecs = map[string]JSONCodec{"std": stdJSONCodec{}}
+)
+
+// SetJSONCodec replaces the JSON codec used by the GraphQL client, WebSocket client, notifier, cookie codec and http cache,
+// eg: yiigo.SetJSONCodec(jsoniter.ConfigCompatibleWithStandardLibrary). It should be called before using them.
+// The streaming exports (NDJSON) keep encoding/json.
+func SetJSONCodec(c JSONCodec) {
+	jsonCodec = c
+}
+
+// RegisterJSONCodec registers a JSON codec by name, which is selectable by `app.json_codec` of env config without code changes.
+// The encoding/json codec is registered as "std". It should be called before UseEnv, eg: in init().
+//
+//	yiigo.RegisterJSONCodec("jsoniter", jsoniter.ConfigCompatibleWithStandardLibrary)
+//
+//	# env.toml
+//	[app]
+//	json_codec = "jsoniter"
+func RegisterJSONCodec(name string, c JSONCodec) {
+	jsonCodecs[name] = c
+}
+
+// useEnvJSONCodec sets the JSON codec named by `app.json_codec` of env config, the current one is kept if it's not set.
+func useEnvJSONCodec() error {
+	name := Env.String("app.json_codec")
+
+	if name == "" {
+		return nil
+	}
+
+	c, ok := jsonCodecs[name]
+
+	if !ok {
+		return fmt.Errorf("yiigo: json codec %s is not registered", name)
+	}
+
+	SetJSONCodec(c)
+
+	return nil
+}
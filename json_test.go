@@ -0,0 +1,90 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type countJSONCodec struct {
+	marshals   int
+	unmarshals int
+}
+
+func (c *countJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+
+	return json.Marshal(v)
+}
+
+func (c *countJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+
+	return json.Unmarshal(data, v)
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	codec := new(countJSONCodec)
+
+	SetJSONCodec(codec)
+
+	defer SetJSONCodec(stdJSONCodec{})
+
+	cc, err := NewCookieCodec([]byte("0123456789abcdef"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := cc.Encode("uid", X{"id": 1}, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := X{}
+
+	if err := cc.Decode("uid", v, &dest); err != nil {
+		t.Fatal(err)
+	}
+
+	if codec.marshals != 1 || codec.unmarshals != 1 {
+		t.Errorf("SetJSONCodec() marshals = %d, unmarshals = %d, want 1, 1", codec.marshals, codec.unmarshals)
+	}
+}
+
+func TestRegisterJSONCodec(t *testing.T) {
+	codec := new(countJSONCodec)
+
+	RegisterJSONCodec("count", codec)
+
+	e := Env
+
+	defer func() {
+		Env = e
+		SetJSONCodec(stdJSONCodec{})
+	}()
+
+	tests := []struct {
+		name    string
+		config  string
+		want    JSONCodec
+		wantErr bool
+	}{
+		{name: "t1", config: "[app]\njson_codec = \"count\"", want: codec},
+		{name: "t2", config: "[app]\njson_codec = \"std\"", want: stdJSONCodec{}},
+		{name: "t3", config: "[app]\njson_codec = \"nope\"", want: stdJSONCodec{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UseEnvBytes([]byte(tt.config))
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseEnvBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if jsonCodec != tt.want {
+				t.Errorf("UseEnvBytes() json codec = %T, want %T", jsonCodec, tt.want)
+			}
+		})
+	}
+}
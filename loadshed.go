@@ -0,0 +1,187 @@
+package yiigo
+
+import (
+	"database/sql"
+	"math"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadShedOptions load shedding options
+type loadShedOptions struct {
+	interval      time.Duration
+	maxGoroutines int
+	maxDBWaits    map[string]time.Duration
+	rate          float64
+	retryAfter    time.Duration
+	criticalTags  map[string]struct{}
+}
+
+// LoadShedOption configures how we set up the load shedder
+type LoadShedOption interface {
+	apply(options *loadShedOptions)
+}
+
+// funcLoadShedOption implements load shed option
+type funcLoadShedOption struct {
+	f func(options *loadShedOptions)
+}
+
+func (fo *funcLoadShedOption) apply(o *loadShedOptions) {
+	fo.f(o)
+}
+
+func newFuncLoadShedOption(f func(options *loadShedOptions)) *funcLoadShedOption {
+	return &funcLoadShedOption{f: f}
+}
+
+// WithLoadShedInterval specifies the interval of sampling the goroutine count and db pool stats.
+func WithLoadShedInterval(d time.Duration) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		o.interval = d
+	})
+}
+
+// WithLoadShedMaxGoroutines specifies the threshold of goroutine count, 0 means no check.
+func WithLoadShedMaxGoroutines(n int) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		o.maxGoroutines = n
+	})
+}
+
+// WithLoadShedMaxDBWait specifies the threshold of the average time waited for a connection of the registered db within an interval.
+func WithLoadShedMaxDBWait(name string, d time.Duration) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		o.maxDBWaits[name] = d
+	})
+}
+
+// WithLoadShedRate specifies the percentage (0 to 1) of the non-critical requests rejected when overloaded.
+func WithLoadShedRate(rate float64) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		o.rate = rate
+	})
+}
+
+// WithLoadShedRetryAfter specifies the `Retry-After` of the rejected requests.
+func WithLoadShedRetryAfter(d time.Duration) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		o.retryAfter = d
+	})
+}
+
+// WithLoadShedCriticalTags specifies the route tags which are never rejected, eg: "payment".
+func WithLoadShedCriticalTags(tags ...string) LoadShedOption {
+	return newFuncLoadShedOption(func(o *loadShedOptions) {
+		for _, v := range tags {
+			o.criticalTags[v] = struct{}{}
+		}
+	})
+}
+
+// LoadShedder rejects a percentage of the non-critical requests with 503 when the service is overloaded,
+// which is when the goroutine count or the average wait time of a db pool exceeds the thresholds, to keep the service alive.
+type LoadShedder struct {
+	options    *loadShedOptions
+	sampledAt  time.Time
+	overloaded bool
+	dbStats    map[string]sql.DBStats
+	mutex      sync.Mutex
+}
+
+// NewLoadShedder returns a new load shedder, the load is sampled lazily by the requests at most once per interval.
+//
+//	shedder := yiigo.NewLoadShedder(yiigo.WithLoadShedMaxGoroutines(10000), yiigo.WithLoadShedMaxDBWait(yiigo.AsDefault, 100*time.Millisecond))
+//
+//	http.Handle("/reports", shedder.Middleware("report")(reportHandler))
+//
+// The default `Interval` is 1s.
+// The default `Rate` is 0.5.
+// The default `RetryAfter` is 5s.
+func NewLoadShedder(options ...LoadShedOption) *LoadShedder {
+	o := &loadShedOptions{
+		interval:     time.Second,
+		maxDBWaits:   make(map[string]time.Duration),
+		rate:         0.5,
+		retryAfter:   5 * time.Second,
+		criticalTags: make(map[string]struct{}),
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &LoadShedder{
+		options: o,
+		dbStats: make(map[string]sql.DBStats),
+	}
+}
+
+// Overloaded reports whether the service is overloaded, the load is resampled if the last sample is older than the interval.
+func (s *LoadShedder) Overloaded() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now := time.Now(); now.Sub(s.sampledAt) >= s.options.interval {
+		s.overloaded = s.sample()
+		s.sampledAt = now
+	}
+
+	return s.overloaded
+}
+
+// sample checks the goroutine count, and the average wait time of db pools since the last sample.
+func (s *LoadShedder) sample() bool {
+	overloaded := s.options.maxGoroutines > 0 && runtime.NumGoroutine() > s.options.maxGoroutines
+
+	for name, max := range s.options.maxDBWaits {
+		v, ok := dbmap.Load(name)
+
+		if !ok {
+			continue
+		}
+
+		stats := v.(*sqlx.DB).Stats()
+		last := s.dbStats[name]
+
+		s.dbStats[name] = stats
+
+		if n := stats.WaitCount - last.WaitCount; n > 0 && (stats.WaitDuration-last.WaitDuration)/time.Duration(n) > max {
+			overloaded = true
+		}
+	}
+
+	return overloaded
+}
+
+// Middleware returns a middleware of the routes tagged by tag, which rejects the requests with 503 and `Retry-After` at the rate when overloaded,
+// unless the tag is critical (see WithLoadShedCriticalTags).
+func (s *LoadShedder) Middleware(tag string) func(http.Handler) http.Handler {
+	_, critical := s.options.criticalTags[tag]
+
+	return func(next http.Handler) http.Handler {
+		if critical {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.Overloaded() && rand.Float64() < s.options.rate {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(s.options.retryAfter.Seconds()))))
+
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
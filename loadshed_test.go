@@ -0,0 +1,46 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadShedder_Middleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// any process has more than one goroutine
+	shedder := NewLoadShedder(WithLoadShedMaxGoroutines(1), WithLoadShedRate(1), WithLoadShedCriticalTags("payment"))
+
+	tests := []struct {
+		name string
+		tag  string
+		want int
+	}{
+		{name: "t1", tag: "report", want: http.StatusServiceUnavailable},
+		{name: "t2", tag: "payment", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			shedder.Middleware(tt.tag)(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			if w.Code != tt.want {
+				t.Errorf("LoadShedder.Middleware() status = %d, want %d", w.Code, tt.want)
+			}
+
+			if tt.want == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "5" {
+				t.Errorf("LoadShedder.Middleware() Retry-After = %s, want 5", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+
+	NewLoadShedder(WithLoadShedRate(1)).Middleware("report")(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("LoadShedder.Middleware() status = %d, want 200 when not overloaded", w.Code)
+	}
+}
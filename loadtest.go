@@ -0,0 +1,213 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoadTestResult the report of a load test.
+type LoadTestResult struct {
+	Requests int // the requests completed
+	Errors   int
+	Dropped  int // the requests not sent since all the workers were busy, which means the target rps is not reached
+	Duration time.Duration
+	RPS      float64 // the actual requests per second
+	Min      time.Duration
+	Mean     time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+	ErrorSet map[string]int // the count of every distinct error
+}
+
+// String returns the printable report.
+func (r *LoadTestResult) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "requests: %d, errors: %d, dropped: %d, duration: %s, rps: %.2f\n", r.Requests, r.Errors, r.Dropped, r.Duration, r.RPS)
+	fmt.Fprintf(&b, "latency: min %s, mean %s, p50 %s, p90 %s, p99 %s, max %s\n", r.Min, r.Mean, r.P50, r.P90, r.P99, r.Max)
+
+	for k, v := range r.ErrorSet {
+		fmt.Fprintf(&b, "error (%d): %s\n", v, k)
+	}
+
+	return b.String()
+}
+
+// loadTestOptions load test options
+type loadTestOptions struct {
+	concurrency int
+}
+
+// LoadTestOption configures how we run the load test
+type LoadTestOption interface {
+	apply(options *loadTestOptions)
+}
+
+// funcLoadTestOption implements load test option
+type funcLoadTestOption struct {
+	f func(options *loadTestOptions)
+}
+
+func (fo *funcLoadTestOption) apply(o *loadTestOptions) {
+	fo.f(o)
+}
+
+func newFuncLoadTestOption(f func(options *loadTestOptions)) *funcLoadTestOption {
+	return &funcLoadTestOption{f: f}
+}
+
+// WithLoadTestConcurrency specifies the maximum requests in flight.
+func WithLoadTestConcurrency(n int) LoadTestOption {
+	return newFuncLoadTestOption(func(o *loadTestOptions) {
+		o.concurrency = n
+	})
+}
+
+// LoadTest calls f at the target rps for duration (or until ctx is done), and reports the latency percentiles.
+//
+// The default `Concurrency` is 100.
+func LoadTest(ctx context.Context, rps int, duration time.Duration, f func() error, options ...LoadTestOption) *LoadTestResult {
+	o := &loadTestOptions{concurrency: 100}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	if rps <= 0 {
+		rps = 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		latencies = make([]time.Duration, 0, rps*int(duration/time.Second+1))
+		errs      = make(map[string]int)
+		dropped   int
+	)
+
+	sem := make(chan struct{}, o.concurrency)
+
+	ticker := time.NewTicker(time.Second / time.Duration(rps))
+
+	defer ticker.Stop()
+
+	start := time.Now()
+
+	for loop := true; loop; {
+		select {
+		case <-ctx.Done():
+			loop = false
+		case <-ticker.C:
+			select {
+			case sem <- struct{}{}:
+			default:
+				dropped++
+
+				continue
+			}
+
+			wg.Add(1)
+
+			SafeGo(func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				t := time.Now()
+
+				err := callSafe(f)
+
+				d := time.Since(t)
+
+				mutex.Lock()
+
+				latencies = append(latencies, d)
+
+				if err != nil {
+					errs[err.Error()]++
+				}
+
+				mutex.Unlock()
+			})
+		}
+	}
+
+	wg.Wait()
+
+	result := newLoadTestResult(latencies, errs, time.Since(start))
+	result.Dropped = dropped
+
+	return result
+}
+
+// newLoadTestResult calculates the report of latencies.
+func newLoadTestResult(latencies []time.Duration, errs map[string]int, elapsed time.Duration) *LoadTestResult {
+	r := &LoadTestResult{
+		Requests: len(latencies),
+		Duration: elapsed,
+		ErrorSet: errs,
+	}
+
+	for _, v := range errs {
+		r.Errors += v
+	}
+
+	if elapsed > 0 {
+		r.RPS = float64(r.Requests) / elapsed.Seconds()
+	}
+
+	if len(latencies) == 0 {
+		return r
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var sum time.Duration
+
+	for _, v := range latencies {
+		sum += v
+	}
+
+	percentile := func(p int) time.Duration {
+		// nearest-rank method
+		i := (len(latencies)*p + 99) / 100
+
+		if i < 1 {
+			i = 1
+		}
+
+		return latencies[i-1]
+	}
+
+	r.Min = latencies[0]
+	r.Max = latencies[len(latencies)-1]
+	r.Mean = sum / time.Duration(len(latencies))
+	r.P50 = percentile(50)
+	r.P90 = percentile(90)
+	r.P99 = percentile(99)
+
+	return r
+}
+
+// LoadTest sends the request at the target rps for duration (or until ctx is done), and reports the latency percentiles.
+// A non-200 response counts as an error.
+func (h *HTTPClient) LoadTest(ctx context.Context, req *HTTPBatchRequest, rps int, duration time.Duration, options ...LoadTestOption) *LoadTestResult {
+	return LoadTest(ctx, rps, duration, func() error {
+		_, err := h.do(req.Method, req.URL, req.Body, req.Options...)
+
+		return err
+	}, options...)
+}
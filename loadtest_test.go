@@ -0,0 +1,51 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_newLoadTestResult(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	r := newLoadTestResult(latencies, map[string]int{"timeout": 2}, 10*time.Second)
+
+	want := &LoadTestResult{
+		Requests: 100,
+		Errors:   2,
+		Duration: 10 * time.Second,
+		RPS:      10,
+		Min:      time.Millisecond,
+		Mean:     50500 * time.Microsecond,
+		P50:      50 * time.Millisecond,
+		P90:      90 * time.Millisecond,
+		P99:      99 * time.Millisecond,
+		Max:      100 * time.Millisecond,
+	}
+
+	if r.Requests != want.Requests || r.Errors != want.Errors || r.RPS != want.RPS || r.Min != want.Min || r.Mean != want.Mean ||
+		r.P50 != want.P50 || r.P90 != want.P90 || r.P99 != want.P99 || r.Max != want.Max {
+		t.Errorf("newLoadTestResult() = %v, want %v", r, want)
+	}
+}
+
+func TestHTTPClient_LoadTest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	defer ts.Close()
+
+	r := NewHTTPClient().LoadTest(context.Background(), &HTTPBatchRequest{Method: "GET", URL: ts.URL}, 100, 300*time.Millisecond)
+
+	if r.Requests < 10 || r.Errors != 0 {
+		t.Errorf("HTTPClient.LoadTest() = %v, want about 30 requests without errors", r)
+	}
+}
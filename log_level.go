@@ -0,0 +1,151 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SetLogLevel changes the level of the registered logger at runtime.
+func SetLogLevel(name string, l zapcore.Level) error {
+	v, ok := logLevelMap.Load(name)
+
+	if !ok {
+		return fmt.Errorf("yiigo: logger.%s is not registered", name)
+	}
+
+	v.(*logLevel).atom.SetLevel(l)
+
+	return nil
+}
+
+// LogLevels returns the current levels of all the registered loggers.
+func LogLevels() map[string]zapcore.Level {
+	levels := make(map[string]zapcore.Level)
+
+	logLevelMap.Range(func(k, v interface{}) bool {
+		levels[k.(string)] = v.(*logLevel).atom.Level()
+
+		return true
+	})
+
+	return levels
+}
+
+// LogLevelHandler returns an admin handler to view and change the levels of the registered loggers,
+// which should be protected (eg: listened on an internal port).
+//
+//	GET /log/level                           -> {"default":"info","foo":"debug"}
+//	PUT /log/level?name=default&level=debug  -> {"default":"debug"}
+func LogLevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		switch r.Method {
+		case http.MethodGet:
+			levels := LogLevels()
+
+			if name := r.URL.Query().Get("name"); name != "" {
+				l, ok := levels[name]
+
+				if !ok {
+					writeLogLevelError(w, http.StatusNotFound, fmt.Sprintf("logger.%s is not registered", name))
+
+					return
+				}
+
+				levels = map[string]zapcore.Level{name: l}
+			}
+
+			writeLogLevels(w, levels)
+		case http.MethodPut, http.MethodPost:
+			name := r.FormValue("name")
+
+			if name == "" {
+				name = AsDefault
+			}
+
+			level := r.FormValue("level")
+
+			// zap reads an empty level as info, which would reset the logger silently
+			if level == "" {
+				writeLogLevelError(w, http.StatusBadRequest, "level is required")
+
+				return
+			}
+
+			var l zapcore.Level
+
+			if err := l.UnmarshalText([]byte(level)); err != nil {
+				writeLogLevelError(w, http.StatusBadRequest, err.Error())
+
+				return
+			}
+
+			if err := SetLogLevel(name, l); err != nil {
+				writeLogLevelError(w, http.StatusNotFound, fmt.Sprintf("logger.%s is not registered", name))
+
+				return
+			}
+
+			writeLogLevels(w, map[string]zapcore.Level{name: l})
+		default:
+			writeLogLevelError(w, http.StatusMethodNotAllowed, "only GET, PUT and POST are allowed")
+		}
+	})
+}
+
+func writeLogLevels(w http.ResponseWriter, levels map[string]zapcore.Level) {
+	data := make(map[string]string, len(levels))
+
+	for k, v := range levels {
+		data[k] = v.String()
+	}
+
+	b, _ := jsonCodec.Marshal(data)
+
+	w.Write(b)
+}
+
+func writeLogLevelError(w http.ResponseWriter, status int, msg string) {
+	b, _ := jsonCodec.Marshal(map[string]string{"error": msg})
+
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// WatchLogLevelSignals switches all the registered loggers to debug level on the signal `debug`,
+// and restores their registered levels on the signal `restore`, until ctx is done.
+//
+//	go yiigo.WatchLogLevelSignals(ctx, syscall.SIGUSR1, syscall.SIGUSR2)
+func WatchLogLevelSignals(ctx context.Context, debug, restore os.Signal) {
+	ch := make(chan os.Signal, 1)
+
+	signal.Notify(ch, debug, restore)
+
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-ch:
+			logLevelMap.Range(func(k, v interface{}) bool {
+				level := v.(*logLevel)
+
+				if sig == debug {
+					level.atom.SetLevel(zap.DebugLevel)
+				} else {
+					level.atom.SetLevel(level.initial)
+				}
+
+				return true
+			})
+		}
+	}
+}
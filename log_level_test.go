@@ -0,0 +1,66 @@
+package yiigo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yiigo_log")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	RegisterLogger("level_test", filepath.Join(dir, "level_test.log"), WithLogLevel(zap.InfoLevel))
+
+	l := UseLogger("level_test")
+
+	if l.Core().Enabled(zap.DebugLevel) {
+		t.Fatal("RegisterLogger() debug enabled, want info level")
+	}
+
+	h := LogLevelHandler()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		body   string
+	}{
+		{name: "t1", method: http.MethodGet, target: "/?name=level_test", status: http.StatusOK, body: `{"level_test":"info"}`},
+		{name: "t2", method: http.MethodPut, target: "/?name=level_test&level=debug", status: http.StatusOK, body: `{"level_test":"debug"}`},
+		{name: "t3", method: http.MethodPut, target: "/?name=level_test&level=loud", status: http.StatusBadRequest},
+		{name: "t4", method: http.MethodPut, target: "/?name=nope&level=debug", status: http.StatusNotFound},
+		{name: "t5", method: http.MethodPut, target: "/?name=level_test&levle=debug", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.status {
+				t.Errorf("LogLevelHandler() status = %d, want %d", w.Code, tt.status)
+			}
+
+			if tt.body != "" && strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Errorf("LogLevelHandler() body = %s, want %s", w.Body.String(), tt.body)
+			}
+		})
+	}
+
+	if !l.Core().Enabled(zap.DebugLevel) {
+		t.Error("LogLevelHandler() debug disabled after PUT")
+	}
+}
@@ -2,6 +2,7 @@ package yiigo
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -12,8 +13,9 @@ import (
 
 var (
 	// Logger default logger
-	Logger *zap.Logger
-	logMap sync.Map
+	Logger      *zap.Logger
+	logMap      sync.Map
+	logLevelMap sync.Map
 )
 
 type logOptions struct {
@@ -22,6 +24,18 @@ type logOptions struct {
 	maxBackups int
 	compress   bool
 	debug      bool
+	level      zapcore.Level
+	caller     bool
+	callerSkip int
+	stacktrace bool
+	sampling   *logSampling
+	alerts     []zapcore.Core
+}
+
+type logSampling struct {
+	tick       time.Duration
+	first      int
+	thereafter int
 }
 
 // LogOption configures how we set up the logger
@@ -89,9 +103,77 @@ func WithLogDebug(b bool) LogOption {
 	})
 }
 
-// initLogger init logger, the default `MaxSize` is 500M.
-func initLogger(logfile string, options ...LogOption) *zap.Logger {
-	o := &logOptions{maxSize: 500}
+// WithLogLevel specifies the minimum level of logger, which can be changed at runtime, see LogLevelHandler.
+func WithLogLevel(l zapcore.Level) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		o.level = l
+	})
+}
+
+// WithLogCaller specifies whether to annotate the logs with the caller's file:line.
+func WithLogCaller(b bool) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		o.caller = b
+	})
+}
+
+// WithLogCallerSkip specifies the number of callers skipped by caller annotation,
+// eg: 1 when the logger is called by a wrapper function of your own.
+func WithLogCallerSkip(n int) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		o.callerSkip = n
+	})
+}
+
+// WithLogStacktrace specifies whether to record the stack traces of error level and above (warn and above in debug mode).
+func WithLogStacktrace(b bool) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		o.stacktrace = b
+	})
+}
+
+// WithLogSampling specifies the sampling of logger, which logs the first `first` entries of the same level and message
+// within every tick, and every `thereafter`-th entry after that, so a failing dependency doesn't fill the disk.
+// A `thereafter` <= 0 suppresses all the duplicates within the tick. The alerts (see WithLogAlert) are not sampled.
+func WithLogSampling(tick time.Duration, first, thereafter int) LogOption {
+	return newFuncLogOption(func(o *logOptions) {
+		if thereafter <= 0 {
+			// never reached within a tick
+			thereafter = math.MaxInt32
+		}
+
+		o.sampling = &logSampling{
+			tick:       tick,
+			first:      first,
+			thereafter: thereafter,
+		}
+	})
+}
+
+// sample wraps core with the sampling if specified.
+func (o *logOptions) sample(core zapcore.Core) zapcore.Core {
+	if o.sampling == nil {
+		return core
+	}
+
+	return zapcore.NewSampler(core, o.sampling.tick, o.sampling.first, o.sampling.thereafter)
+}
+
+// logLevel the level of a registered logger
+type logLevel struct {
+	atom    zap.AtomicLevel
+	initial zapcore.Level
+}
+
+// initLogger init logger, the default `MaxSize` is 500M, and the default `Level` is debug.
+// The caller and stacktrace are enabled by default.
+func initLogger(logfile string, options ...LogOption) (*zap.Logger, *logLevel) {
+	o := &logOptions{
+		maxSize:    500,
+		level:      zap.DebugLevel,
+		caller:     true,
+		stacktrace: true,
+	}
 
 	if len(options) > 0 {
 		for _, option := range options {
@@ -99,15 +181,26 @@ func initLogger(logfile string, options ...LogOption) *zap.Logger {
 		}
 	}
 
+	level := &logLevel{
+		atom:    zap.NewAtomicLevelAt(o.level),
+		initial: o.level,
+	}
+
 	if o.debug {
 		cfg := zap.NewDevelopmentConfig()
 
+		cfg.Level = level.atom
+
 		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 		cfg.EncoderConfig.EncodeTime = MyTimeEncoder
+		cfg.DisableCaller = !o.caller
+		cfg.DisableStacktrace = !o.stacktrace
 
-		l, _ := cfg.Build()
+		l, _ := cfg.Build(zap.AddCallerSkip(o.callerSkip), zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(append([]zapcore.Core{o.sample(core)}, o.alerts...)...)
+		}))
 
-		return l
+		return l, level
 	}
 
 	w := zapcore.AddSync(&lumberjack.Logger{
@@ -127,17 +220,34 @@ func initLogger(logfile string, options ...LogOption) *zap.Logger {
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(cfg),
 		w,
-		zap.DebugLevel,
+		level.atom,
 	)
 
-	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	core = o.sample(core)
+
+	if len(o.alerts) > 0 {
+		core = zapcore.NewTee(append([]zapcore.Core{core}, o.alerts...)...)
+	}
+
+	zapOptions := make([]zap.Option, 0, 3)
+
+	if o.caller {
+		zapOptions = append(zapOptions, zap.AddCaller(), zap.AddCallerSkip(o.callerSkip))
+	}
+
+	if o.stacktrace {
+		zapOptions = append(zapOptions, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+
+	return zap.New(core, zapOptions...), level
 }
 
 // RegisterLogger register logger
 func RegisterLogger(name, file string, options ...LogOption) {
-	logger := initLogger(file, options...)
+	logger, level := initLogger(file, options...)
 
 	logMap.Store(name, logger)
+	logLevelMap.Store(name, level)
 
 	if name == AsDefault {
 		Logger = logger
@@ -155,7 +265,7 @@ func UseLogger(name string) *zap.Logger {
 	return v.(*zap.Logger)
 }
 
-// MyTimeEncoder zap time encoder.
+// MyTimeEncoder zap time encoder, in the timezone of application.
 func MyTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-	enc.AppendString(t.Format("2006-01-02 15:04:05"))
+	enc.AppendString(t.In(TimeLocation()).Format("2006-01-02 15:04:05"))
 }
@@ -0,0 +1,105 @@
+package yiigo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithLogSampling(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      int
+		thereafter int
+		want       int
+	}{
+		{name: "t1", first: 2, thereafter: 3, want: 4},
+		{name: "t2", first: 1, thereafter: 0, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := new(logOptions)
+
+			WithLogSampling(time.Minute, tt.first, tt.thereafter).apply(o)
+
+			core, logs := observer.New(zap.DebugLevel)
+
+			l := zap.New(o.sample(core))
+
+			for i := 0; i < 10; i++ {
+				l.Error("redis is down")
+			}
+
+			if n := logs.Len(); n != tt.want {
+				t.Errorf("WithLogSampling() logged %d, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+// logTestError logs an error as a wrapper function of logger.
+func logTestError(l *zap.Logger) {
+	l.Error("redis is down")
+}
+
+func TestInitLogger_caller(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yiigo_log")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name       string
+		options    []LogOption
+		wantCaller string
+		wantStack  bool
+	}{
+		{name: "t1", wantCaller: "logTestError", wantStack: true},
+		{name: "t2", options: []LogOption{WithLogCaller(false)}, wantCaller: "", wantStack: true},
+		{name: "t3", options: []LogOption{WithLogCallerSkip(1)}, wantCaller: "TestInitLogger_caller", wantStack: true},
+		{name: "t4", options: []LogOption{WithLogStacktrace(false)}, wantCaller: "logTestError", wantStack: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.DebugLevel)
+
+			options := append(tt.options, newFuncLogOption(func(o *logOptions) {
+				o.alerts = append(o.alerts, core)
+			}))
+
+			l, _ := initLogger(filepath.Join(dir, tt.name+".log"), options...)
+
+			logTestError(l)
+
+			if logs.Len() != 1 {
+				t.Fatalf("initLogger() logged %d, want 1", logs.Len())
+			}
+
+			entry := logs.All()[0].Entry
+
+			caller := ""
+
+			if entry.Caller.Defined {
+				caller = runtime.FuncForPC(entry.Caller.PC).Name()
+			}
+
+			if (tt.wantCaller == "" && caller != "") || !strings.Contains(caller, tt.wantCaller) {
+				t.Errorf("initLogger() caller = %s, want %s", caller, tt.wantCaller)
+			}
+
+			if got := entry.Stack != ""; got != tt.wantStack {
+				t.Errorf("initLogger() stacktrace = %v, want %v", got, tt.wantStack)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// LongPollHub dispatches the messages of channels to the long polling requests waiting on them, for the clients which can't use WebSockets.
+// The messages are published locally by Publish, or relayed from redis pub/sub by SubscribeRedis,
+// so all the instances of a service get the messages with one redis connection per instance.
+//
+//	hub := yiigo.NewLongPollHub()
+//
+//	go hub.SubscribeRedis(ctx, yiigo.Redis, "order:*")
+//
+//	// GET /orders/:id/poll
+//	hub.Handle(w, r, "order:"+id, 30*time.Second)
+//
+//	// the publisher
+//	conn.Do("PUBLISH", "order:"+id, data)
+//
+// A message published when a client isn't waiting (eg: between two polls) is missed,
+// so the message should be a notification to fetch the latest state, or the client should fetch it after a timeout.
+type LongPollHub struct {
+	waiters map[string]map[chan []byte]struct{}
+	mutex   sync.Mutex
+}
+
+// NewLongPollHub returns a new long poll hub.
+func NewLongPollHub() *LongPollHub {
+	return &LongPollHub{waiters: make(map[string]map[chan []byte]struct{})}
+}
+
+// Publish sends the message to the waiters of channel, and returns the number of waiters.
+func (h *LongPollHub) Publish(channel string, msg []byte) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	waiters := h.waiters[channel]
+
+	for ch := range waiters {
+		// the buffer is 1 and a waiter is removed once it gets a message, so never blocks
+		ch <- msg
+	}
+
+	delete(h.waiters, channel)
+
+	return len(waiters)
+}
+
+// Wait waits for the next message of channel, it returns ctx.Err() if ctx is done before that, eg: timeout.
+func (h *LongPollHub) Wait(ctx context.Context, channel string) ([]byte, error) {
+	ch := make(chan []byte, 1)
+
+	h.mutex.Lock()
+
+	if h.waiters[channel] == nil {
+		h.waiters[channel] = make(map[chan []byte]struct{})
+	}
+
+	h.waiters[channel][ch] = struct{}{}
+
+	h.mutex.Unlock()
+
+	select {
+	case msg := <-ch:
+		return msg, nil
+	case <-ctx.Done():
+	}
+
+	h.mutex.Lock()
+
+	delete(h.waiters[channel], ch)
+
+	if len(h.waiters[channel]) == 0 {
+		delete(h.waiters, channel)
+	}
+
+	h.mutex.Unlock()
+
+	// the message may be published after ctx is done and before the waiter is removed
+	select {
+	case msg := <-ch:
+		return msg, nil
+	default:
+	}
+
+	return nil, ctx.Err()
+}
+
+// Handle waits for the next message of channel until timeout, and responds it as is (`Content-Type` defaults to application/json),
+// or responds 204 No Content on timeout. Nothing is responded if the client disconnects, eg: with gin `hub.Handle(c.Writer, c.Request, ...)`.
+func (h *LongPollHub) Handle(w http.ResponseWriter, r *http.Request, channel string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+
+	defer cancel()
+
+	msg, err := h.Wait(ctx, channel)
+
+	if err != nil {
+		if r.Context().Err() == nil {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	w.Write(msg)
+}
+
+// SubscribeRedis subscribes the channel patterns (eg: "order:*") of redis and publishes the messages to the hub,
+// it blocks until ctx is done and resubscribes after 1s when the connection fails.
+// The subscription uses a dedicated connection of pool without read timeout, rather than a pooled one.
+func (h *LongPollHub) SubscribeRedis(ctx context.Context, pool *RedisPoolResource, patterns ...string) error {
+	args := make([]interface{}, 0, len(patterns))
+
+	for _, v := range patterns {
+		args = append(args, v)
+	}
+
+	for {
+		err := h.subscribeRedis(ctx, pool, args)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if Logger != nil {
+			Logger.Warn("yiigo: long poll redis subscription failed, resubscribing", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *LongPollHub) subscribeRedis(ctx context.Context, pool *RedisPoolResource, patterns []interface{}) error {
+	conn, err := pool.dial()
+
+	if err != nil {
+		return err
+	}
+
+	// closing the connection unblocks the receiving when ctx is done
+	done := make(chan struct{})
+
+	defer close(done)
+
+	SafeGo(func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+
+		conn.Close()
+	})
+
+	psc := redis.PubSubConn{Conn: conn}
+
+	if err := psc.PSubscribe(patterns...); err != nil {
+		return err
+	}
+
+	for {
+		switch v := psc.ReceiveWithTimeout(0).(type) {
+		case redis.Message:
+			h.Publish(v.Channel, v.Data)
+		case error:
+			return v
+		}
+	}
+}
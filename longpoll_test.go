@@ -0,0 +1,43 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLongPollHub_Handle(t *testing.T) {
+	hub := NewLongPollHub()
+
+	go func() {
+		// wait for the request to be waiting
+		for i := 0; i < 100; i++ {
+			if hub.Publish("order:1", []byte(`{"status":"paid"}`)) > 0 {
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	w := httptest.NewRecorder()
+
+	hub.Handle(w, httptest.NewRequest(http.MethodGet, "/orders/1/poll", nil), "order:1", time.Second)
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"paid"}` {
+		t.Errorf("LongPollHub.Handle() = %d %s, want 200 {\"status\":\"paid\"}", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+
+	hub.Handle(w, httptest.NewRequest(http.MethodGet, "/orders/2/poll", nil), "order:2", 10*time.Millisecond)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("LongPollHub.Handle() timeout = %d, want 204", w.Code)
+	}
+
+	if n := hub.Publish("order:2", []byte("{}")); n != 0 {
+		t.Errorf("LongPollHub.Publish() after timeout = %d, want 0", n)
+	}
+}
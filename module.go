@@ -0,0 +1,104 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Module a pluggable component (eg: a custom store or notifier) which is initialized and started by Bootstrap,
+// and stopped by Shutdown together with the builtin resources.
+type Module interface {
+	// Name returns the unique name of module, its config is the `[name]` section of env.
+	Name() string
+	// Init initializes the module with its config, which is empty if not found.
+	Init(cfg X) error
+	// Start starts the module, it should not block.
+	Start(ctx context.Context) error
+	// Stop stops the module, it should return when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+var (
+	modules      []Module
+	modulesStart int // the count of started modules
+	moduleMutex  sync.Mutex
+)
+
+// RegisterModule registers a module, the modules are started in the order of registration and stopped in reverse.
+func RegisterModule(m Module) error {
+	moduleMutex.Lock()
+	defer moduleMutex.Unlock()
+
+	for _, v := range modules {
+		if v.Name() == m.Name() {
+			return fmt.Errorf("yiigo: module.%s is already registered", m.Name())
+		}
+	}
+
+	modules = append(modules, m)
+
+	return nil
+}
+
+// Bootstrap initializes and starts the registered modules, the started modules are stopped if any fails.
+func Bootstrap(ctx context.Context) error {
+	moduleMutex.Lock()
+	defer moduleMutex.Unlock()
+
+	for _, m := range modules {
+		cfg := X{}
+
+		if Env != nil {
+			cfg = X(Env.Map(m.Name()))
+		}
+
+		if err := m.Init(cfg); err != nil {
+			return fmt.Errorf("yiigo: module.%s init error: %v", m.Name(), err)
+		}
+	}
+
+	for i, m := range modules {
+		if err := m.Start(ctx); err != nil {
+			modulesStart = i
+			stopModules(ctx)
+
+			return fmt.Errorf("yiigo: module.%s start error: %v", m.Name(), err)
+		}
+	}
+
+	modulesStart = len(modules)
+
+	return nil
+}
+
+// Shutdown stops the started modules in reverse order, then closes all the registered dbs, mongodbs and redis pools,
+// until ctx is done. It returns the first error.
+func Shutdown(ctx context.Context) error {
+	moduleMutex.Lock()
+	err := stopModules(ctx)
+	moduleMutex.Unlock()
+
+	for _, f := range []func(ctx context.Context) error{CloseAllDB, CloseAllMongo, CloseAllRedis} {
+		if e := f(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// stopModules stops the started modules in reverse order, the mutex should be held.
+func stopModules(ctx context.Context) error {
+	var err error
+
+	for i := modulesStart - 1; i >= 0; i-- {
+		if e := modules[i].Stop(ctx); e != nil && err == nil {
+			err = fmt.Errorf("yiigo: module.%s stop error: %v", modules[i].Name(), e)
+		}
+	}
+
+	modulesStart = 0
+
+	return err
+}
@@ -0,0 +1,82 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testModule struct {
+	name     string
+	startErr error
+	calls    *[]string
+}
+
+func (m *testModule) Name() string { return m.name }
+
+func (m *testModule) Init(cfg X) error {
+	*m.calls = append(*m.calls, "init "+m.name)
+
+	return nil
+}
+
+func (m *testModule) Start(ctx context.Context) error {
+	*m.calls = append(*m.calls, "start "+m.name)
+
+	return m.startErr
+}
+
+func (m *testModule) Stop(ctx context.Context) error {
+	*m.calls = append(*m.calls, "stop "+m.name)
+
+	return nil
+}
+
+func TestBootstrap(t *testing.T) {
+	defer func() {
+		modules = nil
+	}()
+
+	tests := []struct {
+		name     string
+		startErr error
+		wantErr  bool
+		calls    []string
+	}{
+		{
+			name:  "t1",
+			calls: []string{"init a", "init b", "start a", "start b", "stop b", "stop a"},
+		},
+		{
+			name:     "t2",
+			startErr: errors.New("port in use"),
+			wantErr:  true,
+			calls:    []string{"init a", "init b", "start a", "start b", "stop a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules = nil
+
+			var calls []string
+
+			RegisterModule(&testModule{name: "a", calls: &calls})
+			RegisterModule(&testModule{name: "b", startErr: tt.startErr, calls: &calls})
+
+			if err := RegisterModule(&testModule{name: "a", calls: &calls}); err == nil {
+				t.Error("RegisterModule() duplicate error = nil")
+			}
+
+			if err := Bootstrap(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Bootstrap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			Shutdown(context.Background())
+
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("Bootstrap() calls = %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+}
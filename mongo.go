@@ -375,3 +375,36 @@ func UseMongo(name string) *mongo.Client {
 
 	return v.(*mongo.Client)
 }
+
+// CloseMongo disconnects the registered mongo client, it waits for the operations in use to complete until `ctx` is done.
+// Closing the default mongo client sets yiigo.Mongo to nil.
+func CloseMongo(ctx context.Context, name string) error {
+	v, ok := mgoMap.Load(name)
+
+	if !ok {
+		return fmt.Errorf("yiigo: mongo.%s is not registered", name)
+	}
+
+	mgoMap.Delete(name)
+
+	if name == AsDefault && Mongo == v.(*mongo.Client) {
+		Mongo = nil
+	}
+
+	return v.(*mongo.Client).Disconnect(ctx)
+}
+
+// CloseAllMongo disconnects all the registered mongo clients, it waits for the operations in use to complete until `ctx` is done.
+func CloseAllMongo(ctx context.Context) error {
+	var err error
+
+	mgoMap.Range(func(k, v interface{}) bool {
+		if e := CloseMongo(ctx, k.(string)); e != nil && err == nil {
+			err = e
+		}
+
+		return true
+	})
+
+	return err
+}
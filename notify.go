@@ -0,0 +1,375 @@
+package yiigo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NotifyChannel indicates the group robot which notifications are sent to.
+type NotifyChannel int
+
+const (
+	NotifyDingTalk NotifyChannel = 1 // DingTalk group robot
+	NotifyWeCom    NotifyChannel = 2 // WeCom (WeChat Work) group robot
+)
+
+var (
+	// ErrNotifyQueueFull returned when the async queue of notifier is full.
+	ErrNotifyQueueFull = errors.New("yiigo: notify queue is full")
+	// ErrNotifierClosed returned when sending with a closed notifier.
+	ErrNotifierClosed = errors.New("yiigo: notifier is closed")
+)
+
+// NotifyMessage a message of group robot, see NotifyText, NotifyMarkdown and NotifyCard.
+type NotifyMessage struct {
+	msgType   string
+	title     string
+	content   string
+	btnTitle  string
+	btnURL    string
+	atMobiles []string
+}
+
+// NotifyText returns a text message, which @ the members of mobiles, "@all" means everyone.
+func NotifyText(content string, atMobiles ...string) *NotifyMessage {
+	return &NotifyMessage{
+		msgType:   "text",
+		content:   content,
+		atMobiles: atMobiles,
+	}
+}
+
+// NotifyMarkdown returns a markdown message, the title is shown in the conversation list of DingTalk and ignored by WeCom.
+func NotifyMarkdown(title, content string) *NotifyMessage {
+	return &NotifyMessage{
+		msgType: "markdown",
+		title:   title,
+		content: content,
+	}
+}
+
+// NotifyCard returns a card message with a button which links to url.
+// It's an `actionCard` of DingTalk (content in markdown), and a `news` of WeCom (content as description).
+func NotifyCard(title, content, btnTitle, url string) *NotifyMessage {
+	return &NotifyMessage{
+		msgType:  "card",
+		title:    title,
+		content:  content,
+		btnTitle: btnTitle,
+		btnURL:   url,
+	}
+}
+
+// dingTalkBody returns the request body of DingTalk robot.
+func (m *NotifyMessage) dingTalkBody() X {
+	switch m.msgType {
+	case "markdown":
+		return X{
+			"msgtype":  "markdown",
+			"markdown": X{"title": m.title, "text": m.content},
+		}
+	case "card":
+		return X{
+			"msgtype": "actionCard",
+			"actionCard": X{
+				"title":       m.title,
+				"text":        m.content,
+				"singleTitle": m.btnTitle,
+				"singleURL":   m.btnURL,
+			},
+		}
+	}
+
+	at := X{}
+	mobiles := make([]string, 0, len(m.atMobiles))
+
+	for _, v := range m.atMobiles {
+		if v == "@all" {
+			at["isAtAll"] = true
+
+			continue
+		}
+
+		mobiles = append(mobiles, v)
+	}
+
+	if len(mobiles) > 0 {
+		at["atMobiles"] = mobiles
+	}
+
+	return X{
+		"msgtype": "text",
+		"text":    X{"content": m.content},
+		"at":      at,
+	}
+}
+
+// weComBody returns the request body of WeCom robot.
+func (m *NotifyMessage) weComBody() X {
+	switch m.msgType {
+	case "markdown":
+		return X{
+			"msgtype":  "markdown",
+			"markdown": X{"content": m.content},
+		}
+	case "card":
+		return X{
+			"msgtype": "news",
+			"news": X{
+				"articles": []X{
+					{"title": m.title, "description": m.content, "url": m.btnURL},
+				},
+			},
+		}
+	}
+
+	text := X{"content": m.content}
+
+	if len(m.atMobiles) > 0 {
+		text["mentioned_mobile_list"] = m.atMobiles
+	}
+
+	return X{
+		"msgtype": "text",
+		"text":    text,
+	}
+}
+
+// notifyOptions notifier options
+type notifyOptions struct {
+	secret        string
+	attempts      int
+	retryInterval time.Duration
+	queueSize     int
+	client        *HTTPClient
+	onError       func(msg *NotifyMessage, err error)
+}
+
+// NotifyOption configures how we set up the notifier
+type NotifyOption interface {
+	apply(options *notifyOptions)
+}
+
+// funcNotifyOption implements notify option
+type funcNotifyOption struct {
+	f func(options *notifyOptions)
+}
+
+func (fo *funcNotifyOption) apply(o *notifyOptions) {
+	fo.f(o)
+}
+
+func newFuncNotifyOption(f func(options *notifyOptions)) *funcNotifyOption {
+	return &funcNotifyOption{f: f}
+}
+
+// WithNotifySecret specifies the sign secret of DingTalk robot.
+func WithNotifySecret(s string) NotifyOption {
+	return newFuncNotifyOption(func(o *notifyOptions) {
+		o.secret = s
+	})
+}
+
+// WithNotifyRetry specifies the maximum attempts of sending and the interval between them.
+func WithNotifyRetry(attempts int, interval time.Duration) NotifyOption {
+	return newFuncNotifyOption(func(o *notifyOptions) {
+		o.attempts = attempts
+		o.retryInterval = interval
+	})
+}
+
+// WithNotifyQueueSize specifies the size of async queue.
+func WithNotifyQueueSize(n int) NotifyOption {
+	return newFuncNotifyOption(func(o *notifyOptions) {
+		o.queueSize = n
+	})
+}
+
+// WithNotifyHTTPClient specifies the http client to send the messages.
+func WithNotifyHTTPClient(c *HTTPClient) NotifyOption {
+	return newFuncNotifyOption(func(o *notifyOptions) {
+		o.client = c
+	})
+}
+
+// WithNotifyErrorHandler specifies the handler of the messages failed to send asynchronously.
+func WithNotifyErrorHandler(f func(msg *NotifyMessage, err error)) NotifyOption {
+	return newFuncNotifyOption(func(o *notifyOptions) {
+		o.onError = f
+	})
+}
+
+// Notifier sends the messages to a DingTalk or WeCom group robot.
+type Notifier struct {
+	channel NotifyChannel
+	webhook string
+	options *notifyOptions
+	queue   chan *NotifyMessage
+	closed  bool
+	mutex   sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+// NewNotifier returns a new notifier of the robot webhook.
+//
+// The default `Retry` is 3 attempts with 1s interval.
+// The default `QueueSize` is 100.
+func NewNotifier(channel NotifyChannel, webhook string, options ...NotifyOption) *Notifier {
+	o := &notifyOptions{
+		attempts:      3,
+		retryInterval: time.Second,
+		queueSize:     100,
+		client:        defaultHTTPClient,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	if o.attempts <= 0 {
+		o.attempts = 1
+	}
+
+	n := &Notifier{
+		channel: channel,
+		webhook: webhook,
+		options: o,
+		queue:   make(chan *NotifyMessage, o.queueSize),
+	}
+
+	n.wg.Add(1)
+
+	SafeGo(n.run)
+
+	return n
+}
+
+// Send sends the message synchronously, it retries on failure.
+func (n *Notifier) Send(msg *NotifyMessage) error {
+	var err error
+
+	for i := 0; i < n.options.attempts; i++ {
+		if i > 0 {
+			time.Sleep(n.options.retryInterval)
+		}
+
+		if err = n.send(msg); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// SendAsync queues the message to send in background, it returns ErrNotifyQueueFull instead of blocking when the queue is full.
+// The failures are reported to the handler of `WithNotifyErrorHandler`.
+func (n *Notifier) SendAsync(msg *NotifyMessage) error {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	if n.closed {
+		return ErrNotifierClosed
+	}
+
+	select {
+	case n.queue <- msg:
+		return nil
+	default:
+		return ErrNotifyQueueFull
+	}
+}
+
+// Close stops the notifier after the queued messages are sent.
+func (n *Notifier) Close() {
+	n.mutex.Lock()
+
+	if !n.closed {
+		n.closed = true
+
+		close(n.queue)
+	}
+
+	n.mutex.Unlock()
+
+	n.wg.Wait()
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+
+	for msg := range n.queue {
+		if err := n.Send(msg); err != nil && n.options.onError != nil {
+			n.options.onError(msg, err)
+		}
+	}
+}
+
+func (n *Notifier) send(msg *NotifyMessage) error {
+	webhook := n.webhook
+
+	var data X
+
+	switch n.channel {
+	case NotifyDingTalk:
+		data = msg.dingTalkBody()
+
+		if n.options.secret != "" {
+			webhook = dingTalkSignURL(webhook, n.options.secret, time.Now())
+		}
+	case NotifyWeCom:
+		data = msg.weComBody()
+	default:
+		return fmt.Errorf("yiigo: invalid notify channel %d", n.channel)
+	}
+
+	body, err := jsonCodec.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	b, err := n.options.client.Post(webhook, body, WithRequestHeader("Content-Type", "application/json; charset=utf-8"))
+
+	if err != nil {
+		return err
+	}
+
+	resp := new(struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	})
+
+	if err := jsonCodec.Unmarshal(b, resp); err != nil {
+		return err
+	}
+
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("yiigo: notify error(%d): %s", resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// dingTalkSign returns the sign of timestamp (in milliseconds) with secret.
+func dingTalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// dingTalkSignURL appends the `timestamp` and `sign` of secret to the webhook of DingTalk robot.
+func dingTalkSignURL(webhook, secret string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+
+	return fmt.Sprintf("%s&timestamp=%s&sign=%s", webhook, timestamp, url.QueryEscape(dingTalkSign(timestamp, secret)))
+}
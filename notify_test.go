@@ -0,0 +1,147 @@
+package yiigo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifyMessage_dingTalkBody(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *NotifyMessage
+		want X
+	}{
+		{
+			name: "t1",
+			msg:  NotifyText("hello", "13800000000", "@all"),
+			want: X{
+				"msgtype": "text",
+				"text":    X{"content": "hello"},
+				"at":      X{"isAtAll": true, "atMobiles": []string{"13800000000"}},
+			},
+		},
+		{
+			name: "t2",
+			msg:  NotifyMarkdown("title", "# hello"),
+			want: X{
+				"msgtype":  "markdown",
+				"markdown": X{"title": "title", "text": "# hello"},
+			},
+		},
+		{
+			name: "t3",
+			msg:  NotifyCard("title", "hello", "view", "https://example.com"),
+			want: X{
+				"msgtype": "actionCard",
+				"actionCard": X{
+					"title":       "title",
+					"text":        "hello",
+					"singleTitle": "view",
+					"singleURL":   "https://example.com",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.dingTalkBody(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NotifyMessage.dingTalkBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyMessage_weComBody(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *NotifyMessage
+		want X
+	}{
+		{
+			name: "t1",
+			msg:  NotifyText("hello", "@all"),
+			want: X{
+				"msgtype": "text",
+				"text":    X{"content": "hello", "mentioned_mobile_list": []string{"@all"}},
+			},
+		},
+		{
+			name: "t2",
+			msg:  NotifyMarkdown("title", "# hello"),
+			want: X{
+				"msgtype":  "markdown",
+				"markdown": X{"content": "# hello"},
+			},
+		},
+		{
+			name: "t3",
+			msg:  NotifyCard("title", "hello", "view", "https://example.com"),
+			want: X{
+				"msgtype": "news",
+				"news": X{
+					"articles": []X{
+						{"title": "title", "description": "hello", "url": "https://example.com"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.weComBody(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NotifyMessage.weComBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifier_SendAsync(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fail the first attempt to retry
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+
+		data := make(X)
+
+		json.Unmarshal(b, &data)
+
+		if data["msgtype"] != "markdown" {
+			t.Errorf("Notifier.SendAsync() msgtype = %v, want markdown", data["msgtype"])
+		}
+
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+
+	defer ts.Close()
+
+	n := NewNotifier(NotifyWeCom, ts.URL, WithNotifyRetry(3, 10*time.Millisecond), WithNotifyErrorHandler(func(msg *NotifyMessage, err error) {
+		t.Errorf("Notifier.SendAsync() error = %v", err)
+	}))
+
+	if err := n.SendAsync(NotifyMarkdown("", "# hello")); err != nil {
+		t.Errorf("Notifier.SendAsync() error = %v", err)
+	}
+
+	n.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Notifier.SendAsync() calls = %d, want 2", got)
+	}
+
+	if err := n.SendAsync(NotifyText("hello")); err != ErrNotifierClosed {
+		t.Errorf("Notifier.SendAsync() error = %v, want %v", err, ErrNotifierClosed)
+	}
+}
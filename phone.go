@@ -0,0 +1,188 @@
+package yiigo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPhone returned when a phone number is not a valid Chinese mobile number.
+var ErrInvalidPhone = errors.New("yiigo: invalid mobile number")
+
+// Carriers of Chinese mobile numbers
+const (
+	CarrierChinaMobile   = "China Mobile"
+	CarrierChinaUnicom   = "China Unicom"
+	CarrierChinaTelecom  = "China Telecom"
+	CarrierChinaBroadnet = "China Broadnet"
+)
+
+// PhoneInfo the parsed result of a Chinese mobile number.
+type PhoneInfo struct {
+	Number  string // the 11 digits number, eg: 13800138000
+	E164    string // eg: +8613800138000
+	Carrier string // empty if the prefix is unknown
+	Virtual bool   // whether it's a number of MVNO (eg: 170, 171)
+}
+
+var phoneRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// phoneVirtualPrefixes the 4 digits prefixes of MVNO, which take precedence over the 3 digits ones.
+var phoneVirtualPrefixes = map[string]string{
+	"1700": CarrierChinaTelecom,
+	"1701": CarrierChinaTelecom,
+	"1702": CarrierChinaTelecom,
+	"1703": CarrierChinaMobile,
+	"1704": CarrierChinaUnicom,
+	"1705": CarrierChinaMobile,
+	"1706": CarrierChinaMobile,
+	"1707": CarrierChinaUnicom,
+	"1708": CarrierChinaUnicom,
+	"1709": CarrierChinaUnicom,
+}
+
+var phoneVirtualSegments = map[string]string{
+	"162": CarrierChinaTelecom,
+	"165": CarrierChinaMobile,
+	"167": CarrierChinaUnicom,
+	"171": CarrierChinaUnicom,
+}
+
+var phoneSegments = map[string]string{
+	"130": CarrierChinaUnicom,
+	"131": CarrierChinaUnicom,
+	"132": CarrierChinaUnicom,
+	"133": CarrierChinaTelecom,
+	"134": CarrierChinaMobile, // 1349 is China Telecom
+	"135": CarrierChinaMobile,
+	"136": CarrierChinaMobile,
+	"137": CarrierChinaMobile,
+	"138": CarrierChinaMobile,
+	"139": CarrierChinaMobile,
+	"145": CarrierChinaUnicom,
+	"146": CarrierChinaUnicom,
+	"147": CarrierChinaMobile,
+	"148": CarrierChinaMobile,
+	"149": CarrierChinaTelecom,
+	"150": CarrierChinaMobile,
+	"151": CarrierChinaMobile,
+	"152": CarrierChinaMobile,
+	"153": CarrierChinaTelecom,
+	"155": CarrierChinaUnicom,
+	"156": CarrierChinaUnicom,
+	"157": CarrierChinaMobile,
+	"158": CarrierChinaMobile,
+	"159": CarrierChinaMobile,
+	"166": CarrierChinaUnicom,
+	"172": CarrierChinaMobile,
+	"173": CarrierChinaTelecom,
+	"174": CarrierChinaTelecom,
+	"175": CarrierChinaUnicom,
+	"176": CarrierChinaUnicom,
+	"177": CarrierChinaTelecom,
+	"178": CarrierChinaMobile,
+	"180": CarrierChinaTelecom,
+	"181": CarrierChinaTelecom,
+	"182": CarrierChinaMobile,
+	"183": CarrierChinaMobile,
+	"184": CarrierChinaMobile,
+	"185": CarrierChinaUnicom,
+	"186": CarrierChinaUnicom,
+	"187": CarrierChinaMobile,
+	"188": CarrierChinaMobile,
+	"189": CarrierChinaTelecom,
+	"190": CarrierChinaTelecom,
+	"191": CarrierChinaTelecom,
+	"192": CarrierChinaBroadnet,
+	"193": CarrierChinaTelecom,
+	"195": CarrierChinaMobile,
+	"196": CarrierChinaUnicom,
+	"197": CarrierChinaMobile,
+	"198": CarrierChinaMobile,
+	"199": CarrierChinaTelecom,
+}
+
+// NormalizePhone normalizes a Chinese mobile number to 11 digits,
+// the spaces, dashes and the country code (+86, 0086, 86) are stripped, eg: "+86 138-0013-8000" → "13800138000".
+func NormalizePhone(s string) (string, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '\u00a0', '\u3000':
+			return -1
+		}
+
+		return r
+	}, s)
+
+	switch {
+	case strings.HasPrefix(s, "+86"):
+		s = s[3:]
+	case strings.HasPrefix(s, "0086"):
+		s = s[4:]
+	case len(s) == 13 && strings.HasPrefix(s, "86"):
+		s = s[2:]
+	}
+
+	if !phoneRegexp.MatchString(s) {
+		return "", ErrInvalidPhone
+	}
+
+	return s, nil
+}
+
+// IsMobilePhone reports whether s is a valid Chinese mobile number (in any format NormalizePhone accepts).
+func IsMobilePhone(s string) bool {
+	_, err := NormalizePhone(s)
+
+	return err == nil
+}
+
+// ParsePhone normalizes a Chinese mobile number and detects its carrier by the prefix.
+// Note: the carrier is of the original segment, a number ported to another carrier can't be detected offline.
+func ParsePhone(s string) (*PhoneInfo, error) {
+	number, err := NormalizePhone(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PhoneInfo{
+		Number: number,
+		E164:   "+86" + number,
+	}
+
+	if carrier, ok := phoneVirtualPrefixes[number[:4]]; ok {
+		info.Carrier = carrier
+		info.Virtual = true
+
+		return info, nil
+	}
+
+	if carrier, ok := phoneVirtualSegments[number[:3]]; ok {
+		info.Carrier = carrier
+		info.Virtual = true
+
+		return info, nil
+	}
+
+	if number[:4] == "1349" {
+		info.Carrier = CarrierChinaTelecom
+
+		return info, nil
+	}
+
+	info.Carrier = phoneSegments[number[:3]]
+
+	return info, nil
+}
+
+// MaskPhone masks the middle 4 digits of a Chinese mobile number, eg: "13800138000" → "138****8000".
+func MaskPhone(s string) string {
+	number, err := NormalizePhone(s)
+
+	if err != nil {
+		return s
+	}
+
+	return number[:3] + "****" + number[7:]
+}
@@ -0,0 +1,111 @@
+package yiigo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "t1",
+			args:    args{s: "+86 138-0013-8000"},
+			want:    "13800138000",
+			wantErr: false,
+		},
+		{
+			name:    "t2",
+			args:    args{s: "008613800138000"},
+			want:    "13800138000",
+			wantErr: false,
+		},
+		{
+			name:    "t3",
+			args:    args{s: "8613800138000"},
+			want:    "13800138000",
+			wantErr: false,
+		},
+		{
+			name:    "t4",
+			args:    args{s: "12800138000"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "t5",
+			args:    args{s: "1380013800"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.args.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizePhone() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePhone(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *PhoneInfo
+	}{
+		{
+			name: "t1",
+			args: args{s: "13800138000"},
+			want: &PhoneInfo{Number: "13800138000", E164: "+8613800138000", Carrier: CarrierChinaMobile},
+		},
+		{
+			name: "t2",
+			args: args{s: "13490000000"},
+			want: &PhoneInfo{Number: "13490000000", E164: "+8613490000000", Carrier: CarrierChinaTelecom},
+		},
+		{
+			name: "t3",
+			args: args{s: "17040000000"},
+			want: &PhoneInfo{Number: "17040000000", E164: "+8617040000000", Carrier: CarrierChinaUnicom, Virtual: true},
+		},
+		{
+			name: "t4",
+			args: args{s: "19200000000"},
+			want: &PhoneInfo{Number: "19200000000", E164: "+8619200000000", Carrier: CarrierChinaBroadnet},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePhone(tt.args.s)
+			if err != nil {
+				t.Errorf("ParsePhone() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePhone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskPhone(t *testing.T) {
+	if got := MaskPhone("+8613800138000"); got != "138****8000" {
+		t.Errorf("MaskPhone() = %v, want 138****8000", got)
+	}
+}
@@ -0,0 +1,257 @@
+package yiigo
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// PipelineSource produces the items to out until it's drained or ctx is done, out is closed after it returns.
+type PipelineSource func(ctx context.Context, out chan<- interface{}) error
+
+// PipelineStageFunc transforms an item, a nil result drops the item.
+type PipelineStageFunc func(ctx context.Context, item interface{}) (interface{}, error)
+
+// PipelineSink consumes the items which pass all the stages.
+type PipelineSink func(ctx context.Context, item interface{}) error
+
+// PipelineBatchSink consumes the items in batches, eg: a bulk insert of Elasticsearch.
+type PipelineBatchSink func(ctx context.Context, items []interface{}) error
+
+type pipelineStage struct {
+	f           PipelineStageFunc
+	parallelism int
+}
+
+// pipelineOptions pipeline options
+type pipelineOptions struct {
+	buffer  int
+	onError func(item interface{}, err error) error
+}
+
+// PipelineOption configures how we set up the pipeline
+type PipelineOption interface {
+	apply(options *pipelineOptions)
+}
+
+// funcPipelineOption implements pipeline option
+type funcPipelineOption struct {
+	f func(options *pipelineOptions)
+}
+
+func (fo *funcPipelineOption) apply(o *pipelineOptions) {
+	fo.f(o)
+}
+
+func newFuncPipelineOption(f func(options *pipelineOptions)) *funcPipelineOption {
+	return &funcPipelineOption{f: f}
+}
+
+// WithPipelineBuffer specifies the buffer size of the channels between stages.
+func WithPipelineBuffer(n int) PipelineOption {
+	return newFuncPipelineOption(func(o *pipelineOptions) {
+		o.buffer = n
+	})
+}
+
+// WithPipelineErrorHandler specifies the handler of the item errors of stages and sink.
+// Returning nil skips the failed item and goes on, returning an error stops the pipeline.
+// Without the handler, the first error stops the pipeline.
+func WithPipelineErrorHandler(f func(item interface{}, err error) error) PipelineOption {
+	return newFuncPipelineOption(func(o *pipelineOptions) {
+		o.onError = f
+	})
+}
+
+// Pipeline a batch job of source → stages → sink, connected by bounded channels.
+type Pipeline struct {
+	source  PipelineSource
+	stages  []*pipelineStage
+	options *pipelineOptions
+}
+
+// NewPipeline returns a new pipeline of source.
+//
+// The default `Buffer` is 100.
+func NewPipeline(source PipelineSource, options ...PipelineOption) *Pipeline {
+	o := &pipelineOptions{buffer: 100}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &Pipeline{
+		source:  source,
+		options: o,
+	}
+}
+
+// Stage appends a stage which runs f in parallelism goroutines, the order of items is not kept when parallelism > 1.
+func (p *Pipeline) Stage(f PipelineStageFunc, parallelism int) *Pipeline {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	p.stages = append(p.stages, &pipelineStage{
+		f:           f,
+		parallelism: parallelism,
+	})
+
+	return p
+}
+
+// Run runs the pipeline into sink, it blocks until all the items are consumed or the pipeline is stopped by an error.
+func (p *Pipeline) Run(ctx context.Context, sink PipelineSink) error {
+	eg, ctx := errgroup.WithContext(ctx)
+
+	in := p.start(ctx, eg)
+
+	eg.Go(func() error {
+		for item := range in {
+			if err := callSafe(func() error { return sink(ctx, item) }); err != nil {
+				if err = p.handleError(item, err); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return eg.Wait()
+}
+
+// RunBatch runs the pipeline into sink in batches of size, the last batch may be smaller.
+// An error of the batch is handled with nil item.
+func (p *Pipeline) RunBatch(ctx context.Context, size int, sink PipelineBatchSink) error {
+	if size < 1 {
+		size = 1
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	in := p.start(ctx, eg)
+
+	eg.Go(func() error {
+		batch := make([]interface{}, 0, size)
+
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+
+			err := callSafe(func() error { return sink(ctx, batch) })
+
+			batch = make([]interface{}, 0, size)
+
+			if err != nil {
+				return p.handleError(nil, err)
+			}
+
+			return nil
+		}
+
+		for item := range in {
+			batch = append(batch, item)
+
+			if len(batch) < size {
+				continue
+			}
+
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		// the pipeline is stopped, do not flush the partial batch
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return flush()
+	})
+
+	return eg.Wait()
+}
+
+// start starts the source and stages, and returns the output channel of the last stage.
+func (p *Pipeline) start(ctx context.Context, eg *errgroup.Group) <-chan interface{} {
+	out := make(chan interface{}, p.options.buffer)
+
+	eg.Go(func() error {
+		defer close(out)
+
+		return callSafe(func() error { return p.source(ctx, out) })
+	})
+
+	in := out
+
+	for _, stage := range p.stages {
+		in = p.startStage(ctx, eg, stage, in)
+	}
+
+	return in
+}
+
+func (p *Pipeline) startStage(ctx context.Context, eg *errgroup.Group, stage *pipelineStage, in <-chan interface{}) chan interface{} {
+	out := make(chan interface{}, p.options.buffer)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < stage.parallelism; i++ {
+		wg.Add(1)
+
+		eg.Go(func() error {
+			defer wg.Done()
+
+			for item := range in {
+				var v interface{}
+
+				err := callSafe(func() (err error) {
+					v, err = stage.f(ctx, item)
+
+					return
+				})
+
+				if err != nil {
+					if err = p.handleError(item, err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				if v == nil {
+					continue
+				}
+
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			return nil
+		})
+	}
+
+	SafeGo(func() {
+		wg.Wait()
+
+		close(out)
+	})
+
+	return out
+}
+
+func (p *Pipeline) handleError(item interface{}, err error) error {
+	if p.options.onError == nil {
+		return err
+	}
+
+	return p.options.onError(item, err)
+}
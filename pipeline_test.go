@@ -0,0 +1,132 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func pipelineTestSource(n int) PipelineSource {
+	return func(ctx context.Context, out chan<- interface{}) error {
+		for i := 1; i <= n; i++ {
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestPipeline_Run(t *testing.T) {
+	got := make([]int, 0)
+
+	err := NewPipeline(pipelineTestSource(10), WithPipelineBuffer(2)).
+		Stage(func(ctx context.Context, item interface{}) (interface{}, error) {
+			// drop the odds
+			if item.(int)%2 == 1 {
+				return nil, nil
+			}
+
+			return item, nil
+		}, 1).
+		Stage(func(ctx context.Context, item interface{}) (interface{}, error) {
+			return item.(int) * 10, nil
+		}, 3).
+		Run(context.Background(), func(ctx context.Context, item interface{}) error {
+			got = append(got, item.(int))
+
+			return nil
+		})
+
+	if err != nil {
+		t.Errorf("Pipeline.Run() error = %v", err)
+
+		return
+	}
+
+	sort.Ints(got)
+
+	if want := []int{20, 40, 60, 80, 100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pipeline.Run() = %v, want %v", got, want)
+	}
+}
+
+func TestPipeline_RunError(t *testing.T) {
+	errBad := errors.New("bad item")
+
+	stage := func(ctx context.Context, item interface{}) (interface{}, error) {
+		if item.(int) == 3 {
+			return nil, errBad
+		}
+
+		return item, nil
+	}
+
+	sink := func(ctx context.Context, item interface{}) error {
+		return nil
+	}
+
+	if err := NewPipeline(pipelineTestSource(1000)).Stage(stage, 2).Run(context.Background(), sink); err != errBad {
+		t.Errorf("Pipeline.Run() error = %v, want %v", err, errBad)
+	}
+
+	skipped := make([]interface{}, 0)
+
+	err := NewPipeline(pipelineTestSource(5), WithPipelineErrorHandler(func(item interface{}, err error) error {
+		skipped = append(skipped, item)
+
+		return nil
+	})).Stage(stage, 1).Run(context.Background(), sink)
+
+	if err != nil {
+		t.Errorf("Pipeline.Run() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(skipped, []interface{}{3}) {
+		t.Errorf("Pipeline.Run() skipped = %v, want [3]", skipped)
+	}
+}
+
+func TestPipeline_RunSinkPanic(t *testing.T) {
+	err := NewPipeline(pipelineTestSource(5)).Run(context.Background(), func(ctx context.Context, item interface{}) error {
+		panic("bad sink")
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "bad sink") {
+		t.Errorf("Pipeline.Run() error = %v, want the panic", err)
+	}
+
+	err = NewPipeline(pipelineTestSource(5)).RunBatch(context.Background(), 2, func(ctx context.Context, items []interface{}) error {
+		panic("bad batch sink")
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "bad batch sink") {
+		t.Errorf("Pipeline.RunBatch() error = %v, want the panic", err)
+	}
+}
+
+func TestPipeline_RunBatch(t *testing.T) {
+	sizes := make([]int, 0)
+
+	err := NewPipeline(pipelineTestSource(7)).RunBatch(context.Background(), 3, func(ctx context.Context, items []interface{}) error {
+		sizes = append(sizes, len(items))
+
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Pipeline.RunBatch() error = %v", err)
+
+		return
+	}
+
+	if want := []int{3, 3, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("Pipeline.RunBatch() batch sizes = %v, want %v", sizes, want)
+	}
+}
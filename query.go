@@ -0,0 +1,638 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultPerPage the default rows per page of Paginate.
+const defaultPerPage = 20
+
+// identifierRegexp matches a plain identifier, which is quoted by the query builder.
+var identifierRegexp = regexp.MustCompile(`^\w+$`)
+
+type queryCondition struct {
+	or    bool
+	query string
+	binds []interface{}
+}
+
+// QueryBuilder a chainable sql query builder, the conditions are written with `?` placeholders,
+// which are rebound to `$n` for postgres. A slice bound to `IN (?)` is expanded.
+// Only the conditions with binds are rebound, and the `?` in quoted literals is kept,
+// so a condition without binds may use the `?` operators of postgres jsonb, eg: Where("tags ? 'vip'").
+//
+//	query := yiigo.Query("user").
+//		Select("id", "name").
+//		Where("status = ?", 1).
+//		When(keyword != "", func(q *yiigo.QueryBuilder) {
+//			q.Where("name LIKE ?", "%"+keyword+"%")
+//		}).
+//		WhereIn("role", []string{"admin", "staff"}).
+//		OrderBy("id DESC").
+//		Limit(10)
+//
+//	err := query.Find(ctx, yiigo.DB, &users)
+type QueryBuilder struct {
+	driver  Driver
+	table   string
+	columns []string
+	joins   []string
+	wheres  []queryCondition
+	groupBy []string
+	having  string
+	orderBy []string
+	limit   int
+	offset  int
+	lock    string
+	hbinds  []interface{}
+}
+
+// Query returns a new mysql query builder of table.
+func Query(table string) *QueryBuilder {
+	return &QueryBuilder{
+		driver: MySQL,
+		table:  table,
+	}
+}
+
+// PGQuery returns a new postgres query builder of table.
+func PGQuery(table string) *QueryBuilder {
+	return &QueryBuilder{
+		driver: Postgres,
+		table:  table,
+	}
+}
+
+// Select specifies the columns, eg: Select("id", "name") or Select("id, name"), defaults to `*`.
+func (q *QueryBuilder) Select(columns ...string) *QueryBuilder {
+	q.columns = append(q.columns, columns...)
+
+	return q
+}
+
+// Join adds a join clause, eg: Join("LEFT JOIN `order` AS o ON o.user_id = user.id").
+func (q *QueryBuilder) Join(clause string) *QueryBuilder {
+	q.joins = append(q.joins, clause)
+
+	return q
+}
+
+// Where adds a condition joined by AND.
+func (q *QueryBuilder) Where(query string, binds ...interface{}) *QueryBuilder {
+	q.wheres = append(q.wheres, queryCondition{query: query, binds: binds})
+
+	return q
+}
+
+// OrWhere adds a condition joined by OR, the conditions are combined from left to right,
+// eg: Where(a).OrWhere(b).Where(c) is `((a) OR (b)) AND (c)`.
+func (q *QueryBuilder) OrWhere(query string, binds ...interface{}) *QueryBuilder {
+	q.wheres = append(q.wheres, queryCondition{or: true, query: query, binds: binds})
+
+	return q
+}
+
+// WhereIn adds the condition `column IN (values)`, an empty slice of values matches nothing.
+func (q *QueryBuilder) WhereIn(column string, values interface{}) *QueryBuilder {
+	if v := reflect.ValueOf(values); v.Kind() == reflect.Slice && v.Len() == 0 {
+		return q.Where("1 = 0")
+	}
+
+	return q.Where(column+" IN (?)", values)
+}
+
+// When calls f with the builder if cond is true, for the conditions of optional filters.
+func (q *QueryBuilder) When(cond bool, f func(q *QueryBuilder)) *QueryBuilder {
+	if cond {
+		f(q)
+	}
+
+	return q
+}
+
+// GroupBy specifies the group by columns.
+func (q *QueryBuilder) GroupBy(columns ...string) *QueryBuilder {
+	q.groupBy = append(q.groupBy, columns...)
+
+	return q
+}
+
+// Having specifies the having condition.
+func (q *QueryBuilder) Having(query string, binds ...interface{}) *QueryBuilder {
+	q.having = query
+	q.hbinds = binds
+
+	return q
+}
+
+// OrderBy specifies the orders, eg: OrderBy("id DESC").
+func (q *QueryBuilder) OrderBy(orders ...string) *QueryBuilder {
+	q.orderBy = append(q.orderBy, orders...)
+
+	return q
+}
+
+// Limit specifies the limit, 0 means no limit.
+func (q *QueryBuilder) Limit(n int) *QueryBuilder {
+	q.limit = n
+
+	return q
+}
+
+// Offset specifies the offset, the max limit is used for mysql if no limit.
+func (q *QueryBuilder) Offset(n int) *QueryBuilder {
+	q.offset = n
+
+	return q
+}
+
+// ForUpdate appends `FOR UPDATE` to lock the rows selected for writes, which should be used in a transaction, eg: deducting the inventory.
+func (q *QueryBuilder) ForUpdate() *QueryBuilder {
+	q.lock = "FOR UPDATE"
+
+	return q
+}
+
+// ForShare appends `LOCK IN SHARE MODE` (`FOR SHARE` for postgres) to lock the rows selected against writes, which should be used in a transaction.
+func (q *QueryBuilder) ForShare() *QueryBuilder {
+	q.lock = "LOCK IN SHARE MODE"
+
+	if q.driver == Postgres {
+		q.lock = "FOR SHARE"
+	}
+
+	return q
+}
+
+// ToSQL returns the select sql and binds.
+func (q *QueryBuilder) ToSQL() (string, []interface{}, error) {
+	args := q.newArgs()
+
+	sql, err := q.buildSelect(args)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sql, args.binds, nil
+}
+
+// buildSelect returns the select sql, the binds are collected into args.
+func (q *QueryBuilder) buildSelect(args *queryArgs) (string, error) {
+	columns := "*"
+
+	if len(q.columns) > 0 {
+		columns = strings.Join(q.columns, ", ")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SELECT %s FROM %s", columns, q.quotedTable())
+
+	if err := q.buildFrom(&b, args); err != nil {
+		return "", err
+	}
+
+	if len(q.groupBy) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(q.groupBy, ", "))
+	}
+
+	if q.having != "" {
+		having, err := args.bindCondition(q.having, q.hbinds)
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(" HAVING " + having)
+	}
+
+	if len(q.orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(q.orderBy, ", "))
+	}
+
+	if q.limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", q.limit)
+	} else if q.offset > 0 && q.driver != Postgres {
+		// mysql requires a limit with offset
+		b.WriteString(" LIMIT 18446744073709551615")
+	}
+
+	if q.offset > 0 {
+		fmt.Fprintf(&b, " OFFSET %d", q.offset)
+	}
+
+	if q.lock != "" {
+		b.WriteString(" " + q.lock)
+	}
+
+	return b.String(), nil
+}
+
+// CountSQL returns the sql and binds to count the rows matched, the orders, limit and lock are ignored.
+// A query with `GROUP BY` or `SELECT DISTINCT` is counted as a subquery, so the count matches the rows returned.
+func (q *QueryBuilder) CountSQL() (string, []interface{}, error) {
+	args := q.newArgs()
+
+	if len(q.groupBy) > 0 || q.distinct() {
+		sql, err := q.Clone().noPaging().buildSelect(args)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		return fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS t", sql), args.binds, nil
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SELECT COUNT(*) FROM %s", q.quotedTable())
+
+	if err := q.buildFrom(&b, args); err != nil {
+		return "", nil, err
+	}
+
+	return b.String(), args.binds, nil
+}
+
+// distinct reports whether the query selects the distinct rows, eg: Select("DISTINCT user_id").
+func (q *QueryBuilder) distinct() bool {
+	if len(q.columns) == 0 {
+		return false
+	}
+
+	fields := strings.Fields(q.columns[0])
+
+	return len(fields) > 1 && strings.EqualFold(fields[0], "DISTINCT")
+}
+
+// Find executes the query and scans the rows into dest (a pointer to slice).
+func (q *QueryBuilder) Find(ctx context.Context, db sqlx.QueryerContext, dest interface{}) error {
+	sql, binds, err := q.ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(sqlx.SelectContext(ctx, db, dest, sql, binds...))
+}
+
+// FindOne executes the query with `LIMIT 1` and scans the row into dest, it returns sql.ErrNoRows (coded 404, see IsDBNotFound) if no row.
+func (q *QueryBuilder) FindOne(ctx context.Context, db sqlx.QueryerContext, dest interface{}) error {
+	sql, binds, err := q.Clone().Limit(1).ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(sqlx.GetContext(ctx, db, dest, sql, binds...))
+}
+
+// Count returns the count of rows matched.
+func (q *QueryBuilder) Count(ctx context.Context, db sqlx.QueryerContext) (int64, error) {
+	sql, binds, err := q.CountSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+
+	err = sqlx.GetContext(ctx, db, &n, sql, binds...)
+
+	return n, dbError(err)
+}
+
+// Pagination the page metadata and rows of a paginated query.
+type Pagination struct {
+	Total      int64       `json:"total"`
+	TotalPages int         `json:"total_pages"`
+	Page       int         `json:"page"`
+	PerPage    int         `json:"per_page"`
+	Data       interface{} `json:"data"`
+}
+
+// newPagination returns the pagination of total rows, page (starts from 1) is normalized to >= 1.
+func newPagination(total int64, page, perPage int) *Pagination {
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+
+	return &Pagination{
+		Total:      total,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+		Page:       page,
+		PerPage:    perPage,
+	}
+}
+
+// Paginate counts the rows matched and scans the rows of page (starts from 1) into dest (a pointer to slice),
+// the limit and offset of builder are overridden. The rows are not queried if the page is out of range (eg: total is 0),
+// and `Data` of the pagination is dest as it is, so pass an empty slice (eg: `users := make([]*User, 0)`) to render `[]`.
+//
+// The default `perPage` is 20 if perPage <= 0.
+func (q *QueryBuilder) Paginate(ctx context.Context, db sqlx.QueryerContext, page, perPage int, dest interface{}) (*Pagination, error) {
+	total, err := q.Count(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPagination(total, page, perPage)
+	p.Data = dest
+
+	if p.Page > p.TotalPages {
+		return p, nil
+	}
+
+	if err := q.Clone().Limit(p.PerPage).Offset((p.Page-1)*p.PerPage).Find(ctx, db, dest); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Clone returns a copy of the builder, eg: to count and find with the same conditions.
+func (q *QueryBuilder) Clone() *QueryBuilder {
+	c := *q
+
+	c.columns = append([]string(nil), q.columns...)
+	c.joins = append([]string(nil), q.joins...)
+	c.wheres = append([]queryCondition(nil), q.wheres...)
+	c.groupBy = append([]string(nil), q.groupBy...)
+	c.orderBy = append([]string(nil), q.orderBy...)
+	c.hbinds = append([]interface{}(nil), q.hbinds...)
+
+	return &c
+}
+
+func (q *QueryBuilder) noPaging() *QueryBuilder {
+	q.orderBy = nil
+	q.limit = 0
+	q.offset = 0
+	q.lock = ""
+
+	return q
+}
+
+func (q *QueryBuilder) quotedTable() string {
+	if identifierRegexp.MatchString(q.table) {
+		return q.driver.dialect().quote(q.table)
+	}
+
+	return q.table
+}
+
+func (q *QueryBuilder) buildFrom(b *strings.Builder, args *queryArgs) error {
+	for _, v := range q.joins {
+		b.WriteString(" " + v)
+	}
+
+	if len(q.wheres) == 0 {
+		return nil
+	}
+
+	where, err := q.buildWhere(args)
+
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(" WHERE " + where)
+
+	return nil
+}
+
+// buildWhere combines the conditions from left to right, the OR chain before an AND is grouped,
+// eg: Where(a).OrWhere(b).Where(c) is `((a) OR (b)) AND (c)`, so a condition added later (eg: a tenant filter) applies to all the rows.
+func (q *QueryBuilder) buildWhere(args *queryArgs) (string, error) {
+	var (
+		expr  string
+		hasOr bool
+	)
+
+	for i, v := range q.wheres {
+		query, err := args.bindCondition(v.query, v.binds)
+
+		if err != nil {
+			return "", err
+		}
+
+		cond := "(" + query + ")"
+
+		switch {
+		case i == 0:
+			expr = cond
+		case v.or:
+			expr += " OR " + cond
+			hasOr = true
+		default:
+			if hasOr {
+				expr = "(" + expr + ")"
+				hasOr = false
+			}
+
+			expr += " AND " + cond
+		}
+	}
+
+	return expr, nil
+}
+
+func (q *QueryBuilder) newArgs() *queryArgs {
+	return &queryArgs{
+		driver: q.driver,
+		binds:  make([]interface{}, 0),
+	}
+}
+
+// queryArgs collects the binds of a query, the placeholders are numbered across the sql fragments.
+type queryArgs struct {
+	driver Driver
+	binds  []interface{}
+}
+
+// bind expands the slices bound to `IN (?)` and rebinds the placeholders of fragment for postgres,
+// the `?` in quoted literals is skipped.
+func (a *queryArgs) bind(fragment string, binds []interface{}) (string, error) {
+	var b strings.Builder
+
+	n := 0
+
+	var quote byte
+
+	for i := 0; i < len(fragment); i++ {
+		c := fragment[i]
+
+		if quote != 0 {
+			b.WriteByte(c)
+
+			switch {
+			case c == quote:
+				quote = 0
+			case c == '\\' && a.driver == MySQL && i+1 < len(fragment):
+				i++
+				b.WriteByte(fragment[i])
+			}
+
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		}
+
+		if c != '?' {
+			b.WriteByte(c)
+
+			continue
+		}
+
+		if n >= len(binds) {
+			return "", fmt.Errorf("yiigo: query has more placeholders than binds: %s", fragment)
+		}
+
+		v := binds[n]
+		n++
+
+		rv := reflect.ValueOf(v)
+
+		if _, ok := v.(driver.Valuer); ok || rv.Kind() != reflect.Slice || rv.Type() == reflect.TypeOf([]byte{}) {
+			b.WriteString(a.placeholder())
+			a.binds = append(a.binds, v)
+
+			continue
+		}
+
+		if rv.Len() == 0 {
+			return "", fmt.Errorf("yiigo: empty slice bound to query: %s", fragment)
+		}
+
+		for j := 0; j < rv.Len(); j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+
+			b.WriteString(a.placeholder())
+			a.binds = append(a.binds, rv.Index(j).Interface())
+		}
+	}
+
+	if n != len(binds) {
+		return "", fmt.Errorf("yiigo: query has less placeholders than binds: %s", fragment)
+	}
+
+	return b.String(), nil
+}
+
+// bindCondition binds a condition of QueryBuilder, the condition without binds is kept as is.
+func (a *queryArgs) bindCondition(query string, binds []interface{}) (string, error) {
+	if len(binds) == 0 {
+		return query, nil
+	}
+
+	return a.bind(query, binds)
+}
+
+// placeholder returns the placeholder of the next bind.
+func (a *queryArgs) placeholder() string {
+	return a.driver.dialect().placeholder(len(a.binds) + 1)
+}
+
+// RawQuery a raw sql query with `?` placeholders for the complex queries (eg: window functions and CTEs),
+// the placeholders are rebound and the slices are expanded the same as QueryBuilder.
+//
+//	var rows []*Rank
+//
+//	err := yiigo.Raw("SELECT id, RANK() OVER (ORDER BY score DESC) AS rank FROM `user` WHERE id IN (?)", ids).Scan(ctx, yiigo.DB, &rows)
+type RawQuery struct {
+	driver Driver
+	query  string
+	binds  []interface{}
+}
+
+// Raw returns a new mysql raw query.
+func Raw(query string, binds ...interface{}) *RawQuery {
+	return &RawQuery{
+		driver: MySQL,
+		query:  query,
+		binds:  binds,
+	}
+}
+
+// PGRaw returns a new postgres raw query, the `?` placeholders are rebound to `$n`.
+func PGRaw(query string, binds ...interface{}) *RawQuery {
+	return &RawQuery{
+		driver: Postgres,
+		query:  query,
+		binds:  binds,
+	}
+}
+
+// ToSQL returns the rebound sql and binds.
+func (r *RawQuery) ToSQL() (string, []interface{}, error) {
+	args := (&QueryBuilder{driver: r.driver}).newArgs()
+
+	sql, err := args.bind(r.query, r.binds)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sql, args.binds, nil
+}
+
+// Scan executes the query and scans the rows into dest, a pointer to slice scans all the rows,
+// otherwise the first row is scanned and sql.ErrNoRows (coded 404, see IsDBNotFound) is returned if no row.
+func (r *RawQuery) Scan(ctx context.Context, db sqlx.QueryerContext, dest interface{}) error {
+	sql, binds, err := r.ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice && v.Elem().Type() != reflect.TypeOf([]byte{}) {
+		return dbError(sqlx.SelectContext(ctx, db, dest, sql, binds...))
+	}
+
+	return dbError(sqlx.GetContext(ctx, db, dest, sql, binds...))
+}
+
+// Rows executes the query and returns the rows, which should be closed after reading.
+func (r *RawQuery) Rows(ctx context.Context, db sqlx.QueryerContext) (*sqlx.Rows, error) {
+	sql, binds, err := r.ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryxContext(ctx, sql, binds...)
+
+	return rows, dbError(err)
+}
+
+// Exec executes the query without returning rows, eg: INSERT ... SELECT and UPDATE ... JOIN.
+func (r *RawQuery) Exec(ctx context.Context, db sqlx.ExecerContext) (sql.Result, error) {
+	query, binds, err := r.ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.ExecContext(ctx, query, binds...)
+
+	return result, dbError(err)
+}
@@ -0,0 +1,236 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// ErrDBQueryBudgetExceeded returned by the queries beyond the budget of request when `WithQueryBudgetDeny` specified.
+var ErrDBQueryBudgetExceeded = errors.New("yiigo: db query budget exceeded")
+
+// dbQueryBudgetKey the context key of the query budget.
+type dbQueryBudgetKey struct{}
+
+// dbQueryBudget counts the db queries of a request.
+type dbQueryBudget struct {
+	count int64
+	limit int64
+	deny  bool
+}
+
+// countDBQuery counts a query into the budget of ctx, it's a no-op if ctx has no budget.
+func countDBQuery(ctx context.Context, delta int64) error {
+	b, ok := ctx.Value(dbQueryBudgetKey{}).(*dbQueryBudget)
+
+	if !ok {
+		return nil
+	}
+
+	if n := atomic.AddInt64(&b.count, delta); delta > 0 && b.deny && n > b.limit {
+		return ErrDBQueryBudgetExceeded
+	}
+
+	return nil
+}
+
+// DBQueryCount returns the number of db queries counted in the request of ctx, see DBQueryBudget.
+func DBQueryCount(ctx context.Context) int {
+	b, ok := ctx.Value(dbQueryBudgetKey{}).(*dbQueryBudget)
+
+	if !ok {
+		return 0
+	}
+
+	return int(atomic.LoadInt64(&b.count))
+}
+
+// queryBudgetOptions query budget options
+type queryBudgetOptions struct {
+	deny   bool
+	logger *zap.Logger
+}
+
+// QueryBudgetOption configures how we check the query budget
+type QueryBudgetOption interface {
+	apply(options *queryBudgetOptions)
+}
+
+// funcQueryBudgetOption implements query budget option
+type funcQueryBudgetOption struct {
+	f func(options *queryBudgetOptions)
+}
+
+func (fo *funcQueryBudgetOption) apply(o *queryBudgetOptions) {
+	fo.f(o)
+}
+
+func newFuncQueryBudgetOption(f func(options *queryBudgetOptions)) *funcQueryBudgetOption {
+	return &funcQueryBudgetOption{f: f}
+}
+
+// WithQueryBudgetDeny specifies the queries beyond the budget fail with ErrDBQueryBudgetExceeded.
+func WithQueryBudgetDeny() QueryBudgetOption {
+	return newFuncQueryBudgetOption(func(o *queryBudgetOptions) {
+		o.deny = true
+	})
+}
+
+// WithQueryBudgetLogger specifies the logger of exceeded budgets, defaults to yiigo.Logger.
+func WithQueryBudgetLogger(l *zap.Logger) QueryBudgetOption {
+	return newFuncQueryBudgetOption(func(o *queryBudgetOptions) {
+		o.logger = l
+	})
+}
+
+// DBQueryBudget returns a middleware which counts the db queries per request, and logs a warning
+// when a request executes more than limit queries, to catch the N+1 regressions in staging.
+//
+// Only the dbs registered with `WithDBQueryCount` are counted, and only the queries executed with the request context,
+// eg: yiigo.DB.SelectContext(r.Context(), ...). A statement prepared explicitly is counted once.
+//
+//	yiigo.RegisterDB(yiigo.AsDefault, yiigo.MySQL, dsn, yiigo.WithDBQueryCount())
+//
+//	http.Handle("/orders", yiigo.DBQueryBudget(20)(orderHandler))
+func DBQueryBudget(limit int, options ...QueryBudgetOption) func(http.Handler) http.Handler {
+	o := new(queryBudgetOptions)
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b := &dbQueryBudget{
+				limit: int64(limit),
+				deny:  o.deny,
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), dbQueryBudgetKey{}, b)))
+
+			n := atomic.LoadInt64(&b.count)
+
+			if n <= b.limit {
+				return
+			}
+
+			logger := o.logger
+
+			if logger == nil {
+				logger = Logger
+			}
+
+			if logger == nil {
+				return
+			}
+
+			logger.Warn("yiigo: db query budget exceeded",
+				zap.String("method", r.Method),
+				zap.String("url", r.URL.RequestURI()),
+				zap.Int64("queries", n),
+				zap.Int64("limit", b.limit),
+			)
+		})
+	}
+}
+
+// dbCountConn counts the queries executed on the connection into the query budget of context.
+type dbCountConn struct {
+	driver.Conn
+}
+
+func (c *dbCountConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := countDBQuery(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+func (c *dbCountConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	if err := countDBQuery(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	result, err := execer.ExecContext(ctx, query, args)
+
+	// the query is prepared and counted by PrepareContext instead
+	if err == driver.ErrSkip {
+		countDBQuery(ctx, -1)
+	}
+
+	return result, err
+}
+
+func (c *dbCountConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	if err := countDBQuery(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+
+	// the query is prepared and counted by PrepareContext instead
+	if err == driver.ErrSkip {
+		countDBQuery(ctx, -1)
+	}
+
+	return rows, err
+}
+
+func (c *dbCountConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	if opts.ReadOnly || opts.Isolation != 0 {
+		return nil, errors.New("yiigo: driver does not support non-default transaction options")
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *dbCountConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *dbCountConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *dbCountConn) CheckNamedValue(v *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(v)
+	}
+
+	return driver.ErrSkip
+}
@@ -0,0 +1,92 @@
+package yiigo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// budgetTestDriver opens connections which execute any statement without a database.
+type budgetTestDriver struct{}
+
+func (budgetTestDriver) Open(name string) (driver.Conn, error) {
+	return budgetTestConn{}, nil
+}
+
+type budgetTestConn struct{}
+
+func (budgetTestConn) Prepare(query string) (driver.Stmt, error) { return budgetTestStmt{}, nil }
+func (budgetTestConn) Close() error                              { return nil }
+func (budgetTestConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not implemented") }
+
+type budgetTestStmt struct{}
+
+func (budgetTestStmt) Close() error  { return nil }
+func (budgetTestStmt) NumInput() int { return -1 }
+
+func (budgetTestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (budgetTestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestDBQueryBudget(t *testing.T) {
+	db := sql.OpenDB(&dbConnector{driver: budgetTestDriver{}, count: true})
+
+	defer db.Close()
+
+	tests := []struct {
+		name     string
+		options  []QueryBudgetOption
+		wantErrs int
+		wantLogs int
+	}{
+		{name: "t1", wantErrs: 0, wantLogs: 1},
+		{name: "t2", options: []QueryBudgetOption{WithQueryBudgetDeny()}, wantErrs: 1, wantLogs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+
+			errs := 0
+
+			h := DBQueryBudget(2, append(tt.options, WithQueryBudgetLogger(zap.New(core)))...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 3; i++ {
+					if _, err := db.ExecContext(r.Context(), "UPDATE user SET age = ?", 20); err != nil {
+						if err != ErrDBQueryBudgetExceeded {
+							t.Errorf("DBQueryBudget() query error = %v, want %v", err, ErrDBQueryBudgetExceeded)
+						}
+
+						errs++
+					}
+				}
+
+				if n := DBQueryCount(r.Context()); n != 3 {
+					t.Errorf("DBQueryCount() got = %d, want 3", n)
+				}
+			}))
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+			if errs != tt.wantErrs {
+				t.Errorf("DBQueryBudget() errors = %d, want %d", errs, tt.wantErrs)
+			}
+
+			if logs.Len() != tt.wantLogs {
+				t.Fatalf("DBQueryBudget() warnings = %d, want %d", logs.Len(), tt.wantLogs)
+			}
+
+			if n := logs.All()[0].ContextMap()["queries"]; n != int64(3) {
+				t.Errorf("DBQueryBudget() queries = %v, want 3", n)
+			}
+		})
+	}
+}
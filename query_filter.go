@@ -0,0 +1,163 @@
+package yiigo
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// queryFilterKeyRegexp matches the filter keys, eg: filter[status] and filter[created_at][gte].
+var queryFilterKeyRegexp = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// queryFilterOps the comparison operators of filters, `like` and `in` are handled separately.
+var queryFilterOps = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// QueryFilter parses the filters and sorts of url query into a query builder,
+// only the whitelisted columns can be filtered and sorted, and the values are always bound.
+//
+//	// ?filter[status]=1&filter[created_at][gte]=2024-01-01&filter[role][in]=admin,staff&sort=-id
+//	filter := yiigo.NewQueryFilter().
+//		Filterable("status", "created_at", "role").
+//		Sortable("id", "created_at")
+//
+//	query := yiigo.Query("user")
+//
+//	if err := filter.Apply(query, r.URL.Query()); err != nil {
+//		// respond 400
+//	}
+//
+// The operators are: eq (default), ne, gt, gte, lt, lte, like (contains) and in (comma separated).
+type QueryFilter struct {
+	filterable map[string]string
+	sortable   map[string]string
+}
+
+// NewQueryFilter returns a new query filter without any columns allowed.
+func NewQueryFilter() *QueryFilter {
+	return &QueryFilter{
+		filterable: make(map[string]string),
+		sortable:   make(map[string]string),
+	}
+}
+
+// queryFilterColumn parses a column of whitelist, eg: "status" or "status:u.status" (the name in query string : the column in sql).
+func queryFilterColumn(s string) (string, string) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+
+	return s, s
+}
+
+// Filterable allows the columns to be filtered, eg: Filterable("status", "name:u.name").
+func (f *QueryFilter) Filterable(columns ...string) *QueryFilter {
+	for _, v := range columns {
+		name, column := queryFilterColumn(v)
+
+		f.filterable[name] = column
+	}
+
+	return f
+}
+
+// Sortable allows the columns to be sorted, eg: Sortable("id", "created_at:u.created_at").
+func (f *QueryFilter) Sortable(columns ...string) *QueryFilter {
+	for _, v := range columns {
+		name, column := queryFilterColumn(v)
+
+		f.sortable[name] = column
+	}
+
+	return f
+}
+
+// Apply adds the conditions of `filter[...]` and the orders of `sort` (comma separated, `-` prefix means DESC) in values to q.
+// It returns an error if a column isn't allowed or an operator is unknown, the other query keys are ignored.
+func (f *QueryFilter) Apply(q *QueryBuilder, values url.Values) error {
+	keys := make([]string, 0, len(values))
+
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	// a stable order of conditions for the same query
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		m := queryFilterKeyRegexp.FindStringSubmatch(k)
+
+		if m == nil {
+			continue
+		}
+
+		column, ok := f.filterable[m[1]]
+
+		if !ok {
+			return fmt.Errorf("yiigo: filter on `%s` is not allowed", m[1])
+		}
+
+		op := m[2]
+
+		if op == "" {
+			op = "eq"
+		}
+
+		v := values.Get(k)
+
+		switch op {
+		case "like":
+			q.Where(column+" LIKE ?", "%"+escapeLike(v)+"%")
+		case "in":
+			q.WhereIn(column, strings.Split(v, ","))
+		default:
+			sym, ok := queryFilterOps[op]
+
+			if !ok {
+				return fmt.Errorf("yiigo: unknown filter operator `%s`", op)
+			}
+
+			q.Where(fmt.Sprintf("%s %s ?", column, sym), v)
+		}
+	}
+
+	if s := values.Get("sort"); s != "" {
+		for _, v := range strings.Split(s, ",") {
+			v = strings.TrimSpace(v)
+
+			if v == "" {
+				continue
+			}
+
+			dir := "ASC"
+
+			if strings.HasPrefix(v, "-") {
+				dir = "DESC"
+				v = v[1:]
+			}
+
+			column, ok := f.sortable[v]
+
+			if !ok {
+				return fmt.Errorf("yiigo: sort on `%s` is not allowed", v)
+			}
+
+			q.OrderBy(column + " " + dir)
+		}
+	}
+
+	return nil
+}
+
+// escapeLike escapes the wildcards of LIKE in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,78 @@
+package yiigo
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestQueryFilter_Apply(t *testing.T) {
+	filter := NewQueryFilter().
+		Filterable("status", "created_at", "role", "name:u.name").
+		Sortable("id", "created_at")
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		want1   []interface{}
+		wantErr bool
+	}{
+		{
+			name:  "t1",
+			query: "filter[status]=1&filter[created_at][gte]=2024-01-01&filter[role][in]=admin,staff&sort=-id,created_at&page=2",
+			want:  "SELECT * FROM `user` WHERE (created_at >= ?) AND (role IN (?, ?)) AND (status = ?) ORDER BY id DESC, created_at ASC",
+			want1: []interface{}{"2024-01-01", "admin", "staff", "1"},
+		},
+		{
+			name:  "t2",
+			query: "filter[name][like]=50%25_off",
+			want:  "SELECT * FROM `user` WHERE (u.name LIKE ?)",
+			want1: []interface{}{`%50\%\_off%`},
+		},
+		{
+			name:    "t3",
+			query:   "filter[password]=123",
+			wantErr: true,
+		},
+		{
+			name:    "t4",
+			query:   "filter[status][regexp]=1",
+			wantErr: true,
+		},
+		{
+			name:    "t5",
+			query:   "sort=password",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+
+			q := Query("user")
+
+			if err := filter.Apply(q, values); (err != nil) != tt.wantErr {
+				t.Fatalf("QueryFilter.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got, got1, err := q.ToSQL()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("QueryFilter.Apply() got = %v, want %v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("QueryFilter.Apply() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
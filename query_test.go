@@ -0,0 +1,234 @@
+package yiigo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryBuilder_ToSQL(t *testing.T) {
+	keyword := "yiigo"
+
+	tests := []struct {
+		name  string
+		query *QueryBuilder
+		want  string
+		want1 []interface{}
+	}{
+		{
+			name:  "t1",
+			query: Query("user"),
+			want:  "SELECT * FROM `user`",
+			want1: []interface{}{},
+		},
+		{
+			name: "t2",
+			query: Query("user").
+				Select("id", "name").
+				Where("status = ?", 1).
+				When(keyword != "", func(q *QueryBuilder) {
+					q.Where("name LIKE ?", "%"+keyword+"%")
+				}).
+				When(false, func(q *QueryBuilder) {
+					q.Where("age > ?", 18)
+				}).
+				WhereIn("role", []string{"admin", "staff"}).
+				OrderBy("id DESC").
+				Limit(10).
+				Offset(20),
+			want:  "SELECT id, name FROM `user` WHERE (status = ?) AND (name LIKE ?) AND (role IN (?, ?)) ORDER BY id DESC LIMIT 10 OFFSET 20",
+			want1: []interface{}{1, "%yiigo%", "admin", "staff"},
+		},
+		{
+			name: "t3",
+			query: PGQuery("user").
+				Where("status = ?", 1).
+				OrWhere("id IN (?)", []int{1, 2}).
+				WhereIn("role", []string{}),
+			want:  `SELECT * FROM "user" WHERE ((status = $1) OR (id IN ($2, $3))) AND (1 = 0)`,
+			want1: []interface{}{1, 1, 2},
+		},
+		{
+			name: "t4",
+			query: Query("order AS o").
+				Select("o.user_id", "SUM(o.amount) AS total").
+				Join("LEFT JOIN `user` AS u ON u.id = o.user_id").
+				Where("u.status = ?", nil).
+				GroupBy("o.user_id").
+				Having("total > ?", 100),
+			want:  "SELECT o.user_id, SUM(o.amount) AS total FROM order AS o LEFT JOIN `user` AS u ON u.id = o.user_id WHERE (u.status = ?) GROUP BY o.user_id HAVING total > ?",
+			want1: []interface{}{nil, 100},
+		},
+		{
+			name:  "t5",
+			query: Query("goods").Where("id = ?", 1).ForUpdate(),
+			want:  "SELECT * FROM `goods` WHERE (id = ?) FOR UPDATE",
+			want1: []interface{}{1},
+		},
+		{
+			name:  "t6",
+			query: PGQuery("goods").Where("id = ?", 1).Limit(1).ForShare(),
+			want:  `SELECT * FROM "goods" WHERE (id = $1) LIMIT 1 FOR SHARE`,
+			want1: []interface{}{1},
+		},
+		{
+			name: "t7",
+			query: Query("user").
+				Where("role = ?", "admin").
+				OrWhere("role = ?", "staff").
+				Where("tenant_id = ?", 7).
+				OrWhere("id = ?", 1).
+				Where("status = ?", 1),
+			want:  "SELECT * FROM `user` WHERE (((role = ?) OR (role = ?)) AND (tenant_id = ?) OR (id = ?)) AND (status = ?)",
+			want1: []interface{}{"admin", "staff", 7, 1, 1},
+		},
+		{
+			name:  "t8",
+			query: Query("user").Where("name = '?'").Where("id = ?", 1).Where("remark <> 'a?' AND age > ?", 18),
+			want:  "SELECT * FROM `user` WHERE (name = '?') AND (id = ?) AND (remark <> 'a?' AND age > ?)",
+			want1: []interface{}{1, 18},
+		},
+		{
+			name:  "t9",
+			query: PGQuery("user").Where("tags ? 'vip'").Where("id = ?", 1).Having("COUNT(*) > ?", 2),
+			want:  `SELECT * FROM "user" WHERE (tags ? 'vip') AND (id = $1) HAVING COUNT(*) > $2`,
+			want1: []interface{}{1, 2},
+		},
+		{
+			name:  "t10",
+			query: Query("user").Where("a = ?", 1).Offset(10),
+			want:  "SELECT * FROM `user` WHERE (a = ?) LIMIT 18446744073709551615 OFFSET 10",
+			want1: []interface{}{1},
+		},
+		{
+			name:  "t11",
+			query: PGQuery("user").Offset(10),
+			want:  `SELECT * FROM "user" OFFSET 10`,
+			want1: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, err := tt.query.ToSQL()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("QueryBuilder.ToSQL() got = %v, want %v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("QueryBuilder.ToSQL() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestQueryBuilder_CountSQL(t *testing.T) {
+	q := PGQuery("user").Where("status = ?", 1).OrderBy("id DESC").Limit(10)
+
+	got, got1, err := q.CountSQL()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `SELECT COUNT(*) FROM "user" WHERE (status = $1)`; got != want {
+		t.Errorf("QueryBuilder.CountSQL() got = %v, want %v", got, want)
+	}
+
+	if !reflect.DeepEqual(got1, []interface{}{1}) {
+		t.Errorf("QueryBuilder.CountSQL() got1 = %v", got1)
+	}
+
+	got, _, _ = q.Clone().GroupBy("role").CountSQL()
+
+	if want := `SELECT COUNT(*) FROM (SELECT * FROM "user" WHERE (status = $1) GROUP BY role) AS t`; got != want {
+		t.Errorf("QueryBuilder.CountSQL() group got = %v, want %v", got, want)
+	}
+
+	got, _, _ = q.Clone().Select("DISTINCT user_id").CountSQL()
+
+	if want := `SELECT COUNT(*) FROM (SELECT DISTINCT user_id FROM "user" WHERE (status = $1)) AS t`; got != want {
+		t.Errorf("QueryBuilder.CountSQL() distinct got = %v, want %v", got, want)
+	}
+
+	got, _, _ = q.Clone().Select("distinct_id").CountSQL()
+
+	if want := `SELECT COUNT(*) FROM "user" WHERE (status = $1)`; got != want {
+		t.Errorf("QueryBuilder.CountSQL() distinct_id got = %v, want %v", got, want)
+	}
+
+	if _, _, err := Query("user").Where("id = ? AND name = ?", 1).ToSQL(); err == nil {
+		t.Error("QueryBuilder.ToSQL() error = nil, want placeholder mismatch")
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		page    int
+		perPage int
+		want    *Pagination
+	}{
+		{name: "first", total: 45, page: 1, perPage: 10, want: &Pagination{Total: 45, TotalPages: 5, Page: 1, PerPage: 10}},
+		{name: "exact", total: 40, page: 2, perPage: 10, want: &Pagination{Total: 40, TotalPages: 4, Page: 2, PerPage: 10}},
+		{name: "empty", total: 0, page: 1, perPage: 10, want: &Pagination{Total: 0, TotalPages: 0, Page: 1, PerPage: 10}},
+		{name: "defaults", total: 45, page: 0, perPage: 0, want: &Pagination{Total: 45, TotalPages: 3, Page: 1, PerPage: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newPagination(tt.total, tt.page, tt.perPage); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newPagination() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawQuery_ToSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *RawQuery
+		want    string
+		want1   []interface{}
+		wantErr bool
+	}{
+		{
+			name:  "t1",
+			query: Raw("WITH t AS (SELECT * FROM `user` WHERE id IN (?)) SELECT * FROM t WHERE status = ?", []int{1, 2}, 1),
+			want:  "WITH t AS (SELECT * FROM `user` WHERE id IN (?, ?)) SELECT * FROM t WHERE status = ?",
+			want1: []interface{}{1, 2, 1},
+		},
+		{
+			name:  "t2",
+			query: PGRaw("SELECT * FROM users WHERE id IN (?) AND status = ?", []int{1, 2}, 1),
+			want:  "SELECT * FROM users WHERE id IN ($1, $2) AND status = $3",
+			want1: []interface{}{1, 2, 1},
+		},
+		{
+			name:    "t3",
+			query:   Raw("SELECT * FROM `user` WHERE id = ?"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, err := tt.query.ToSQL()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RawQuery.ToSQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("RawQuery.ToSQL() got = %v, want %v", got, tt.want)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("RawQuery.ToSQL() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
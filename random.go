@@ -0,0 +1,182 @@
+package yiigo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Alphabets of random strings
+const (
+	AlphabetDigits       = "0123456789"
+	AlphabetLetters      = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetAlphanumeric = AlphabetDigits + AlphabetLetters
+	// AlphabetReadable excludes the confusable characters `0 O 1 I`, for the codes read and typed by humans.
+	AlphabetReadable = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// ErrUniqueCodeExhausted returned when no unique code is generated within the attempts.
+var ErrUniqueCodeExhausted = errors.New("yiigo: unique code attempts exhausted")
+
+// RandomString returns a random string of n characters from alphabet, backed by crypto/rand.
+func RandomString(n int, alphabet string) (string, error) {
+	if len(alphabet) == 0 {
+		return "", errors.New("yiigo: empty alphabet")
+	}
+
+	max := big.NewInt(int64(len(alphabet)))
+
+	b := make([]byte, n)
+
+	for i := range b {
+		// rand.Int is uniform, no modulo bias
+		v, err := rand.Int(rand.Reader, max)
+
+		if err != nil {
+			return "", err
+		}
+
+		b[i] = alphabet[v.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// RandomCode returns a random numeric code of n digits, eg: the SMS verification code.
+func RandomCode(n int) (string, error) {
+	return RandomString(n, AlphabetDigits)
+}
+
+// RandomToken returns a URL-safe base64 token of n random bytes, eg: RandomToken(32) for a session or reset token.
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// InviteCode returns a random code of n characters from alphabet (defaults to AlphabetReadable),
+// the last character is a checksum, so VerifyInviteCode rejects the mistyped codes without a lookup.
+func InviteCode(n int, alphabet ...string) (string, error) {
+	a := AlphabetReadable
+
+	if len(alphabet) > 0 {
+		a = alphabet[0]
+	}
+
+	if n < 2 {
+		return "", errors.New("yiigo: invite code needs at least 2 characters")
+	}
+
+	s, err := RandomString(n-1, a)
+
+	if err != nil {
+		return "", err
+	}
+
+	return s + string(a[inviteChecksum(s, a)]), nil
+}
+
+// VerifyInviteCode reports whether the checksum of code generated by InviteCode is valid, case-insensitive for AlphabetReadable.
+func VerifyInviteCode(code string, alphabet ...string) bool {
+	a := AlphabetReadable
+
+	if len(alphabet) > 0 {
+		a = alphabet[0]
+	}
+
+	if a == AlphabetReadable {
+		code = strings.ToUpper(code)
+	}
+
+	if len(code) < 2 {
+		return false
+	}
+
+	body := code[:len(code)-1]
+
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(a, body[i]) < 0 {
+			return false
+		}
+	}
+
+	return code[len(code)-1] == a[inviteChecksum(body, a)]
+}
+
+// inviteChecksum returns the index of checksum character, a weighted sum (like ISBN) which detects a swap of adjacent characters and most of the single typos.
+func inviteChecksum(s, alphabet string) int {
+	sum := 0
+
+	for i := 0; i < len(s); i++ {
+		sum += (i + 1) * strings.IndexByte(alphabet, s[i])
+	}
+
+	return sum % len(alphabet)
+}
+
+// UniqueCode generates a code with gen that is unique in the namespace of prefix, by reserving it in redis with `SET NX`.
+// The reservation expires after ttl (0 means never), eg: the pending verification codes or the invite codes of a campaign.
+// It returns ErrUniqueCodeExhausted if all the attempts collide.
+func UniqueCode(pool *RedisPoolResource, prefix string, ttl time.Duration, attempts int, gen func() (string, error)) (string, error) {
+	conn, err := pool.Get()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer pool.Put(conn)
+
+	for i := 0; i < attempts; i++ {
+		code, err := gen()
+
+		if err != nil {
+			return "", err
+		}
+
+		args := redis.Args{prefix + code, 1, "NX"}
+
+		if ms := int64(ttl / time.Millisecond); ms > 0 {
+			args = args.Add("PX", ms)
+		}
+
+		reply, err := redis.String(conn.Do("SET", args...))
+
+		if err == redis.ErrNil {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if reply == "OK" {
+			return code, nil
+		}
+	}
+
+	return "", ErrUniqueCodeExhausted
+}
+
+// ReleaseCode releases the code reserved by UniqueCode, eg: after the verification code is used.
+func ReleaseCode(pool *RedisPoolResource, prefix, code string) error {
+	conn, err := pool.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer pool.Put(conn)
+
+	_, err = conn.Do("DEL", prefix+code)
+
+	return err
+}
@@ -0,0 +1,80 @@
+package yiigo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandomString(t *testing.T) {
+	s, err := RandomString(32, AlphabetReadable)
+
+	if err != nil {
+		t.Errorf("RandomString() error = %v", err)
+
+		return
+	}
+
+	if len(s) != 32 {
+		t.Errorf("RandomString() length = %d, want 32", len(s))
+	}
+
+	for _, c := range s {
+		if !strings.ContainsRune(AlphabetReadable, c) {
+			t.Errorf("RandomString() = %v, has invalid character %c", s, c)
+		}
+	}
+}
+
+func TestVerifyInviteCode(t *testing.T) {
+	code, err := InviteCode(8)
+
+	if err != nil {
+		t.Errorf("InviteCode() error = %v", err)
+
+		return
+	}
+
+	if !VerifyInviteCode(code) || !VerifyInviteCode(strings.ToLower(code)) {
+		t.Errorf("VerifyInviteCode(%v) = false, want true", code)
+	}
+
+	// swap the adjacent characters
+	swapped := []byte(code)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+
+	if swapped[0] != swapped[1] && VerifyInviteCode(string(swapped)) {
+		t.Errorf("VerifyInviteCode(%s) = true, want false", swapped)
+	}
+
+	type args struct {
+		code string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "t1",
+			args: args{code: "2345678J"},
+			want: true,
+		},
+		{
+			name: "t2",
+			args: args{code: "2345678K"},
+			want: false,
+		},
+		{
+			name: "t3",
+			args: args{code: "0345678J"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyInviteCode(tt.args.code); got != tt.want {
+				t.Errorf("VerifyInviteCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
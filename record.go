@@ -0,0 +1,392 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// recordMask the value which the secrets are replaced with.
+const recordMask = "***"
+
+// RequestRecord a recorded request and its response.
+type RequestRecord struct {
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"request_header"`
+	RequestBody    string        `json:"request_body"`
+	Status         int           `json:"status"`
+	ResponseHeader http.Header   `json:"response_header"`
+	ResponseBody   string        `json:"response_body"`
+	Duration       time.Duration `json:"duration"`
+	RecordedAt     time.Time     `json:"recorded_at"`
+}
+
+// RequestRecordStore persists the recorded requests.
+type RequestRecordStore interface {
+	Save(ctx context.Context, record *RequestRecord) error
+}
+
+// dbRequestRecordStore a RequestRecordStore backed by db
+type dbRequestRecordStore struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewDBRequestRecordStore returns a RequestRecordStore which inserts the records into the table of db, eg (mysql):
+//
+//	CREATE TABLE `request_record` (
+//		`id` bigint unsigned NOT NULL AUTO_INCREMENT,
+//		`method` varchar(16) NOT NULL,
+//		`url` varchar(2048) NOT NULL,
+//		`request_header` text NOT NULL,
+//		`request_body` mediumtext NOT NULL,
+//		`status` int NOT NULL,
+//		`response_header` text NOT NULL,
+//		`response_body` mediumtext NOT NULL,
+//		`duration_ms` bigint NOT NULL,
+//		`recorded_at` datetime NOT NULL,
+//		PRIMARY KEY (`id`)
+//	);
+func NewDBRequestRecordStore(db *sqlx.DB, table string) RequestRecordStore {
+	return &dbRequestRecordStore{
+		db:    db,
+		table: table,
+	}
+}
+
+func (s *dbRequestRecordStore) Save(ctx context.Context, record *RequestRecord) error {
+	reqHeader, err := jsonCodec.Marshal(record.RequestHeader)
+
+	if err != nil {
+		return err
+	}
+
+	respHeader, err := jsonCodec.Marshal(record.ResponseHeader)
+
+	if err != nil {
+		return err
+	}
+
+	query, binds := insertSQL(driverOf(s.db), s.table, X{
+		"method":          record.Method,
+		"url":             record.URL,
+		"request_header":  string(reqHeader),
+		"request_body":    record.RequestBody,
+		"status":          record.Status,
+		"response_header": string(respHeader),
+		"response_body":   record.ResponseBody,
+		"duration_ms":     int64(record.Duration / time.Millisecond),
+		"recorded_at":     record.RecordedAt,
+	})
+
+	_, err = s.db.ExecContext(ctx, query, binds...)
+
+	return err
+}
+
+// recordOptions request recording options
+type recordOptions struct {
+	rate        float64
+	maxBodySize int
+	headers     []string
+	fields      map[string]struct{}
+}
+
+// RecordOption configures how we record the requests
+type RecordOption interface {
+	apply(options *recordOptions)
+}
+
+// funcRecordOption implements record option
+type funcRecordOption struct {
+	f func(options *recordOptions)
+}
+
+func (fo *funcRecordOption) apply(o *recordOptions) {
+	fo.f(o)
+}
+
+func newFuncRecordOption(f func(options *recordOptions)) *funcRecordOption {
+	return &funcRecordOption{f: f}
+}
+
+// WithRecordSampleRate specifies the percentage (0 to 1) of the requests recorded.
+func WithRecordSampleRate(rate float64) RecordOption {
+	return newFuncRecordOption(func(o *recordOptions) {
+		o.rate = rate
+	})
+}
+
+// WithRecordMaxBodySize specifies the maximum bytes of the request and response bodies recorded, the rest is truncated.
+func WithRecordMaxBodySize(n int) RecordOption {
+	return newFuncRecordOption(func(o *recordOptions) {
+		o.maxBodySize = n
+	})
+}
+
+// WithRecordMaskHeaders specifies the headers masked, which replaces the defaults.
+func WithRecordMaskHeaders(names ...string) RecordOption {
+	return newFuncRecordOption(func(o *recordOptions) {
+		o.headers = names
+	})
+}
+
+// WithRecordMaskFields specifies the fields of JSON bodies and url query masked (case-insensitive), which replaces the defaults.
+func WithRecordMaskFields(names ...string) RecordOption {
+	return newFuncRecordOption(func(o *recordOptions) {
+		o.fields = make(map[string]struct{}, len(names))
+
+		for _, v := range names {
+			o.fields[strings.ToLower(v)] = struct{}{}
+		}
+	})
+}
+
+// recordResponseWriter passes the response through and keeps a copy of it.
+type recordResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	limit  int
+}
+
+func (w *recordResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if n := w.limit - w.body.Len(); n > 0 {
+		if n > len(b) {
+			n = len(b)
+		}
+
+		w.body.Write(b[:n])
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps the streaming responses (eg: SSE) working.
+func (w *recordResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// RecordRequests returns an opt-in middleware which records the sampled requests and their responses into store asynchronously,
+// to reproduce the production bugs by ReplayRequest. The secrets are masked as "***" before saved.
+//
+//	http.Handle("/orders", yiigo.RecordRequests(yiigo.NewDBRequestRecordStore(yiigo.DB, "request_record"), yiigo.WithRecordSampleRate(0.01))(orderHandler))
+//
+// The default `SampleRate` is 0.01.
+// The default `MaxBodySize` is 64KB.
+// The default `MaskHeaders` are Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+// The default `MaskFields` are password, token and secret.
+func RecordRequests(store RequestRecordStore, options ...RecordOption) func(http.Handler) http.Handler {
+	o := &recordOptions{
+		rate:        0.01,
+		maxBodySize: 64 << 10,
+		headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		fields: map[string]struct{}{
+			"password": {},
+			"token":    {},
+			"secret":   {},
+		},
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rand.Float64() >= o.rate {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			var reqBody []byte
+
+			// read the head of body to record, and restore the body as it is
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody, _ = ioutil.ReadAll(io.LimitReader(r.Body, int64(o.maxBodySize)))
+
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+
+			rw := &recordResponseWriter{
+				ResponseWriter: w,
+				limit:          o.maxBodySize,
+			}
+
+			start := time.Now()
+
+			next.ServeHTTP(rw, r)
+
+			record := &RequestRecord{
+				Method:         r.Method,
+				URL:            o.maskURL(r.URL.RequestURI()),
+				RequestHeader:  o.maskHeader(r.Header),
+				RequestBody:    o.maskBody(reqBody),
+				Status:         rw.status,
+				ResponseHeader: o.maskHeader(w.Header()),
+				ResponseBody:   o.maskBody(rw.body.Bytes()),
+				Duration:       time.Since(start),
+				RecordedAt:     start,
+			}
+
+			SafeGo(func() {
+				if err := store.Save(context.Background(), record); err != nil {
+					logRecordError(err)
+				}
+			})
+		})
+	}
+}
+
+func logRecordError(err error) {
+	if Logger == nil {
+		log.Println("yiigo: save request record:", err)
+
+		return
+	}
+
+	Logger.Error("yiigo: save request record", zap.Error(err))
+}
+
+func (o *recordOptions) maskHeader(header http.Header) http.Header {
+	h := make(http.Header, len(header))
+
+	for k, v := range header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	for _, v := range o.headers {
+		if _, ok := h[http.CanonicalHeaderKey(v)]; ok {
+			h.Set(v, recordMask)
+		}
+	}
+
+	return h
+}
+
+func (o *recordOptions) maskURL(uri string) string {
+	i := strings.Index(uri, "?")
+
+	if i < 0 {
+		return uri
+	}
+
+	params := strings.Split(uri[i+1:], "&")
+
+	for j, v := range params {
+		kv := strings.SplitN(v, "=", 2)
+
+		if _, ok := o.fields[strings.ToLower(kv[0])]; ok && len(kv) == 2 {
+			params[j] = kv[0] + "=" + recordMask
+		}
+	}
+
+	return uri[:i+1] + strings.Join(params, "&")
+}
+
+// maskBody masks the fields of a JSON body, the other bodies are kept as they are.
+func (o *recordOptions) maskBody(body []byte) string {
+	var v interface{}
+
+	if len(o.fields) == 0 || jsonCodec.Unmarshal(body, &v) != nil {
+		return string(body)
+	}
+
+	if !o.maskValue(v) {
+		return string(body)
+	}
+
+	b, err := jsonCodec.Marshal(v)
+
+	if err != nil {
+		return string(body)
+	}
+
+	return string(b)
+}
+
+// maskValue masks the fields of a decoded JSON value in place, and reports whether any field is masked.
+func (o *recordOptions) maskValue(v interface{}) bool {
+	masked := false
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, item := range x {
+			if _, ok := o.fields[strings.ToLower(k)]; ok {
+				x[k] = recordMask
+				masked = true
+
+				continue
+			}
+
+			if o.maskValue(item) {
+				masked = true
+			}
+		}
+	case []interface{}:
+		for _, item := range x {
+			if o.maskValue(item) {
+				masked = true
+			}
+		}
+	}
+
+	return masked
+}
+
+// ReplayRequest replays the recorded request against h and returns the response, to reproduce a production bug in tests.
+// The masked values are replayed as "***", so restore them by modify (it can be nil) if the handler checks them, eg: a session cookie.
+//
+//	record := new(yiigo.RequestRecord) // loaded from the store
+//
+//	w := yiigo.ReplayRequest(router, record, func(r *http.Request) {
+//		r.Header.Set("Authorization", "Bearer "+testToken)
+//	})
+func ReplayRequest(h http.Handler, record *RequestRecord, modify func(r *http.Request)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(record.Method, record.URL, strings.NewReader(record.RequestBody))
+
+	for k, v := range record.RequestHeader {
+		r.Header[k] = append([]string(nil), v...)
+	}
+
+	if modify != nil {
+		modify(r)
+	}
+
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	return w
+}
@@ -0,0 +1,72 @@
+package yiigo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chanRequestRecordStore chan *RequestRecord
+
+func (s chanRequestRecordStore) Save(ctx context.Context, record *RequestRecord) error {
+	s <- record
+
+	return nil
+}
+
+func TestRecordRequests(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(b) + `}`))
+	})
+
+	store := make(chanRequestRecordStore, 1)
+
+	r := httptest.NewRequest("POST", "/login?token=abc&page=1", strings.NewReader(`{"name":"IIInsomnia","password":"123456"}`))
+	r.Header.Set("Authorization", "Bearer abc")
+
+	w := httptest.NewRecorder()
+
+	RecordRequests(store, WithRecordSampleRate(1))(h).ServeHTTP(w, r)
+
+	if want := `{"echo":{"name":"IIInsomnia","password":"123456"}}`; w.Body.String() != want {
+		t.Errorf("RecordRequests() response = %s, want %s", w.Body.String(), want)
+	}
+
+	var record *RequestRecord
+
+	select {
+	case record = <-store:
+	case <-time.After(time.Second):
+		t.Fatal("RecordRequests() record is not saved")
+	}
+
+	if want := "/login?token=***&page=1"; record.URL != want {
+		t.Errorf("RecordRequests() url = %s, want %s", record.URL, want)
+	}
+
+	if got := record.RequestHeader.Get("Authorization"); got != recordMask {
+		t.Errorf("RecordRequests() Authorization = %s, want %s", got, recordMask)
+	}
+
+	if want := `{"name":"IIInsomnia","password":"***"}`; record.RequestBody != want {
+		t.Errorf("RecordRequests() request body = %s, want %s", record.RequestBody, want)
+	}
+
+	if want := `{"echo":{"name":"IIInsomnia","password":"***"}}`; record.Status != http.StatusCreated || record.ResponseBody != want {
+		t.Errorf("RecordRequests() response = %d %s, want 201 %s", record.Status, record.ResponseBody, want)
+	}
+
+	replay := ReplayRequest(h, record, nil)
+
+	if replay.Code != http.StatusCreated || replay.Body.String() != record.ResponseBody {
+		t.Errorf("ReplayRequest() = %d %s, want 201 %s", replay.Code, replay.Body.String(), record.ResponseBody)
+	}
+}
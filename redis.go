@@ -185,6 +185,16 @@ func (r *RedisPoolResource) Put(rc RedisConn) {
 	r.pool.Put(rc)
 }
 
+// Close closes the pool, it waits for all the connection resources to be returned.
+func (r *RedisPoolResource) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.pool != nil && !r.pool.IsClosed() {
+		r.pool.Close()
+	}
+}
+
 var (
 	// Redis default redis connection pool
 	Redis    *RedisPoolResource
@@ -239,3 +249,40 @@ func UseRedis(name string) *RedisPoolResource {
 
 	return v.(*RedisPoolResource)
 }
+
+// CloseRedis closes the registered redis pool, it waits for the connections in use to be returned until `ctx` is done.
+// Closing the default redis pool sets yiigo.Redis to nil.
+func CloseRedis(ctx context.Context, name string) error {
+	v, ok := redisMap.Load(name)
+
+	if !ok {
+		return fmt.Errorf("yiigo: redis.%s is not registered", name)
+	}
+
+	redisMap.Delete(name)
+
+	if name == AsDefault && Redis == v.(*RedisPoolResource) {
+		Redis = nil
+	}
+
+	return closeWithContext(ctx, func() error {
+		v.(*RedisPoolResource).Close()
+
+		return nil
+	})
+}
+
+// CloseAllRedis closes all the registered redis pools, it waits for the connections in use to be returned until `ctx` is done.
+func CloseAllRedis(ctx context.Context) error {
+	var err error
+
+	redisMap.Range(func(k, v interface{}) bool {
+		if e := CloseRedis(ctx, k.(string)); e != nil && err == nil {
+			err = e
+		}
+
+		return true
+	})
+
+	return err
+}
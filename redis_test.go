@@ -0,0 +1,25 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedis starts an in-memory redis server and registers a pool of it as name,
+// the returned func closes both.
+func newTestRedis(t *testing.T, name string) (*miniredis.Miniredis, *RedisPoolResource, func()) {
+	mr, err := miniredis.Run()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterRedis(name, mr.Addr())
+
+	return mr, UseRedis(name), func() {
+		CloseRedis(context.Background(), name)
+		mr.Close()
+	}
+}
@@ -0,0 +1,188 @@
+package yiigo
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RuntimeStats a sample of the runtime stats.
+type RuntimeStats struct {
+	Goroutines   int
+	HeapAlloc    uint64 // bytes of allocated heap objects
+	HeapObjects  uint64
+	HeapSys      uint64
+	NumGC        uint32
+	PauseTotal   time.Duration
+	LastPause    time.Duration
+	GCCPUPercent float64
+	OpenFDs      int // -1 if unknown, it's only supported on linux
+	SampleTime   time.Time
+}
+
+// runtimeOptions runtime monitor options
+type runtimeOptions struct {
+	interval      time.Duration
+	maxGoroutines int
+	maxHeapAlloc  uint64
+	maxOpenFDs    int
+	logger        *zap.Logger
+	handler       func(stats *RuntimeStats)
+}
+
+// RuntimeOption configures how we set up the runtime monitor
+type RuntimeOption interface {
+	apply(options *runtimeOptions)
+}
+
+// funcRuntimeOption implements runtime option
+type funcRuntimeOption struct {
+	f func(options *runtimeOptions)
+}
+
+func (fo *funcRuntimeOption) apply(o *runtimeOptions) {
+	fo.f(o)
+}
+
+func newFuncRuntimeOption(f func(options *runtimeOptions)) *funcRuntimeOption {
+	return &funcRuntimeOption{f: f}
+}
+
+// WithRuntimeInterval specifies the interval of sampling.
+func WithRuntimeInterval(d time.Duration) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.interval = d
+	})
+}
+
+// WithRuntimeMaxGoroutines specifies the threshold of goroutine count to warn, 0 means no warning.
+func WithRuntimeMaxGoroutines(n int) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.maxGoroutines = n
+	})
+}
+
+// WithRuntimeMaxHeapAlloc specifies the threshold of heap allocated bytes to warn, 0 means no warning.
+func WithRuntimeMaxHeapAlloc(n uint64) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.maxHeapAlloc = n
+	})
+}
+
+// WithRuntimeMaxOpenFDs specifies the threshold of open file descriptors to warn, 0 means no warning.
+func WithRuntimeMaxOpenFDs(n int) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.maxOpenFDs = n
+	})
+}
+
+// WithRuntimeLogger specifies the logger of warnings, defaults to yiigo.Logger.
+func WithRuntimeLogger(l *zap.Logger) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.logger = l
+	})
+}
+
+// WithRuntimeHandler specifies the handler of every sample, eg: to feed the metrics.
+func WithRuntimeHandler(f func(stats *RuntimeStats)) RuntimeOption {
+	return newFuncRuntimeOption(func(o *runtimeOptions) {
+		o.handler = f
+	})
+}
+
+// ReadRuntimeStats samples the runtime stats now.
+// Note: it calls runtime.ReadMemStats, which stops the world for a short while.
+func ReadRuntimeStats() *RuntimeStats {
+	ms := new(runtime.MemStats)
+
+	runtime.ReadMemStats(ms)
+
+	stats := &RuntimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    ms.HeapAlloc,
+		HeapObjects:  ms.HeapObjects,
+		HeapSys:      ms.HeapSys,
+		NumGC:        ms.NumGC,
+		PauseTotal:   time.Duration(ms.PauseTotalNs),
+		GCCPUPercent: ms.GCCPUFraction * 100,
+		OpenFDs:      openFDs(),
+		SampleTime:   time.Now(),
+	}
+
+	if ms.NumGC > 0 {
+		stats.LastPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+	}
+
+	return stats
+}
+
+// openFDs returns the number of open file descriptors of the process, -1 if unknown.
+func openFDs() int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+
+	if err != nil {
+		return -1
+	}
+
+	return len(fds)
+}
+
+// MonitorRuntime samples the runtime stats periodically until ctx is done,
+// and logs a warning whenever a sample exceeds the thresholds, for quick leak detection.
+//
+// The default `Interval` is 1 minute.
+func MonitorRuntime(ctx context.Context, options ...RuntimeOption) {
+	o := &runtimeOptions{interval: time.Minute}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	ticker := time.NewTicker(o.interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := ReadRuntimeStats()
+
+			if o.handler != nil {
+				o.handler(stats)
+			}
+
+			checkRuntimeStats(stats, o)
+		}
+	}
+}
+
+func checkRuntimeStats(stats *RuntimeStats, o *runtimeOptions) {
+	logger := o.logger
+
+	if logger == nil {
+		logger = Logger
+	}
+
+	if logger == nil {
+		return
+	}
+
+	if o.maxGoroutines > 0 && stats.Goroutines > o.maxGoroutines {
+		logger.Warn("yiigo: too many goroutines", zap.Int("goroutines", stats.Goroutines), zap.Int("threshold", o.maxGoroutines))
+	}
+
+	if o.maxHeapAlloc > 0 && stats.HeapAlloc > o.maxHeapAlloc {
+		logger.Warn("yiigo: heap alloc exceeds the threshold", zap.Uint64("heap_alloc", stats.HeapAlloc), zap.Uint64("threshold", o.maxHeapAlloc))
+	}
+
+	if o.maxOpenFDs > 0 && stats.OpenFDs > o.maxOpenFDs {
+		logger.Warn("yiigo: too many open files", zap.Int("open_fds", stats.OpenFDs), zap.Int("threshold", o.maxOpenFDs))
+	}
+}
@@ -0,0 +1,31 @@
+package yiigo
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_checkRuntimeStats(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+
+	o := &runtimeOptions{
+		maxGoroutines: 100,
+		maxHeapAlloc:  1 << 20,
+		maxOpenFDs:    1000,
+		logger:        zap.New(core),
+	}
+
+	checkRuntimeStats(&RuntimeStats{Goroutines: 101, HeapAlloc: 1 << 10, OpenFDs: -1}, o)
+
+	if logs.Len() != 1 {
+		t.Errorf("checkRuntimeStats() warnings = %d, want 1", logs.Len())
+
+		return
+	}
+
+	if msg := logs.All()[0].Message; msg != "yiigo: too many goroutines" {
+		t.Errorf("checkRuntimeStats() warning = %v, want yiigo: too many goroutines", msg)
+	}
+}
@@ -0,0 +1,52 @@
+package yiigo
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// SafeGo runs f in a goroutine, a panic is recovered and logged by yiigo.Logger (or the standard logger if not registered),
+// so a panicking callback can't crash the whole process.
+func SafeGo(f func()) {
+	go func() {
+		defer logPanic()
+
+		f()
+	}()
+}
+
+// logPanic recovers and logs the panic, it should be deferred directly.
+func logPanic() {
+	if r := recover(); r != nil {
+		logPanicError(panicError(r))
+	}
+}
+
+func logPanicError(err error) {
+	if Logger == nil {
+		log.Println(err)
+
+		return
+	}
+
+	Logger.Error("yiigo: goroutine panicked", zap.Error(err))
+}
+
+// callSafe calls f and converts the panic to an error, for the goroutines which report their errors.
+func callSafe(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+
+	return f()
+}
+
+// panicError returns the error of a recovered panic with the stack trace.
+func panicError(r interface{}) error {
+	return fmt.Errorf("yiigo: panic recovered: %v\n%s", r, debug.Stack())
+}
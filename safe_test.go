@@ -0,0 +1,43 @@
+package yiigo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSafeGo(t *testing.T) {
+	done := make(chan struct{})
+
+	SafeGo(func() {
+		defer close(done)
+
+		panic("boom")
+	})
+
+	<-done
+}
+
+func TestCallSafe(t *testing.T) {
+	err := callSafe(func() error {
+		panic("boom")
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "panic recovered: boom") {
+		t.Errorf("callSafe() error = %v, want the recovered panic", err)
+	}
+}
+
+func TestDBFanOutPanic(t *testing.T) {
+	dbmap.Store("panic_test", (*sqlx.DB)(nil))
+
+	defer dbmap.Delete("panic_test")
+
+	err := DBFanOut(context.Background(), []string{"panic_test"}, 1, nil)
+
+	if e, ok := err.(DBFanOutError); !ok || e["panic_test"] == nil {
+		t.Errorf("DBFanOut() error = %v, want the recovered panic of panic_test", err)
+	}
+}
@@ -0,0 +1,307 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SagaStatus the status of a saga execution.
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"      // the steps are being executed
+	SagaCompensating SagaStatus = "compensating" // a step failed, the completed steps are being compensated
+	SagaDone         SagaStatus = "done"         // all the steps succeeded
+	SagaCompensated  SagaStatus = "compensated"  // all the completed steps were compensated
+)
+
+// SagaState the progress of a saga execution, which is persisted by SagaStore.
+type SagaState struct {
+	ID      string     `db:"id"`
+	Name    string     `db:"name"`
+	Payload []byte     `db:"payload"`
+	Step    int        `db:"step"` // the count of completed steps
+	Status  SagaStatus `db:"status"`
+	Error   string     `db:"error"`
+}
+
+// SagaStore persists the progress of sagas, so the unfinished ones can be resumed after a crash.
+type SagaStore interface {
+	// Save creates or updates the state.
+	Save(ctx context.Context, state *SagaState) error
+	// Pending returns the states of saga name which are running or compensating.
+	Pending(ctx context.Context, name string) ([]*SagaState, error)
+}
+
+// SagaError returned when a saga step fails.
+type SagaError struct {
+	Step          string // the failed step
+	Err           error  // the error of step
+	CompensateErr error  // the error of compensation, nil means all the completed steps were compensated
+}
+
+// Error returns the error of step and compensation.
+func (e *SagaError) Error() string {
+	if e.CompensateErr != nil {
+		return fmt.Sprintf("yiigo: saga step %s: %v, compensate: %v", e.Step, e.Err, e.CompensateErr)
+	}
+
+	return fmt.Sprintf("yiigo: saga step %s: %v", e.Step, e.Err)
+}
+
+// SagaFunc the action or compensation of a saga step, it receives the payload of execution.
+// It can be re-executed on resume, so should be idempotent.
+type SagaFunc func(ctx context.Context, payload []byte) error
+
+type sagaStep struct {
+	name       string
+	action     SagaFunc
+	compensate SagaFunc
+}
+
+// sagaOptions saga options
+type sagaOptions struct {
+	store SagaStore
+}
+
+// SagaOption configures how we set up the saga
+type SagaOption interface {
+	apply(options *sagaOptions)
+}
+
+// funcSagaOption implements saga option
+type funcSagaOption struct {
+	f func(options *sagaOptions)
+}
+
+func (fo *funcSagaOption) apply(o *sagaOptions) {
+	fo.f(o)
+}
+
+func newFuncSagaOption(f func(options *sagaOptions)) *funcSagaOption {
+	return &funcSagaOption{f: f}
+}
+
+// WithSagaStore specifies the store to persist the progress, eg: NewDBSagaStore.
+func WithSagaStore(s SagaStore) SagaOption {
+	return newFuncSagaOption(func(o *sagaOptions) {
+		o.store = s
+	})
+}
+
+// Saga executes the steps of a multi-step operation in order, and compensates the completed steps
+// in reverse order when a step fails, for the operations that can't share a db transaction.
+//
+//	saga := yiigo.NewSaga("place_order", yiigo.WithSagaStore(yiigo.NewDBSagaStore(db, "saga_log"))).
+//		Step("reserve_stock", reserveStock, releaseStock).
+//		Step("charge", charge, refund).
+//		Step("create_shipment", createShipment, nil)
+//
+//	err := saga.Execute(ctx, orderNo, payload)
+//
+//	// on startup
+//	saga.Resume(ctx)
+type Saga struct {
+	name    string
+	steps   []*sagaStep
+	options *sagaOptions
+}
+
+// NewSaga returns a new saga, the name identifies its executions in store.
+func NewSaga(name string, options ...SagaOption) *Saga {
+	o := new(sagaOptions)
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &Saga{
+		name:    name,
+		options: o,
+	}
+}
+
+// Step appends a step with its compensation (nil means nothing to compensate).
+func (s *Saga) Step(name string, action, compensate SagaFunc) *Saga {
+	s.steps = append(s.steps, &sagaStep{
+		name:       name,
+		action:     action,
+		compensate: compensate,
+	})
+
+	return s
+}
+
+// Execute executes the saga with payload, id identifies the execution in store, eg: the order no.
+// It returns a *SagaError if a step fails.
+func (s *Saga) Execute(ctx context.Context, id string, payload []byte) error {
+	state := &SagaState{
+		ID:      id,
+		Name:    s.name,
+		Payload: payload,
+		Status:  SagaRunning,
+	}
+
+	if err := s.save(ctx, state); err != nil {
+		return err
+	}
+
+	return s.run(ctx, state)
+}
+
+// Resume continues the unfinished executions in store, eg: on startup after a crash.
+// The step in progress when crashed is re-executed, and a failed compensation is retried.
+func (s *Saga) Resume(ctx context.Context) error {
+	if s.options.store == nil {
+		return nil
+	}
+
+	states, err := s.options.store.Pending(ctx, s.name)
+
+	if err != nil {
+		return err
+	}
+
+	var e error
+
+	for _, state := range states {
+		if err := s.run(ctx, state); err != nil && e == nil {
+			e = err
+		}
+	}
+
+	return e
+}
+
+func (s *Saga) run(ctx context.Context, state *SagaState) error {
+	var sagaErr *SagaError
+
+	if state.Status == SagaRunning {
+		for state.Step < len(s.steps) {
+			step := s.steps[state.Step]
+
+			if err := step.action(ctx, state.Payload); err != nil {
+				sagaErr = &SagaError{Step: step.name, Err: err}
+
+				state.Status = SagaCompensating
+				state.Error = err.Error()
+
+				break
+			}
+
+			state.Step++
+
+			if err := s.save(ctx, state); err != nil {
+				return err
+			}
+		}
+
+		if state.Status == SagaRunning {
+			state.Status = SagaDone
+
+			return s.save(ctx, state)
+		}
+
+		if err := s.save(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	if state.Status != SagaCompensating {
+		return nil
+	}
+
+	if sagaErr == nil {
+		sagaErr = &SagaError{Err: fmt.Errorf("%s", state.Error)}
+
+		if state.Step < len(s.steps) {
+			sagaErr.Step = s.steps[state.Step].name
+		}
+	}
+
+	for state.Step > 0 {
+		step := s.steps[state.Step-1]
+
+		if step.compensate != nil {
+			if err := step.compensate(ctx, state.Payload); err != nil {
+				sagaErr.CompensateErr = fmt.Errorf("%s: %v", step.name, err)
+
+				return sagaErr
+			}
+		}
+
+		state.Step--
+
+		if err := s.save(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	state.Status = SagaCompensated
+
+	if err := s.save(ctx, state); err != nil {
+		return err
+	}
+
+	return sagaErr
+}
+
+func (s *Saga) save(ctx context.Context, state *SagaState) error {
+	if s.options.store == nil {
+		return nil
+	}
+
+	return s.options.store.Save(ctx, state)
+}
+
+// dbSagaStore a SagaStore backed by mysql
+type dbSagaStore struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewDBSagaStore returns a SagaStore backed by the mysql table.
+//
+//	CREATE TABLE `saga_log` (
+//		`id` varchar(64) NOT NULL,
+//		`name` varchar(64) NOT NULL,
+//		`payload` blob,
+//		`step` int NOT NULL DEFAULT 0,
+//		`status` varchar(16) NOT NULL,
+//		`error` varchar(1024) NOT NULL DEFAULT '',
+//		`updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+//		PRIMARY KEY (`name`, `id`),
+//		KEY `idx_status` (`name`, `status`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+func NewDBSagaStore(db *sqlx.DB, table string) SagaStore {
+	return &dbSagaStore{
+		db:    db,
+		table: table,
+	}
+}
+
+func (s *dbSagaStore) Save(ctx context.Context, state *SagaState) error {
+	errMsg := state.Error
+
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO `%s` (`id`, `name`, `payload`, `step`, `status`, `error`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `step` = VALUES(`step`), `status` = VALUES(`status`), `error` = VALUES(`error`)", s.table),
+		state.ID, state.Name, state.Payload, state.Step, state.Status, errMsg)
+
+	return err
+}
+
+func (s *dbSagaStore) Pending(ctx context.Context, name string) ([]*SagaState, error) {
+	states := make([]*SagaState, 0)
+
+	err := s.db.SelectContext(ctx, &states, fmt.Sprintf("SELECT `id`, `name`, `payload`, `step`, `status`, `error` FROM `%s` WHERE `name` = ? AND `status` IN (?, ?)", s.table),
+		name, SagaRunning, SagaCompensating)
+
+	return states, err
+}
@@ -0,0 +1,143 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// memorySagaStore a process local SagaStore
+type memorySagaStore struct {
+	states sync.Map
+}
+
+func (s *memorySagaStore) Save(ctx context.Context, state *SagaState) error {
+	v := *state
+
+	s.states.Store(state.Name+":"+state.ID, &v)
+
+	return nil
+}
+
+func (s *memorySagaStore) Pending(ctx context.Context, name string) ([]*SagaState, error) {
+	states := make([]*SagaState, 0)
+
+	s.states.Range(func(k, v interface{}) bool {
+		state := v.(*SagaState)
+
+		if state.Name == name && (state.Status == SagaRunning || state.Status == SagaCompensating) {
+			v := *state
+			states = append(states, &v)
+		}
+
+		return true
+	})
+
+	return states, nil
+}
+
+func TestSaga(t *testing.T) {
+	var calls []string
+
+	record := func(name string, err error) SagaFunc {
+		return func(ctx context.Context, payload []byte) error {
+			calls = append(calls, name)
+
+			return err
+		}
+	}
+
+	errCharge := errors.New("insufficient balance")
+
+	tests := []struct {
+		name   string
+		saga   *Saga
+		err    error
+		status SagaStatus
+		calls  []string
+	}{
+		{
+			name: "t1",
+			saga: NewSaga("order").
+				Step("stock", record("stock", nil), record("release", nil)).
+				Step("charge", record("charge", nil), record("refund", nil)),
+			status: SagaDone,
+			calls:  []string{"stock", "charge"},
+		},
+		{
+			name: "t2",
+			saga: NewSaga("order").
+				Step("stock", record("stock", nil), record("release", nil)).
+				Step("coupon", record("coupon", nil), nil).
+				Step("charge", record("charge", errCharge), record("refund", nil)),
+			err:    &SagaError{Step: "charge", Err: errCharge},
+			status: SagaCompensated,
+			calls:  []string{"stock", "coupon", "charge", "release"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+
+			store := new(memorySagaStore)
+			tt.saga.options.store = store
+
+			err := tt.saga.Execute(context.Background(), "1", nil)
+
+			if !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Saga.Execute() error = %v, want %v", err, tt.err)
+			}
+
+			v, _ := store.states.Load("order:1")
+
+			if status := v.(*SagaState).Status; status != tt.status {
+				t.Errorf("Saga.Execute() status = %s, want %s", status, tt.status)
+			}
+
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("Saga.Execute() calls = %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestSagaResume(t *testing.T) {
+	var calls []string
+
+	record := func(name string, err error) SagaFunc {
+		return func(ctx context.Context, payload []byte) error {
+			calls = append(calls, name+":"+string(payload))
+
+			return err
+		}
+	}
+
+	store := new(memorySagaStore)
+
+	store.Save(context.Background(), &SagaState{ID: "1", Name: "order", Payload: []byte("a"), Step: 1, Status: SagaRunning})
+	store.Save(context.Background(), &SagaState{ID: "2", Name: "order", Payload: []byte("b"), Step: 1, Status: SagaCompensating, Error: "timeout"})
+
+	saga := NewSaga("order", WithSagaStore(store)).
+		Step("stock", record("stock", nil), record("release", nil)).
+		Step("charge", record("charge", nil), record("refund", nil))
+
+	if err := saga.Resume(context.Background()); err == nil {
+		t.Error("Saga.Resume() error = nil, want the error of compensated execution")
+	}
+
+	called := map[string]bool{}
+
+	for _, v := range calls {
+		called[v] = true
+	}
+
+	if !reflect.DeepEqual(called, map[string]bool{"charge:a": true, "release:b": true}) {
+		t.Errorf("Saga.Resume() calls = %v", calls)
+	}
+
+	if states, _ := store.Pending(context.Background(), "order"); len(states) != 0 {
+		t.Errorf("Saga.Resume() pending = %d, want 0", len(states))
+	}
+}
@@ -0,0 +1,212 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// idSegment a range of ids (cur, max] allocated from db.
+type idSegment struct {
+	cur  int64
+	max  int64
+	step int64
+}
+
+// segmentOptions segment id generator options
+type segmentOptions struct {
+	table     string
+	threshold float64
+}
+
+// SegmentOption configures how we set up the segment id generator
+type SegmentOption interface {
+	apply(options *segmentOptions)
+}
+
+// funcSegmentOption implements segment option
+type funcSegmentOption struct {
+	f func(options *segmentOptions)
+}
+
+func (fo *funcSegmentOption) apply(o *segmentOptions) {
+	fo.f(o)
+}
+
+func newFuncSegmentOption(f func(options *segmentOptions)) *funcSegmentOption {
+	return &funcSegmentOption{f: f}
+}
+
+// WithSegmentTable specifies the table of segments.
+func WithSegmentTable(s string) SegmentOption {
+	return newFuncSegmentOption(func(o *segmentOptions) {
+		o.table = s
+	})
+}
+
+// WithSegmentLoadThreshold specifies the used ratio of current segment, beyond which the next segment is loaded in background.
+func WithSegmentLoadThreshold(f float64) SegmentOption {
+	return newFuncSegmentOption(func(o *segmentOptions) {
+		o.threshold = f
+	})
+}
+
+// SegmentIDGenerator generates the sequential ids from the segments allocated in db (the Leaf-segment of Meituan),
+// which doesn't depend on the clock like Snowflake. Every call to db allocates `step` ids, and the segments are
+// double-buffered, so the next segment is ready before the current one runs out.
+//
+// The ids are increasing within a process, but not across processes, and the unused ids are skipped after a restart.
+//
+//	CREATE TABLE `id_segment` (
+//		`biz_tag` varchar(128) NOT NULL,
+//		`max_id` bigint NOT NULL DEFAULT 0,
+//		`step` int NOT NULL DEFAULT 1000,
+//		`updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+//		PRIMARY KEY (`biz_tag`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+type SegmentIDGenerator struct {
+	bizTag  string
+	options *segmentOptions
+	load    func(ctx context.Context) (*idSegment, error)
+	current *idSegment
+	next    *idSegment
+	loading chan struct{}
+	mutex   sync.Mutex
+}
+
+// NewSegmentIDGenerator returns a new segment id generator of bizTag, which should be a row of the segment table.
+//
+// The default `Table` is "id_segment".
+// The default `LoadThreshold` is 0.1, the same as Leaf.
+func NewSegmentIDGenerator(db *sqlx.DB, bizTag string, options ...SegmentOption) *SegmentIDGenerator {
+	o := &segmentOptions{
+		table:     "id_segment",
+		threshold: 0.1,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	g := &SegmentIDGenerator{
+		bizTag:  bizTag,
+		options: o,
+	}
+
+	g.load = func(ctx context.Context) (*idSegment, error) {
+		return loadIDSegment(ctx, db, o.table, bizTag)
+	}
+
+	return g
+}
+
+// loadIDSegment allocates the next segment of bizTag by increasing its `max_id` with `step`.
+func loadIDSegment(ctx context.Context, db *sqlx.DB, table, bizTag string) (*idSegment, error) {
+	table = driverOf(db).dialect().quote(table)
+
+	seg := new(idSegment)
+
+	err := DBTransaction(ctx, db, func(ctx context.Context, tx *sqlx.Tx) error {
+		r, err := tx.ExecContext(ctx, tx.Rebind(fmt.Sprintf("UPDATE %s SET max_id = max_id + step WHERE biz_tag = ?", table)), bizTag)
+
+		if err != nil {
+			return err
+		}
+
+		if n, err := r.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("yiigo: unknown biz_tag %q of id segment", bizTag)
+		}
+
+		return tx.QueryRowxContext(ctx, tx.Rebind(fmt.Sprintf("SELECT max_id, step FROM %s WHERE biz_tag = ?", table)), bizTag).Scan(&seg.max, &seg.step)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	seg.cur = seg.max - seg.step
+
+	return seg, nil
+}
+
+// Next returns the next id, it blocks to load a segment from db only when both of the buffers run out.
+func (g *SegmentIDGenerator) Next(ctx context.Context) (int64, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for {
+		if seg := g.current; seg != nil && seg.cur < seg.max {
+			seg.cur++
+
+			if g.next == nil && g.loading == nil && float64(seg.cur-(seg.max-seg.step)) > g.options.threshold*float64(seg.step) {
+				g.preload()
+			}
+
+			return seg.cur, nil
+		}
+
+		if g.next != nil {
+			g.current, g.next = g.next, nil
+
+			continue
+		}
+
+		if loading := g.loading; loading != nil {
+			g.mutex.Unlock()
+
+			select {
+			case <-ctx.Done():
+				g.mutex.Lock()
+
+				return 0, ctx.Err()
+			case <-loading:
+			}
+
+			g.mutex.Lock()
+
+			continue
+		}
+
+		seg, err := g.load(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		g.current = seg
+	}
+}
+
+// preload loads the next segment in background, the mutex should be held.
+// A failure is ignored, and returned by Next when the current segment runs out.
+func (g *SegmentIDGenerator) preload() {
+	loading := make(chan struct{})
+
+	g.loading = loading
+
+	SafeGo(func() {
+		var seg *idSegment
+
+		err := callSafe(func() (err error) {
+			seg, err = g.load(context.Background())
+
+			return
+		})
+
+		g.mutex.Lock()
+
+		if err == nil {
+			g.next = seg
+		}
+
+		g.loading = nil
+
+		g.mutex.Unlock()
+
+		close(loading)
+	})
+}
@@ -0,0 +1,81 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSegmentIDGenerator(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		maxID int64
+	)
+
+	g := &SegmentIDGenerator{options: &segmentOptions{threshold: 0.1}}
+
+	g.load = func(ctx context.Context) (*idSegment, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		maxID += 10
+
+		return &idSegment{cur: maxID - 10, max: maxID, step: 10}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	ids := make(chan int64, 100)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				id, err := g.Next(context.Background())
+
+				if err != nil {
+					t.Error(err)
+
+					return
+				}
+
+				ids <- id
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool)
+
+	for id := range ids {
+		if seen[id] || id < 1 || id > 110 {
+			t.Errorf("SegmentIDGenerator.Next() got duplicate or invalid id %d", id)
+		}
+
+		seen[id] = true
+	}
+
+	if len(seen) != 100 {
+		t.Errorf("SegmentIDGenerator.Next() got %d ids, want 100", len(seen))
+	}
+}
+
+func TestSegmentIDGeneratorLoadError(t *testing.T) {
+	g := &SegmentIDGenerator{options: &segmentOptions{threshold: 0.1}}
+
+	errLoad := errors.New("db down")
+
+	g.load = func(ctx context.Context) (*idSegment, error) {
+		return nil, errLoad
+	}
+
+	if _, err := g.Next(context.Background()); err != errLoad {
+		t.Errorf("SegmentIDGenerator.Next() error = %v, want %v", err, errLoad)
+	}
+}
@@ -0,0 +1,196 @@
+package yiigo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// base58Alphabet the bitcoin alphabet, which excludes `0 O I l`.
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	errBaseInvalidChar = errors.New("yiigo: invalid character to decode")
+	errBaseOverflow    = errors.New("yiigo: decoded value overflows uint64")
+	// ErrShortLinkInvalid returned when a short link code is malformed or its signature mismatches.
+	ErrShortLinkInvalid = errors.New("yiigo: invalid short link")
+)
+
+// Base62Encode encodes n into base62 (0-9A-Za-z).
+func Base62Encode(n uint64) string {
+	return baseEncode(n, base62Alphabet)
+}
+
+// Base62Decode decodes a base62 string encoded by Base62Encode.
+func Base62Decode(s string) (uint64, error) {
+	return baseDecode(s, base62Alphabet)
+}
+
+// Base58Encode encodes n into base58 (the bitcoin alphabet), which is friendly to be read and typed by humans.
+func Base58Encode(n uint64) string {
+	return baseEncode(n, base58Alphabet)
+}
+
+// Base58Decode decodes a base58 string encoded by Base58Encode.
+func Base58Decode(s string) (uint64, error) {
+	return baseDecode(s, base58Alphabet)
+}
+
+func baseEncode(n uint64, alphabet string) string {
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	base := uint64(len(alphabet))
+
+	b := make([]byte, 0, 11)
+
+	for n > 0 {
+		b = append(b, alphabet[n%base])
+		n /= base
+	}
+
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+
+	return string(b)
+}
+
+func baseDecode(s, alphabet string) (uint64, error) {
+	if s == "" {
+		return 0, errBaseInvalidChar
+	}
+
+	base := uint64(len(alphabet))
+
+	var n uint64
+
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(alphabet, s[i])
+
+		if v < 0 {
+			return 0, errBaseInvalidChar
+		}
+
+		if n > (^uint64(0)-uint64(v))/base {
+			return 0, errBaseOverflow
+		}
+
+		n = n*base + uint64(v)
+	}
+
+	return n, nil
+}
+
+// shortLinkSignLen the length of signature appended to the code.
+const shortLinkSignLen = 4
+
+// ShortLink encodes the ids into short link codes, eg: https://t.example.com/{code}.
+type ShortLink struct {
+	secret []byte
+}
+
+// NewShortLink returns a new short link encoder.
+// With a secret, the codes are signed, so the codes of the ids not issued can't be guessed by enumerating.
+func NewShortLink(secret string) *ShortLink {
+	return &ShortLink{secret: []byte(secret)}
+}
+
+// Encode returns the code of id.
+func (l *ShortLink) Encode(id uint64) string {
+	code := Base62Encode(id)
+
+	if len(l.secret) > 0 {
+		code += l.sign(id)
+	}
+
+	return code
+}
+
+// Decode returns the id of code, or ErrShortLinkInvalid.
+func (l *ShortLink) Decode(code string) (uint64, error) {
+	sig := ""
+
+	if len(l.secret) > 0 {
+		if len(code) <= shortLinkSignLen {
+			return 0, ErrShortLinkInvalid
+		}
+
+		code, sig = code[:len(code)-shortLinkSignLen], code[len(code)-shortLinkSignLen:]
+	}
+
+	id, err := Base62Decode(code)
+
+	if err != nil {
+		return 0, ErrShortLinkInvalid
+	}
+
+	// reject the non-canonical codes, eg: with leading zeros
+	if Base62Encode(id) != code {
+		return 0, ErrShortLinkInvalid
+	}
+
+	if len(l.secret) > 0 && !hmac.Equal([]byte(sig), []byte(l.sign(id))) {
+		return 0, ErrShortLinkInvalid
+	}
+
+	return id, nil
+}
+
+// sign returns the fixed length signature of id.
+func (l *ShortLink) sign(id uint64) string {
+	b := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(b, id)
+
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write(b)
+
+	sum := mac.Sum(nil)
+
+	// 4 characters of base62 (62^4 values) padded with leading zeros
+	v := binary.BigEndian.Uint32(sum) % 14776336
+
+	s := Base62Encode(uint64(v))
+
+	return strings.Repeat("0", shortLinkSignLen-len(s)) + s
+}
+
+// Handler returns a http.Handler which redirects `/{code}` to the url resolved by id,
+// it responds 404 when the code is invalid or resolve returns an empty url.
+func (l *ShortLink) Handler(resolve func(id uint64) (string, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+		id, err := l.Decode(code)
+
+		if err != nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		url, err := resolve(id)
+
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		if url == "" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		http.Redirect(w, r, url, http.StatusFound)
+	})
+}
@@ -0,0 +1,127 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBase62Encode(t *testing.T) {
+	type args struct {
+		n uint64
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{n: 0},
+			want: "0",
+		},
+		{
+			name: "t2",
+			args: args{n: 61},
+			want: "z",
+		},
+		{
+			name: "t3",
+			args: args{n: 62},
+			want: "10",
+		},
+		{
+			name: "t4",
+			args: args{n: 18446744073709551615},
+			want: "LygHa16AHYF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Base62Encode(tt.args.n)
+			if got != tt.want {
+				t.Errorf("Base62Encode() = %v, want %v", got, tt.want)
+			}
+			if n, err := Base62Decode(got); err != nil || n != tt.args.n {
+				t.Errorf("Base62Decode() = %v, %v, want %v", n, err, tt.args.n)
+			}
+		})
+	}
+}
+
+func TestBase58Decode(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name:    "t1",
+			args:    args{s: Base58Encode(123456789)},
+			want:    123456789,
+			wantErr: false,
+		},
+		{
+			name:    "t2",
+			args:    args{s: "0OIl"},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "t3",
+			args:    args{s: "zzzzzzzzzzzzzzz"},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Base58Decode(tt.args.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Base58Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Base58Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortLink(t *testing.T) {
+	l := NewShortLink("secret")
+
+	code := l.Encode(10086)
+
+	if id, err := l.Decode(code); err != nil || id != 10086 {
+		t.Errorf("ShortLink.Decode() = %v, %v, want 10086", id, err)
+	}
+
+	if _, err := l.Decode(Base62Encode(10087) + code[len(code)-shortLinkSignLen:]); err != ErrShortLinkInvalid {
+		t.Errorf("ShortLink.Decode() error = %v, want %v", err, ErrShortLinkInvalid)
+	}
+
+	h := l.Handler(func(id uint64) (string, error) {
+		return "https://example.com/items/10086", nil
+	})
+
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/s/"+code, nil))
+
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/items/10086" {
+		t.Errorf("ShortLink.Handler() = %d %s, want 302 https://example.com/items/10086", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/s/abc", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("ShortLink.Handler() = %d, want 404", w.Code)
+	}
+}
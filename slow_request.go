@@ -0,0 +1,136 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// requestTraceKey the context key of the request trace.
+type requestTraceKey struct{}
+
+// requestTrace the phases of a request, each one lasts from the end of the previous one (or the start of request).
+type requestTrace struct {
+	last   time.Time
+	phases []zap.Field
+	mutex  sync.Mutex
+}
+
+// TracePhase records a phase of the request which ends now, eg: TracePhase(ctx, "binding") after the params are bound,
+// the phase lasts from the end of the previous one (or the start of request).
+// It's a no-op if the request isn't traced by TraceSlowRequests.
+func TracePhase(ctx context.Context, name string) {
+	trace, ok := ctx.Value(requestTraceKey{}).(*requestTrace)
+
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	trace.mutex.Lock()
+	trace.phases = append(trace.phases, zap.Duration(name, now.Sub(trace.last)))
+	trace.last = now
+	trace.mutex.Unlock()
+}
+
+// slowRequestOptions slow request options
+type slowRequestOptions struct {
+	logger *zap.Logger
+}
+
+// SlowRequestOption configures how we trace the slow requests
+type SlowRequestOption interface {
+	apply(options *slowRequestOptions)
+}
+
+// funcSlowRequestOption implements slow request option
+type funcSlowRequestOption struct {
+	f func(options *slowRequestOptions)
+}
+
+func (fo *funcSlowRequestOption) apply(o *slowRequestOptions) {
+	fo.f(o)
+}
+
+func newFuncSlowRequestOption(f func(options *slowRequestOptions)) *funcSlowRequestOption {
+	return &funcSlowRequestOption{f: f}
+}
+
+// WithSlowRequestLogger specifies the logger of slow requests, defaults to yiigo.Logger.
+func WithSlowRequestLogger(l *zap.Logger) SlowRequestOption {
+	return newFuncSlowRequestOption(func(o *slowRequestOptions) {
+		o.logger = l
+	})
+}
+
+// TraceSlowRequests returns a middleware which logs a warning with the breakdown of phases (see TracePhase)
+// when a request takes longer than threshold, to pinpoint the slow layers without a full APM.
+// The time after the last phase is logged as `rest`, and the warnings can be alerted by WithLogAlert at zapcore.WarnLevel.
+//
+//	http.Handle("/orders", yiigo.TraceSlowRequests(time.Second)(orderHandler))
+//
+//	func orderHandler(w http.ResponseWriter, r *http.Request) {
+//		ctx := r.Context()
+//
+//		// bind params
+//		yiigo.TracePhase(ctx, "binding")
+//
+//		// call services
+//		yiigo.TracePhase(ctx, "service")
+//
+//		// render response
+//		yiigo.TracePhase(ctx, "render")
+//	}
+func TraceSlowRequests(threshold time.Duration, options ...SlowRequestOption) func(http.Handler) http.Handler {
+	o := new(slowRequestOptions)
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			trace := &requestTrace{last: start}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestTraceKey{}, trace)))
+
+			end := time.Now()
+
+			total := end.Sub(start)
+
+			if total <= threshold {
+				return
+			}
+
+			logger := o.logger
+
+			if logger == nil {
+				logger = Logger
+			}
+
+			if logger == nil {
+				return
+			}
+
+			trace.mutex.Lock()
+
+			fields := make([]zap.Field, 0, len(trace.phases)+4)
+
+			fields = append(fields, zap.String("method", r.Method), zap.String("url", r.URL.RequestURI()), zap.Duration("total", total))
+			fields = append(fields, trace.phases...)
+			fields = append(fields, zap.Duration("rest", end.Sub(trace.last)))
+
+			trace.mutex.Unlock()
+
+			logger.Warn("yiigo: slow request", fields...)
+		})
+	}
+}
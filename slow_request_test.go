@@ -0,0 +1,49 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceSlowRequests(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+
+	h := TraceSlowRequests(10*time.Millisecond, WithSlowRequestLogger(zap.New(core)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		TracePhase(r.Context(), "binding")
+
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		TracePhase(r.Context(), "service")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+	if logs.Len() != 0 {
+		t.Errorf("TraceSlowRequests() warnings = %d, want 0", logs.Len())
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders?slow=1", nil))
+
+	if logs.Len() != 1 {
+		t.Fatalf("TraceSlowRequests() warnings = %d, want 1", logs.Len())
+	}
+
+	fields := logs.All()[0].ContextMap()
+
+	for _, k := range []string{"total", "binding", "service", "rest"} {
+		if _, ok := fields[k]; !ok {
+			t.Errorf("TraceSlowRequests() field %s is missing", k)
+		}
+	}
+
+	if d := fields["service"].(time.Duration); d < 20*time.Millisecond {
+		t.Errorf("TraceSlowRequests() service = %v, want >= 20ms", d)
+	}
+}
@@ -0,0 +1,322 @@
+package yiigo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEEvent a server-sent event
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseOptions sse client options
+type sseOptions struct {
+	client           *HTTPClient
+	headers          map[string]string
+	lastEventID      string
+	retry            time.Duration
+	heartbeatTimeout time.Duration
+	errorHandler     func(err error)
+}
+
+// SSEOption configures how we set up the sse client
+type SSEOption interface {
+	apply(options *sseOptions)
+}
+
+// funcSSEOption implements sse option
+type funcSSEOption struct {
+	f func(options *sseOptions)
+}
+
+func (fo *funcSSEOption) apply(o *sseOptions) {
+	fo.f(o)
+}
+
+func newFuncSSEOption(f func(options *sseOptions)) *funcSSEOption {
+	return &funcSSEOption{f: f}
+}
+
+// WithSSEHTTPClient specifies the http client to sse client, the request timeout of http client is not applied to the stream.
+func WithSSEHTTPClient(c *HTTPClient) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.client = c
+	})
+}
+
+// WithSSEHeader specifies the header to sse request.
+func WithSSEHeader(key, value string) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.headers[key] = value
+	})
+}
+
+// WithSSELastEventID specifies the `Last-Event-ID` of the first connection, to resume a stream.
+func WithSSELastEventID(id string) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.lastEventID = id
+	})
+}
+
+// WithSSERetry specifies the delay before reconnecting, which can be changed by the `retry` field sent from server.
+func WithSSERetry(d time.Duration) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.retry = d
+	})
+}
+
+// WithSSEHeartbeatTimeout specifies the duration the stream can be silent (no events or comments) before reconnecting.
+func WithSSEHeartbeatTimeout(d time.Duration) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.heartbeatTimeout = d
+	})
+}
+
+// WithSSEErrorHandler specifies the handler of the connection errors, which are followed by reconnecting.
+func WithSSEErrorHandler(f func(err error)) SSEOption {
+	return newFuncSSEOption(func(o *sseOptions) {
+		o.errorHandler = f
+	})
+}
+
+// SSEClient an EventSource-style client
+type SSEClient struct {
+	url         string
+	options     *sseOptions
+	lastEventID string
+	mutex       sync.RWMutex
+}
+
+// NewSSEClient returns a new sse client of url.
+//
+// The default `Retry` is 3s.
+// The default `HeartbeatTimeout` is 60s.
+func NewSSEClient(url string, options ...SSEOption) *SSEClient {
+	o := &sseOptions{
+		client:           defaultHTTPClient,
+		headers:          make(map[string]string),
+		retry:            3 * time.Second,
+		heartbeatTimeout: 60 * time.Second,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &SSEClient{
+		url:         url,
+		options:     o,
+		lastEventID: o.lastEventID,
+	}
+}
+
+// LastEventID returns the id of the last received event, it's safe to be called from another goroutine.
+func (s *SSEClient) LastEventID() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.lastEventID
+}
+
+func (s *SSEClient) setLastEventID(id string) {
+	s.mutex.Lock()
+	s.lastEventID = id
+	s.mutex.Unlock()
+}
+
+func (s *SSEClient) retry() time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.options.retry
+}
+
+func (s *SSEClient) setRetry(d time.Duration) {
+	s.mutex.Lock()
+	s.options.retry = d
+	s.mutex.Unlock()
+}
+
+// Subscribe connects to the stream and calls `handler` for each event, it blocks until `ctx` is done
+// or the server responds 204 No Content, reconnecting with `Last-Event-ID` whenever the connection is lost.
+func (s *SSEClient) Subscribe(ctx context.Context, handler func(e *SSEEvent)) error {
+	for {
+		err := s.connect(ctx, handler)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err == errSSENoContent {
+			return nil
+		}
+
+		if err != nil && s.options.errorHandler != nil {
+			s.options.errorHandler(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retry()):
+		}
+	}
+}
+
+var (
+	// ErrSSEStreamClosed reported to the error handler when the server closes the stream, which is followed by reconnecting.
+	ErrSSEStreamClosed = errors.New("yiigo: sse stream closed")
+
+	errSSENoContent = errors.New("yiigo: sse stream closed by server (204)")
+)
+
+// scanSSELines is a split function of bufio.Scanner for the lines of event stream, which end with `\r\n`, `\n` or `\r`.
+func scanSSELines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+
+		// `\r`, which may be followed by `\n` in the next read
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	// request more data
+	return 0, nil, nil
+}
+
+func (s *SSEClient) connect(ctx context.Context, handler func(e *SSEEvent)) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	req, err := http.NewRequest("GET", s.url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for k, v := range s.options.headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	if id := s.LastEventID(); id != "" {
+		req.Header.Set("Last-Event-ID", id)
+	}
+
+	// reconnect if the stream is silent for too long
+	heartbeat := time.AfterFunc(s.options.heartbeatTimeout, cancel)
+
+	defer heartbeat.Stop()
+
+	resp, err := s.options.client.client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return errSSENoContent
+	default:
+		return httpStatusError(resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	scanner.Split(scanSSELines)
+
+	e := new(SSEEvent)
+	data := make([]string, 0)
+
+	for scanner.Scan() {
+		heartbeat.Reset(s.options.heartbeatTimeout)
+
+		line := scanner.Text()
+
+		// dispatch the event on a blank line
+		if line == "" {
+			if len(data) > 0 {
+				e.ID = s.LastEventID()
+				e.Data = strings.Join(data, "\n")
+
+				if e.Event == "" {
+					e.Event = "message"
+				}
+
+				handler(e)
+			}
+
+			e = new(SSEEvent)
+			data = data[:0]
+
+			continue
+		}
+
+		// comment
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			e.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				s.setLastEventID(value)
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				s.setRetry(time.Duration(n) * time.Millisecond)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ErrSSEStreamClosed
+}
@@ -0,0 +1,137 @@
+package yiigo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestSSEClient_Subscribe(t *testing.T) {
+	lastEventIDs := make([]string, 0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+
+		if r.Header.Get("Last-Event-ID") == "2" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		fmt.Fprint(w, ": heartbeat\n\nretry: 10\n\nid: 1\ndata: hello\ndata: world\n\nevent: update\nid: 2\ndata: {\"id\":2}\n\n")
+	}))
+
+	defer ts.Close()
+
+	events := make([]SSEEvent, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	err := NewSSEClient(ts.URL).Subscribe(ctx, func(e *SSEEvent) {
+		events = append(events, *e)
+	})
+
+	if err != nil {
+		t.Errorf("SSEClient.Subscribe() error = %v", err)
+
+		return
+	}
+
+	want := []SSEEvent{
+		{ID: "1", Event: "message", Data: "hello\nworld"},
+		{ID: "2", Event: "update", Data: `{"id":2}`},
+	}
+
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("SSEClient.Subscribe() events = %v, want %v", events, want)
+	}
+
+	if !reflect.DeepEqual(lastEventIDs, []string{"", "2"}) {
+		t.Errorf("SSEClient.Subscribe() Last-Event-IDs = %v, want [ 2]", lastEventIDs)
+	}
+}
+
+func Test_scanSSELines(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{name: "lf", data: "id: 1\ndata: a\n\n", want: []string{"id: 1", "data: a", ""}},
+		{name: "crlf", data: "id: 1\r\ndata: a\r\n\r\n", want: []string{"id: 1", "data: a", ""}},
+		{name: "cr", data: "id: 1\rdata: a\r\r", want: []string{"id: 1", "data: a", ""}},
+		{name: "mixed", data: "data: a\r\ndata: b\rdata: c\n\r\ndata: d", want: []string{"data: a", "data: b", "data: c", "", "data: d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// one byte per read, so a `\r\n` is split between reads
+			scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.data)))
+			scanner.Split(scanSSELines)
+
+			got := make([]string, 0)
+
+			for scanner.Scan() {
+				got = append(got, scanner.Text())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanSSELines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEClient_LastEventID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Last-Event-ID") != "" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for i := 1; i <= 100; i++ {
+			fmt.Fprintf(w, "id: %d\rdata: %d\r\r", i, i)
+		}
+	}))
+
+	defer ts.Close()
+
+	client := NewSSEClient(ts.URL, WithSSERetry(time.Millisecond))
+
+	done := make(chan error)
+
+	go func() {
+		done <- client.Subscribe(context.Background(), func(e *SSEEvent) {})
+	}()
+
+	// read concurrently with Subscribe, run with -race
+	for loop := true; loop; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("SSEClient.Subscribe() error = %v", err)
+			}
+
+			loop = false
+		default:
+			client.LastEventID()
+		}
+	}
+
+	if id := client.LastEventID(); id != "100" {
+		t.Errorf("SSEClient.LastEventID() = %s, want 100", id)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"hash"
 	"strings"
+	"unicode"
 )
 
 // MD5 calculate the md5 hash of a string.
@@ -111,3 +112,29 @@ func QuoteMeta(s string) string {
 
 	return buf.String()
 }
+
+// SnakeCase converts a camel case string to snake case, eg: "UserID" becomes "user_id", "CreatedAt" becomes "created_at".
+func SnakeCase(s string) string {
+	var buf bytes.Buffer
+
+	runes := []rune(s)
+	l := len(runes)
+
+	for i, ch := range runes {
+		if unicode.IsUpper(ch) {
+			// a new word starts at an upper letter which follows a lower letter or digit,
+			// or precedes a lower letter in an acronym (eg: the "I" of "HTTPId")
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (i+1 < l && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				buf.WriteRune('_')
+			}
+
+			buf.WriteRune(unicode.ToLower(ch))
+
+			continue
+		}
+
+		buf.WriteRune(ch)
+	}
+
+	return buf.String()
+}
@@ -171,3 +171,42 @@ func TestQuoteMeta(t *testing.T) {
 		})
 	}
 }
+
+func TestSnakeCase(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "t1",
+			args: args{s: "CreatedAt"},
+			want: "created_at",
+		},
+		{
+			name: "t2",
+			args: args{s: "UserID"},
+			want: "user_id",
+		},
+		{
+			name: "t3",
+			args: args{s: "HTTPStatus2Code"},
+			want: "http_status2_code",
+		},
+		{
+			name: "t4",
+			args: args{s: "name"},
+			want: "name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnakeCase(tt.args.s); got != tt.want {
+				t.Errorf("SnakeCase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// supervisorOptions supervisor options
+type supervisorOptions struct {
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	logger     *zap.Logger
+}
+
+// SupervisorOption configures how we set up the supervisor
+type SupervisorOption interface {
+	apply(options *supervisorOptions)
+}
+
+// funcSupervisorOption implements supervisor option
+type funcSupervisorOption struct {
+	f func(options *supervisorOptions)
+}
+
+func (fo *funcSupervisorOption) apply(o *supervisorOptions) {
+	fo.f(o)
+}
+
+func newFuncSupervisorOption(f func(options *supervisorOptions)) *funcSupervisorOption {
+	return &funcSupervisorOption{f: f}
+}
+
+// WithSupervisorBackoff specifies the exponential backoff of restarting, from min to max.
+func WithSupervisorBackoff(min, max time.Duration) SupervisorOption {
+	return newFuncSupervisorOption(func(o *supervisorOptions) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	})
+}
+
+// WithSupervisorLogger specifies the logger of panics and errors, defaults to yiigo.Logger.
+func WithSupervisorLogger(l *zap.Logger) SupervisorOption {
+	return newFuncSupervisorOption(func(o *supervisorOptions) {
+		o.logger = l
+	})
+}
+
+// Supervisor manages the background goroutines of an app:
+// recovers their panics, restarts the failed ones with backoff, and stops them all on shutdown.
+type Supervisor struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	options *supervisorOptions
+	wg      sync.WaitGroup
+}
+
+// NewSupervisor returns a new supervisor, the goroutines are stopped when ctx is done or Shutdown is called.
+//
+// The default `Backoff` is 1s to 1min.
+func NewSupervisor(ctx context.Context, options ...SupervisorOption) *Supervisor {
+	o := &supervisorOptions{
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Supervisor{
+		ctx:     ctx,
+		cancel:  cancel,
+		options: o,
+	}
+}
+
+// Go runs f in a goroutine once, a panic is recovered and logged.
+func (s *Supervisor) Go(name string, f func(ctx context.Context) error) {
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		if err := s.call(f); err != nil {
+			s.logError(name, err)
+		}
+	}()
+}
+
+// Supervise runs f in a goroutine, and restarts it with backoff whenever it returns an error or panics,
+// until it returns nil or the supervisor is shut down. f should return when ctx is done.
+func (s *Supervisor) Supervise(name string, f func(ctx context.Context) error) {
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		backoff := s.options.minBackoff
+
+		for {
+			start := time.Now()
+
+			err := s.call(f)
+
+			if err == nil || s.ctx.Err() != nil {
+				return
+			}
+
+			s.logError(name, err)
+
+			// it ran well for a while, restart it quickly
+			if time.Since(start) > s.options.maxBackoff {
+				backoff = s.options.minBackoff
+			}
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			if backoff *= 2; backoff > s.options.maxBackoff {
+				backoff = s.options.maxBackoff
+			}
+		}
+	}()
+}
+
+// Shutdown cancels the context of the goroutines and waits for them to return,
+// it returns ctx.Err() if ctx is done before that.
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait waits for all the goroutines to return.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// call calls f and converts the panic to an error.
+func (s *Supervisor) call(f func(ctx context.Context) error) error {
+	return callSafe(func() error {
+		return f(s.ctx)
+	})
+}
+
+func (s *Supervisor) logError(name string, err error) {
+	logger := s.options.logger
+
+	if logger == nil {
+		logger = Logger
+	}
+
+	if logger == nil {
+		return
+	}
+
+	logger.Error(fmt.Sprintf("yiigo: goroutine %s failed", name), zap.Error(err))
+}
@@ -0,0 +1,63 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSupervisor(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+
+	s := NewSupervisor(context.Background(), WithSupervisorBackoff(time.Millisecond, 10*time.Millisecond), WithSupervisorLogger(zap.New(core)))
+
+	var runs int32
+
+	running := make(chan struct{})
+
+	s.Supervise("flaky", func(ctx context.Context) error {
+		switch atomic.AddInt32(&runs, 1) {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("failed")
+		case 3:
+			close(running)
+		}
+
+		<-ctx.Done()
+
+		return nil
+	})
+
+	s.Go("once", func(ctx context.Context) error {
+		return nil
+	})
+
+	select {
+	case <-running:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supervisor.Supervise() not restarted after the panic and error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil {
+		t.Errorf("Supervisor.Shutdown() error = %v", err)
+	}
+
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Errorf("Supervisor.Supervise() runs = %d, want 3", got)
+	}
+
+	if logs.Len() != 2 {
+		t.Errorf("Supervisor.Supervise() errors logged = %d, want 2", logs.Len())
+	}
+}
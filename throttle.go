@@ -0,0 +1,343 @@
+package yiigo
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// ErrLoginLocked returned when the account or ip is locked out for too many failed logins.
+var ErrLoginLocked = errors.New("yiigo: too many failed logins, try again later")
+
+// loginFailScript counts a failure of KEYS[1] within the window, and locks KEYS[3] out when reaching the maximum.
+// The lockout doubles with the lockouts counted by KEYS[2], which is reset after a quiet period of the maximum lockout.
+// ARGV: window(ms), max failures, base lockout(ms), max lockout(ms).
+var loginFailScript = redis.NewScript(3, `
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+if n < tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+local c = redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[4])
+local d = math.min(tonumber(ARGV[3]) * math.pow(2, c - 1), tonumber(ARGV[4]))
+d = math.floor(d)
+redis.call('SET', KEYS[3], 1, 'PX', d)
+return d
+`)
+
+// throttleOptions login throttle options
+type throttleOptions struct {
+	prefix        string
+	maxFailures   int
+	maxIPFailures int
+	window        time.Duration
+	baseLockout   time.Duration
+	maxLockout    time.Duration
+}
+
+// ThrottleOption configures how we set up the login throttle
+type ThrottleOption interface {
+	apply(options *throttleOptions)
+}
+
+// funcThrottleOption implements throttle option
+type funcThrottleOption struct {
+	f func(options *throttleOptions)
+}
+
+func (fo *funcThrottleOption) apply(o *throttleOptions) {
+	fo.f(o)
+}
+
+func newFuncThrottleOption(f func(options *throttleOptions)) *funcThrottleOption {
+	return &funcThrottleOption{f: f}
+}
+
+// WithThrottlePrefix specifies the prefix of redis keys.
+func WithThrottlePrefix(s string) ThrottleOption {
+	return newFuncThrottleOption(func(o *throttleOptions) {
+		o.prefix = s
+	})
+}
+
+// WithThrottleMaxFailures specifies the maximum failures of an account and an ip within the window before locking out.
+// The ip limit should be higher, since many users may share a NAT ip.
+func WithThrottleMaxFailures(account, ip int) ThrottleOption {
+	return newFuncThrottleOption(func(o *throttleOptions) {
+		o.maxFailures = account
+		o.maxIPFailures = ip
+	})
+}
+
+// WithThrottleWindow specifies the window which failures are counted in.
+func WithThrottleWindow(d time.Duration) ThrottleOption {
+	return newFuncThrottleOption(func(o *throttleOptions) {
+		o.window = d
+	})
+}
+
+// WithThrottleLockout specifies the lockout duration, which doubles with every lockout from base up to max.
+func WithThrottleLockout(base, max time.Duration) ThrottleOption {
+	return newFuncThrottleOption(func(o *throttleOptions) {
+		o.baseLockout = base
+		o.maxLockout = max
+	})
+}
+
+// LoginThrottle tracks the failed logins per account and ip in redis, and locks them out exponentially.
+// It's used as a middleware of the login handler (see Middleware), or directly:
+//
+//	if _, err := throttle.Check(account, ip); err != nil {
+//		// yiigo.ErrLoginLocked, respond 429
+//	}
+//
+//	if !passwordOK {
+//		throttle.Fail(account, ip)
+//	} else {
+//		throttle.Succeed(account)
+//	}
+type LoginThrottle struct {
+	pool    *RedisPoolResource
+	options *throttleOptions
+}
+
+// NewLoginThrottle returns a new login throttle backed by the redis pool.
+//
+// The default `Prefix` is "login_throttle:".
+// The default `MaxFailures` is 5 per account and 20 per ip.
+// The default `Window` is 15 minutes.
+// The default `Lockout` is 1 minute to 24 hours.
+func NewLoginThrottle(pool *RedisPoolResource, options ...ThrottleOption) *LoginThrottle {
+	o := &throttleOptions{
+		prefix:        "login_throttle:",
+		maxFailures:   5,
+		maxIPFailures: 20,
+		window:        15 * time.Minute,
+		baseLockout:   time.Minute,
+		maxLockout:    24 * time.Hour,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &LoginThrottle{
+		pool:    pool,
+		options: o,
+	}
+}
+
+func (t *LoginThrottle) subjects(account, ip string) []string {
+	subjects := make([]string, 0, 2)
+
+	if account != "" {
+		subjects = append(subjects, "account:"+account)
+	}
+
+	if ip != "" {
+		subjects = append(subjects, "ip:"+ip)
+	}
+
+	return subjects
+}
+
+// Check returns ErrLoginLocked with the remaining lockout if the account or ip is locked out, empty account or ip is not checked.
+func (t *LoginThrottle) Check(account, ip string) (time.Duration, error) {
+	conn, err := t.pool.Get()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer t.pool.Put(conn)
+
+	var remaining time.Duration
+
+	for _, s := range t.subjects(account, ip) {
+		ms, err := redis.Int64(conn.Do("PTTL", t.options.prefix+"lock:"+s))
+
+		if err != nil {
+			return 0, err
+		}
+
+		if d := time.Duration(ms) * time.Millisecond; d > remaining {
+			remaining = d
+		}
+	}
+
+	if remaining > 0 {
+		return remaining, ErrLoginLocked
+	}
+
+	return 0, nil
+}
+
+// Fail records a failed login of the account and ip, it returns the lockout duration if it causes a lockout.
+func (t *LoginThrottle) Fail(account, ip string) (time.Duration, error) {
+	conn, err := t.pool.Get()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer t.pool.Put(conn)
+
+	var lockout time.Duration
+
+	for _, s := range t.subjects(account, ip) {
+		max := t.options.maxFailures
+
+		if strings.HasPrefix(s, "ip:") {
+			max = t.options.maxIPFailures
+		}
+
+		ms, err := redis.Int64(loginFailScript.Do(conn.Conn,
+			t.options.prefix+"fail:"+s,
+			t.options.prefix+"lockouts:"+s,
+			t.options.prefix+"lock:"+s,
+			int64(t.options.window/time.Millisecond),
+			max,
+			int64(t.options.baseLockout/time.Millisecond),
+			int64(t.options.maxLockout/time.Millisecond),
+		))
+
+		if err != nil {
+			return 0, err
+		}
+
+		if d := time.Duration(ms) * time.Millisecond; d > lockout {
+			lockout = d
+		}
+	}
+
+	return lockout, nil
+}
+
+// Succeed resets the failures of the account after a successful login, the lockout escalation is kept.
+func (t *LoginThrottle) Succeed(account string) error {
+	conn, err := t.pool.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer t.pool.Put(conn)
+
+	_, err = conn.Do("DEL", t.options.prefix+"fail:account:"+account)
+
+	return err
+}
+
+// Unlock unlocks the account and resets its failures and lockout escalation, eg: by an admin or after a password reset.
+func (t *LoginThrottle) Unlock(account string) error {
+	return t.unlock("account:" + account)
+}
+
+// UnlockIP unlocks the ip and resets its failures and lockout escalation.
+func (t *LoginThrottle) UnlockIP(ip string) error {
+	return t.unlock("ip:" + ip)
+}
+
+func (t *LoginThrottle) unlock(subject string) error {
+	conn, err := t.pool.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer t.pool.Put(conn)
+
+	_, err = conn.Do("DEL", t.options.prefix+"fail:"+subject, t.options.prefix+"lockouts:"+subject, t.options.prefix+"lock:"+subject)
+
+	return err
+}
+
+// throttleResponseWriter keeps the status of the login response.
+type throttleResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *throttleResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *throttleResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Middleware returns a middleware of the login handler, which responds 429 with `Retry-After` when the account or ip is locked out,
+// and records the login by the response status: 401 and 403 are failures, 2xx is a success.
+// The account is read from the request by account, and the ip is taken from `RemoteAddr`.
+//
+//	http.Handle("/login", throttle.Middleware(func(r *http.Request) string {
+//		return r.FormValue("account")
+//	})(loginHandler))
+func (t *LoginThrottle) Middleware(account func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, ip := account(r), requestIP(r)
+
+			remaining, err := t.Check(name, ip)
+
+			if err == ErrLoginLocked {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+
+				return
+			}
+
+			if err != nil {
+				logThrottleError(err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+				return
+			}
+
+			tw := &throttleResponseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(tw, r)
+
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+
+			switch {
+			case tw.status == http.StatusUnauthorized || tw.status == http.StatusForbidden:
+				_, err = t.Fail(name, ip)
+			case tw.status >= 200 && tw.status < 300 && name != "":
+				err = t.Succeed(name)
+			}
+
+			if err != nil {
+				logThrottleError(err)
+			}
+		})
+	}
+}
+
+func logThrottleError(err error) {
+	if Logger != nil {
+		Logger.Error("yiigo: login throttle", zap.Error(err))
+	}
+}
@@ -0,0 +1,222 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	type step struct {
+		action  string // fail, check, succeed, unlock, unlockip, wait
+		account string
+		ip      string
+		wait    time.Duration
+		want    time.Duration // the lockout of fail, or the remaining of check
+		locked  bool          // check returns ErrLoginLocked
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "account locked at the maximum failures",
+			steps: []step{
+				{action: "fail", account: "alice", ip: "1.1.1.1", want: 0},
+				{action: "fail", account: "alice", ip: "1.1.1.1", want: 0},
+				{action: "check", account: "alice", ip: "1.1.1.1", want: 0},
+				{action: "fail", account: "alice", ip: "1.1.1.1", want: time.Minute},
+				{action: "check", account: "alice", want: time.Minute, locked: true},
+				{action: "check", account: "bob", ip: "2.2.2.2", want: 0},
+			},
+		},
+		{
+			name: "lockout doubles up to the maximum",
+			steps: []step{
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: time.Minute},
+				{action: "wait", wait: time.Minute + time.Second},
+				{action: "check", account: "alice", want: 0},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: 2 * time.Minute},
+				{action: "wait", wait: 2*time.Minute + time.Second},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: 4 * time.Minute},
+				{action: "wait", wait: 4*time.Minute + time.Second},
+				// the escalation expired after a quiet period of the maximum lockout
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: time.Minute},
+			},
+		},
+		{
+			name: "lockout capped at the maximum",
+			steps: []step{
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: time.Minute},
+				{action: "wait", wait: time.Minute},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: 2 * time.Minute},
+				{action: "wait", wait: 2 * time.Minute},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: 4 * time.Minute},
+				// failing while locked out still counts
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: 4 * time.Minute},
+			},
+		},
+		{
+			name: "ip has a higher limit than account",
+			steps: []step{
+				{action: "fail", account: "u1", ip: "3.3.3.3"},
+				{action: "fail", account: "u2", ip: "3.3.3.3"},
+				{action: "fail", account: "u3", ip: "3.3.3.3"},
+				{action: "fail", account: "u4", ip: "3.3.3.3"},
+				{action: "check", account: "u5", ip: "3.3.3.3", want: 0},
+				{action: "fail", account: "u5", ip: "3.3.3.3", want: time.Minute},
+				{action: "check", account: "u6", ip: "3.3.3.3", want: time.Minute, locked: true},
+				{action: "check", account: "u6", ip: "4.4.4.4", want: 0},
+			},
+		},
+		{
+			name: "succeed resets the failures",
+			steps: []step{
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "succeed", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "check", account: "alice", want: 0},
+			},
+		},
+		{
+			name: "unlock resets the lockout and escalation",
+			steps: []step{
+				{action: "fail", account: "alice", ip: "5.5.5.5"},
+				{action: "fail", account: "alice", ip: "5.5.5.5"},
+				{action: "fail", account: "alice", ip: "5.5.5.5", want: time.Minute},
+				{action: "unlock", account: "alice"},
+				{action: "check", account: "alice", want: 0},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice"},
+				{action: "fail", account: "alice", want: time.Minute},
+			},
+		},
+		{
+			name: "unlock ip",
+			steps: []step{
+				{action: "fail", account: "u1", ip: "6.6.6.6"},
+				{action: "fail", account: "u2", ip: "6.6.6.6"},
+				{action: "fail", account: "u3", ip: "6.6.6.6"},
+				{action: "fail", account: "u4", ip: "6.6.6.6"},
+				{action: "fail", account: "u5", ip: "6.6.6.6", want: time.Minute},
+				{action: "unlockip", ip: "6.6.6.6"},
+				{action: "check", account: "u6", ip: "6.6.6.6", want: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr, pool, closeRedis := newTestRedis(t, "throttle")
+
+			defer closeRedis()
+
+			throttle := NewLoginThrottle(pool,
+				WithThrottleMaxFailures(3, 5),
+				WithThrottleLockout(time.Minute, 4*time.Minute),
+			)
+
+			for i, s := range tt.steps {
+				var (
+					got time.Duration
+					err error
+				)
+
+				switch s.action {
+				case "fail":
+					got, err = throttle.Fail(s.account, s.ip)
+				case "check":
+					got, err = throttle.Check(s.account, s.ip)
+
+					if s.locked {
+						if err != ErrLoginLocked {
+							t.Fatalf("step %d: LoginThrottle.Check() error = %v, want ErrLoginLocked", i, err)
+						}
+
+						err = nil
+					}
+				case "succeed":
+					err = throttle.Succeed(s.account)
+				case "unlock":
+					err = throttle.Unlock(s.account)
+				case "unlockip":
+					err = throttle.UnlockIP(s.ip)
+				case "wait":
+					mr.FastForward(s.wait)
+
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("step %d: LoginThrottle.%s() error = %v", i, s.action, err)
+				}
+
+				if got != s.want {
+					t.Errorf("step %d: LoginThrottle.%s() = %v, want %v", i, s.action, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginThrottle_Middleware(t *testing.T) {
+	_, pool, closeRedis := newTestRedis(t, "throttle_middleware")
+
+	defer closeRedis()
+
+	throttle := NewLoginThrottle(pool, WithThrottleMaxFailures(2, 10))
+
+	h := throttle.Middleware(func(r *http.Request) string {
+		return r.FormValue("account")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("password") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	tests := []struct {
+		name       string
+		target     string
+		status     int
+		retryAfter string
+	}{
+		{name: "t1", target: "/login?account=iiinsomnia&password=guess", status: http.StatusUnauthorized},
+		{name: "t2", target: "/login?account=iiinsomnia&password=secret", status: http.StatusOK},
+		{name: "t3", target: "/login?account=iiinsomnia&password=guess", status: http.StatusUnauthorized},
+		{name: "t4", target: "/login?account=iiinsomnia&password=guess", status: http.StatusUnauthorized},
+		{name: "t5", target: "/login?account=iiinsomnia&password=secret", status: http.StatusTooManyRequests, retryAfter: "60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, httptest.NewRequest("POST", tt.target, nil))
+
+			if w.Code != tt.status {
+				t.Errorf("LoginThrottle.Middleware() status = %d, want %d", w.Code, tt.status)
+			}
+
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("LoginThrottle.Middleware() Retry-After = %s, want %s", got, tt.retryAfter)
+			}
+		})
+	}
+}
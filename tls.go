@@ -0,0 +1,78 @@
+package yiigo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// ClientTLSConfig returns the tls config of http client for mutual TLS, which can be specified by WithHTTPTLSConfig.
+// The client presents the certificate of `certFile` / `keyFile`, and verifies the server by the CA of `caFile`.
+// If caFile is empty, the system roots are used; if certFile is empty, no client certificate is presented.
+func ClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	c := &tls.Config{}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.RootCAs = pool
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
+
+// ServerTLSConfig returns the tls config of http server (http.Server.TLSConfig) for mutual TLS.
+// The server presents the certificate of `certFile` / `keyFile`, and requires the clients to present
+// a certificate signed by the CA of `caFile`.
+func ServerTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := loadCertPool(caFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	return c, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(caFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("yiigo: no valid certificates in %s", caFile)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,129 @@
+package yiigo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a certificate signed by parent (self-signed if parent is nil) and returns the cert and key.
+func writeTestCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	ioutil.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return cert, key
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yiigo_tls")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca, caKey := writeTestCert(t, dir, "ca", true, nil, nil)
+	writeTestCert(t, dir, "server", false, ca, caKey)
+	writeTestCert(t, dir, "client", false, ca, caKey)
+
+	serverCfg, err := ServerTLSConfig(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+
+	ts.TLS = serverCfg
+	ts.StartTLS()
+
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "t1",
+			certFile: filepath.Join(dir, "client.crt"),
+			keyFile:  filepath.Join(dir, "client.key"),
+			want:     "client",
+			wantErr:  false,
+		},
+		{
+			name:    "t2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg, err := ClientTLSConfig(filepath.Join(dir, "ca.crt"), tt.certFile, tt.keyFile)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := NewHTTPClient(WithHTTPTLSConfig(clientCfg)).Get(ts.URL)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPClient.Get() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("HTTPClient.Get() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DeviceType indicates the device class of a user agent.
+type DeviceType string
+
+const (
+	DeviceDesktop DeviceType = "desktop"
+	DeviceMobile  DeviceType = "mobile"
+	DeviceTablet  DeviceType = "tablet"
+	DeviceBot     DeviceType = "bot"
+)
+
+// UserAgent the parsed result of a `User-Agent` header.
+type UserAgent struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	Device         DeviceType
+	Bot            bool
+}
+
+type uaRule struct {
+	name   string
+	regexp *regexp.Regexp
+}
+
+// uaBrowserRules the rules of browsers, in order of precedence (eg: Edge and WeChat have `Chrome` in their UA).
+var uaBrowserRules = []*uaRule{
+	{name: "Edge", regexp: regexp.MustCompile(`(?:Edge|Edg|EdgA|EdgiOS)/([\d.]+)`)},
+	{name: "Opera", regexp: regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{name: "WeChat", regexp: regexp.MustCompile(`MicroMessenger/([\d.]+)`)},
+	{name: "QQBrowser", regexp: regexp.MustCompile(`M?QQBrowser/([\d.]+)`)},
+	{name: "UCBrowser", regexp: regexp.MustCompile(`UC?Browser/([\d.]+)`)},
+	{name: "Samsung Internet", regexp: regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{name: "Firefox", regexp: regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{name: "Chrome", regexp: regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{name: "Safari", regexp: regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{name: "IE", regexp: regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+// uaOSRules the rules of operating systems, in order of precedence (eg: Android has `Linux` in its UA).
+var uaOSRules = []*uaRule{
+	{name: "Windows Phone", regexp: regexp.MustCompile(`Windows Phone(?: OS)? ([\d.]+)`)},
+	{name: "Windows", regexp: regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{name: "iOS", regexp: regexp.MustCompile(`(?:iPhone|iPad|iPod).*? OS ([\d_]+)`)},
+	{name: "HarmonyOS", regexp: regexp.MustCompile(`HarmonyOS(?:[ /]([\d.]+))?`)},
+	{name: "Android", regexp: regexp.MustCompile(`Android(?: ([\d.]+))?`)},
+	{name: "macOS", regexp: regexp.MustCompile(`Mac OS X(?: ([\d_.]+))?`)},
+	{name: "Chrome OS", regexp: regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{name: "Linux", regexp: regexp.MustCompile(`Linux()`)},
+}
+
+// uaWindowsVersions maps the `Windows NT` versions to the release names.
+var uaWindowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+var (
+	uaBotRegexp    = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|curl/|wget/|python-requests|go-http-client|java/|okhttp|headless|lighthouse|scrapy`)
+	uaTabletRegexp = regexp.MustCompile(`(?i)ipad|tablet|kindle|silk/|playbook`)
+	uaMobileRegexp = regexp.MustCompile(`(?i)mobi|iphone|ipod|android|windows phone|harmonyos`)
+)
+
+// ParseUserAgent parses the browser, OS and device class of a `User-Agent` header, the unknown parts are empty.
+func ParseUserAgent(ua string) *UserAgent {
+	u := &UserAgent{Device: DeviceDesktop}
+
+	if name, version, ok := matchUARules(uaBrowserRules, ua); ok {
+		u.Browser = name
+		u.BrowserVersion = version
+	}
+
+	if name, version, ok := matchUARules(uaOSRules, ua); ok {
+		u.OS = name
+		u.OSVersion = strings.Replace(version, "_", ".", -1)
+
+		if name == "Windows" {
+			if v, ok := uaWindowsVersions[version]; ok {
+				u.OSVersion = v
+			}
+		}
+	}
+
+	switch {
+	case uaBotRegexp.MatchString(ua):
+		u.Device = DeviceBot
+		u.Bot = true
+	case uaTabletRegexp.MatchString(ua), u.OS == "Android" && !strings.Contains(ua, "Mobile"):
+		u.Device = DeviceTablet
+	case uaMobileRegexp.MatchString(ua):
+		u.Device = DeviceMobile
+	}
+
+	return u
+}
+
+func matchUARules(rules []*uaRule, ua string) (string, string, bool) {
+	for _, rule := range rules {
+		if m := rule.regexp.FindStringSubmatch(ua); m != nil {
+			return rule.name, m[1], true
+		}
+	}
+
+	return "", "", false
+}
+
+// IsMobile reports whether the device is a mobile phone or tablet.
+func (u *UserAgent) IsMobile() bool {
+	return u.Device == DeviceMobile || u.Device == DeviceTablet
+}
+
+// userAgentKey the context key of the parsed user agent.
+type userAgentKey struct{}
+
+// CaptureUserAgent returns a middleware which parses the `User-Agent` header and puts it into the request context, see RequestUserAgent.
+//
+//	http.Handle("/articles", yiigo.CaptureUserAgent()(articleHandler))
+func CaptureUserAgent() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ua := ParseUserAgent(r.UserAgent())
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userAgentKey{}, ua)))
+		})
+	}
+}
+
+// RequestUserAgent returns the user agent parsed by CaptureUserAgent, it's nil if the request isn't captured.
+func RequestUserAgent(ctx context.Context) *UserAgent {
+	ua, _ := ctx.Value(userAgentKey{}).(*UserAgent)
+
+	return ua
+}
@@ -0,0 +1,79 @@
+package yiigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	type args struct {
+		ua string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *UserAgent
+	}{
+		{
+			name: "t1",
+			args: args{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/75.0.3770.80 Safari/537.36"},
+			want: &UserAgent{Browser: "Chrome", BrowserVersion: "75.0.3770.80", OS: "Windows", OSVersion: "10", Device: DeviceDesktop},
+		},
+		{
+			name: "t2",
+			args: args{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 12_3_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/7.0.4(0x17000428) NetType/WIFI Language/zh_CN"},
+			want: &UserAgent{Browser: "WeChat", BrowserVersion: "7.0.4", OS: "iOS", OSVersion: "12.3.1", Device: DeviceMobile},
+		},
+		{
+			name: "t3",
+			args: args{ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.1 Safari/605.1.15"},
+			want: &UserAgent{Browser: "Safari", BrowserVersion: "12.1.1", OS: "macOS", OSVersion: "10.14.5", Device: DeviceDesktop},
+		},
+		{
+			name: "t4",
+			args: args{ua: "Mozilla/5.0 (Linux; Android 9; SM-T835) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.157 Safari/537.36"},
+			want: &UserAgent{Browser: "Chrome", BrowserVersion: "74.0.3729.157", OS: "Android", OSVersion: "9", Device: DeviceTablet},
+		},
+		{
+			name: "t5",
+			args: args{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.169 Safari/537.36 Edg/74.1.96.24"},
+			want: &UserAgent{Browser: "Edge", BrowserVersion: "74.1.96.24", OS: "Windows", OSVersion: "10", Device: DeviceDesktop},
+		},
+		{
+			name: "t6",
+			args: args{ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+			want: &UserAgent{Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "t7",
+			args: args{ua: "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko"},
+			want: &UserAgent{Browser: "IE", BrowserVersion: "11.0", OS: "Windows", OSVersion: "7", Device: DeviceDesktop},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseUserAgent(tt.args.ua); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseUserAgent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureUserAgent(t *testing.T) {
+	var got *UserAgent
+
+	h := CaptureUserAgent()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestUserAgent(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if want := (&UserAgent{Device: DeviceBot, Bot: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("RequestUserAgent() = %+v, want %+v", got, want)
+	}
+}
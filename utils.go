@@ -1,9 +1,11 @@
 package yiigo
 
 import (
+	"context"
 	"encoding/xml"
 	"math"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -23,7 +25,65 @@ func (c CDATA) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}{string(c)}, start)
 }
 
-// Date format a local time/date and
+var (
+	// timeLocation the timezone of application
+	timeLocation = time.Local
+	// timeLocationSet reports whether the timezone of application is set, the default `loc` of mysql dsn is applied then
+	timeLocationSet   bool
+	timeLocationMutex sync.RWMutex
+)
+
+// SetTimeLocation sets the timezone of application (defaults to time.Local), which applies to Date, MyTimeEncoder,
+// the time parsing of ReadCSV, the time of log alerts and the `loc` of mysql dsn registered later (see WithDBTimeLocation).
+// It's set by `app.timezone` of env config when UseEnv, and should be called on startup otherwise.
+func SetTimeLocation(loc *time.Location) {
+	timeLocationMutex.Lock()
+	timeLocation = loc
+	timeLocationSet = true
+	timeLocationMutex.Unlock()
+}
+
+// LoadTimeLocation sets the timezone of application by name, eg: yiigo.LoadTimeLocation("Asia/Shanghai").
+func LoadTimeLocation(name string) error {
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		return err
+	}
+
+	SetTimeLocation(loc)
+
+	return nil
+}
+
+// TimeLocation returns the timezone of application.
+func TimeLocation() *time.Location {
+	timeLocationMutex.RLock()
+	defer timeLocationMutex.RUnlock()
+
+	return timeLocation
+}
+
+// appTimeLocation returns the timezone of application, ok is false if it's not set.
+func appTimeLocation() (*time.Location, bool) {
+	timeLocationMutex.RLock()
+	defer timeLocationMutex.RUnlock()
+
+	return timeLocation, timeLocationSet
+}
+
+// useEnvTimeLocation sets the timezone of application named by `app.timezone` of env config, the current one is kept if it's not set.
+func useEnvTimeLocation() error {
+	name := Env.String("app.timezone")
+
+	if name == "" {
+		return nil
+	}
+
+	return LoadTimeLocation(name)
+}
+
+// Date format a local time/date (in the timezone of application) and
 // returns a string formatted according to the given format string using the given timestamp of int64.
 // The default format is: 2006-01-02 15:04:05.
 func Date(timestamp int64, format ...string) string {
@@ -33,7 +93,7 @@ func Date(timestamp int64, format ...string) string {
 		layout = format[0]
 	}
 
-	date := time.Unix(timestamp, 0).Format(layout)
+	date := time.Unix(timestamp, 0).In(TimeLocation()).Format(layout)
 
 	return date
 }
@@ -57,3 +117,19 @@ func Long2IP(ip uint32) string {
 
 	return net.IPv4(byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip)).String()
 }
+
+// closeWithContext calls close and waits for it to return until `ctx` is done.
+func closeWithContext(ctx context.Context, close func() error) error {
+	done := make(chan error, 1)
+
+	SafeGo(func() {
+		done <- callSafe(close)
+	})
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
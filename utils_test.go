@@ -1,6 +1,9 @@
 package yiigo
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDate(t *testing.T) {
 	type args struct {
@@ -77,3 +80,32 @@ func TestLong2IP(t *testing.T) {
 		})
 	}
 }
+
+func TestUseEnvTimeLocation(t *testing.T) {
+	e := Env
+
+	defer func() {
+		Env = e
+
+		timeLocationMutex.Lock()
+		timeLocation = time.Local
+		timeLocationSet = false
+		timeLocationMutex.Unlock()
+	}()
+
+	if _, ok := appTimeLocation(); ok {
+		t.Fatal("appTimeLocation() is set, want not set before UseEnvBytes")
+	}
+
+	if err := UseEnvBytes([]byte("[app]\ntimezone = \"Asia/Shanghai\"")); err != nil {
+		t.Fatal(err)
+	}
+
+	if loc, ok := appTimeLocation(); !ok || loc.String() != "Asia/Shanghai" {
+		t.Errorf("appTimeLocation() got = %v, %v, want Asia/Shanghai, true", loc, ok)
+	}
+
+	if err := UseEnvBytes([]byte("[app]\ntimezone = \"Mars/Olympus\"")); err == nil {
+		t.Error("UseEnvBytes() error = nil, want an unknown timezone error")
+	}
+}
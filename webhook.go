@@ -0,0 +1,239 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxRequestBody the default maximum bytes of the request body read by ReadRequestBody and CaptureRequestBody.
+const defaultMaxRequestBody = 1 << 20
+
+var (
+	// ErrWebhookSignature returned when the webhook signature is missing or mismatched.
+	ErrWebhookSignature = errors.New("yiigo: invalid webhook signature")
+	// ErrWebhookTimestamp returned when the webhook timestamp is invalid or out of the tolerance.
+	ErrWebhookTimestamp = errors.New("yiigo: webhook timestamp out of tolerance")
+	// ErrRequestBodyTooLarge returned when the request body exceeds the maximum bytes.
+	ErrRequestBodyTooLarge = errors.New("yiigo: request body too large")
+)
+
+// requestBodyKey the context key of the raw request body captured by CaptureRequestBody.
+type requestBodyKey struct{}
+
+// ReadRequestBody reads the raw body of an inbound request and restores it,
+// so the body can be verified first and then bound by the framework (eg: gin `c.ShouldBindJSON`) as usual.
+// It returns ErrRequestBodyTooLarge if the body exceeds maxBytes.
+//
+// The default `maxBytes` is 1MB if maxBytes <= 0.
+func ReadRequestBody(r *http.Request, maxBytes int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return []byte{}, nil
+	}
+
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxRequestBody
+	}
+
+	b, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxBytes))
+
+	r.Body.Close()
+
+	if err != nil {
+		if int64(len(b)) >= maxBytes {
+			return nil, ErrRequestBodyTooLarge
+		}
+
+		return nil, err
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	return b, nil
+}
+
+// CaptureRequestBody returns a middleware which reads the raw body of requests (see ReadRequestBody) into the request context,
+// so the webhook handlers can get it by RequestBody to verify the signature, and bind the restored body as usual.
+// A body exceeding maxBytes is responded 413.
+//
+//	http.Handle("/webhooks/github", yiigo.CaptureRequestBody(0)(githubHandler))
+//
+// The default `maxBytes` is 1MB if maxBytes <= 0.
+func CaptureRequestBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := ReadRequestBody(r, maxBytes)
+
+			if err != nil {
+				status := http.StatusBadRequest
+
+				if err == ErrRequestBodyTooLarge {
+					status = http.StatusRequestEntityTooLarge
+				}
+
+				http.Error(w, http.StatusText(status), status)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestBodyKey{}, b)))
+		})
+	}
+}
+
+// RequestBody returns the raw request body captured by CaptureRequestBody, returns nil if not captured.
+func RequestBody(ctx context.Context) []byte {
+	b, _ := ctx.Value(requestBodyKey{}).([]byte)
+
+	return b
+}
+
+// VerifyGitHubSignature verifies the signature of a GitHub webhook.
+// param signature expects the header `X-Hub-Signature-256` (sha256=...) or the legacy `X-Hub-Signature` (sha1=...).
+func VerifyGitHubSignature(body []byte, signature, secret string) error {
+	var h func() hash.Hash
+
+	switch {
+	case strings.HasPrefix(signature, "sha256="):
+		h = sha256.New
+	case strings.HasPrefix(signature, "sha1="):
+		h = sha1.New
+	default:
+		return ErrWebhookSignature
+	}
+
+	sig, err := hex.DecodeString(signature[strings.Index(signature, "=")+1:])
+
+	if err != nil {
+		return ErrWebhookSignature
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrWebhookSignature
+	}
+
+	return nil
+}
+
+// VerifyStripeSignature verifies the signature of a Stripe webhook.
+// param header expects the header `Stripe-Signature`, eg: "t=1492774577,v1=5257a869...".
+// param tolerance is the maximum difference allowed between the signed timestamp and now, 0 means no check.
+func VerifyStripeSignature(body []byte, header, secret string, tolerance time.Duration) error {
+	timestamp := ""
+	signatures := make([]string, 0, 1)
+
+	for _, v := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(v), "=", 2)
+
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrWebhookSignature
+	}
+
+	if err := checkWebhookTimestamp(timestamp, tolerance); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	expected := mac.Sum(nil)
+
+	for _, v := range signatures {
+		if sig, err := hex.DecodeString(v); err == nil && hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+
+	return ErrWebhookSignature
+}
+
+// VerifyWeChatSignature verifies the signature of a WeChat (Official Accounts) server message,
+// which is the SHA1 of the sorted and concatenated `token`, `timestamp` and `nonce`.
+// param tolerance is the maximum difference allowed between the timestamp and now, 0 means no check.
+func VerifyWeChatSignature(token, signature, timestamp, nonce string, tolerance time.Duration) error {
+	if err := checkWebhookTimestamp(timestamp, tolerance); err != nil {
+		return err
+	}
+
+	strs := []string{token, timestamp, nonce}
+
+	sort.Strings(strs)
+
+	if subtle.ConstantTimeCompare([]byte(SHA1(strings.Join(strs, ""))), []byte(strings.ToLower(signature))) != 1 {
+		return ErrWebhookSignature
+	}
+
+	return nil
+}
+
+// checkWebhookTimestamp checks the unix timestamp (in seconds) is within the tolerance of now.
+func checkWebhookTimestamp(timestamp string, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		return nil
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return ErrWebhookTimestamp
+	}
+
+	if math.Abs(float64(time.Now().Unix()-t)) > tolerance.Seconds() {
+		return ErrWebhookTimestamp
+	}
+
+	return nil
+}
+
+// VerifyDingTalkSignature verifies the signature of a DingTalk outgoing robot callback.
+// param timestamp and signature expect the headers `timestamp` (in milliseconds) and `sign`.
+// param tolerance is the maximum difference allowed between the timestamp and now, 0 means no check (DingTalk recommends 1 hour).
+func VerifyDingTalkSignature(timestamp, signature, secret string, tolerance time.Duration) error {
+	if tolerance > 0 {
+		ms, err := strconv.ParseInt(timestamp, 10, 64)
+
+		if err != nil {
+			return ErrWebhookTimestamp
+		}
+
+		if math.Abs(float64(time.Now().UnixNano()/int64(time.Millisecond)-ms)) > float64(tolerance/time.Millisecond) {
+			return ErrWebhookTimestamp
+		}
+	}
+
+	if !hmac.Equal([]byte(dingTalkSign(timestamp, secret)), []byte(signature)) {
+		return ErrWebhookSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package yiigo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureRequestBody(t *testing.T) {
+	var captured, bound []byte
+
+	h := CaptureRequestBody(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = RequestBody(r.Context())
+		bound, _ = ioutil.ReadAll(r.Body)
+	}))
+
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"id":1}`)))
+
+	if w.Code != http.StatusOK || string(captured) != `{"id":1}` || string(bound) != `{"id":1}` {
+		t.Errorf("CaptureRequestBody() status = %d, captured = %s, bound = %s", w.Code, captured, bound)
+	}
+
+	w = httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"name":"IIInsomnia"}`)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("CaptureRequestBody() status = %d, want 413", w.Code)
+	}
+}
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	type args struct {
+		body      []byte
+		signature string
+		secret    string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "t1",
+			args: args{
+				body:      []byte(`{"action":"opened"}`),
+				signature: "sha256=d42142b53efbc7cf5cd20b6e074eb33707e0de3b368f698e6d6f6c824ffb8d37",
+				secret:    "secret",
+			},
+			wantErr: false,
+		},
+		{
+			name: "t2",
+			args: args{
+				body:      []byte(`{"action":"closed"}`),
+				signature: "sha256=d42142b53efbc7cf5cd20b6e074eb33707e0de3b368f698e6d6f6c824ffb8d37",
+				secret:    "secret",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyGitHubSignature(tt.args.body, tt.args.signature, tt.args.secret); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyGitHubSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	type args struct {
+		body      []byte
+		header    string
+		secret    string
+		tolerance time.Duration
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "t1",
+			args: args{
+				body:   []byte(`{"id":"evt_1"}`),
+				header: "t=1492774577,v1=7656fc2882a7ca0a651666b36bf2f2f22ee204f54f608f227498a2419f2890b2,v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39",
+				secret: "whsec_test",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "t2",
+			args: args{
+				body:      []byte(`{"id":"evt_1"}`),
+				header:    "t=1492774577,v1=7656fc2882a7ca0a651666b36bf2f2f22ee204f54f608f227498a2419f2890b2",
+				secret:    "whsec_test",
+				tolerance: 5 * time.Minute,
+			},
+			wantErr: ErrWebhookTimestamp,
+		},
+		{
+			name: "t3",
+			args: args{
+				body:   []byte(`{"id":"evt_2"}`),
+				header: "t=1492774577,v1=7656fc2882a7ca0a651666b36bf2f2f22ee204f54f608f227498a2419f2890b2",
+				secret: "whsec_test",
+			},
+			wantErr: ErrWebhookSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyStripeSignature(tt.args.body, tt.args.header, tt.args.secret, tt.args.tolerance); err != tt.wantErr {
+				t.Errorf("VerifyStripeSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWeChatSignature(t *testing.T) {
+	type args struct {
+		token     string
+		signature string
+		timestamp string
+		nonce     string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "t1",
+			args: args{
+				token:     "token",
+				signature: "e12cb21dde10d6995f3e79d8577bc9ba78a0b12e",
+				timestamp: "1492774577",
+				nonce:     "nonce",
+			},
+			wantErr: false,
+		},
+		{
+			name: "t2",
+			args: args{
+				token:     "token",
+				signature: "e12cb21dde10d6995f3e79d8577bc9ba78a0b12e",
+				timestamp: "1492774578",
+				nonce:     "nonce",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyWeChatSignature(tt.args.token, tt.args.signature, tt.args.timestamp, tt.args.nonce, 0); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWeChatSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyDingTalkSignature(t *testing.T) {
+	type args struct {
+		timestamp string
+		signature string
+		secret    string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "t1",
+			args: args{
+				timestamp: "1577836800000",
+				signature: "bm0ywHJH8t/pHkwxe/l85nFbcTSuRgIRBEj55yQE/j4=",
+				secret:    "secret",
+			},
+			wantErr: false,
+		},
+		{
+			name: "t2",
+			args: args{
+				timestamp: "1577836800001",
+				signature: "bm0ywHJH8t/pHkwxe/l85nFbcTSuRgIRBEj55yQE/j4=",
+				secret:    "secret",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyDingTalkSignature(tt.args.timestamp, tt.args.signature, tt.args.secret, 0); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyDingTalkSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,449 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrWSSendQueueFull returned when the send queue of websocket client is full.
+var ErrWSSendQueueFull = errors.New("yiigo: websocket send queue is full")
+
+// wsOptions websocket client options
+type wsOptions struct {
+	origin       string
+	headers      http.Header
+	tlsConfig    *tls.Config
+	dialTimeout  time.Duration
+	pingInterval time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	queueSize    int
+	onConnect    func(c *WSClient)
+	onError      func(err error)
+}
+
+// WSOption configures how we set up the websocket client
+type WSOption interface {
+	apply(options *wsOptions)
+}
+
+// funcWSOption implements websocket option
+type funcWSOption struct {
+	f func(options *wsOptions)
+}
+
+func (fo *funcWSOption) apply(o *wsOptions) {
+	fo.f(o)
+}
+
+func newFuncWSOption(f func(options *wsOptions)) *funcWSOption {
+	return &funcWSOption{f: f}
+}
+
+// WithWSOrigin specifies the `Origin` to websocket handshake, defaults to the http(s) url of server.
+func WithWSOrigin(s string) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.origin = s
+	})
+}
+
+// WithWSHeader specifies the header to websocket handshake.
+func WithWSHeader(key, value string) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.headers.Set(key, value)
+	})
+}
+
+// WithWSTLSConfig specifies the tls config to `wss` connection.
+func WithWSTLSConfig(c *tls.Config) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.tlsConfig = c
+	})
+}
+
+// WithWSDialTimeout specifies the timeout of dialing and handshake.
+func WithWSDialTimeout(d time.Duration) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.dialTimeout = d
+	})
+}
+
+// WithWSPingInterval specifies the interval of sending ping frames.
+func WithWSPingInterval(d time.Duration) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.pingInterval = d
+	})
+}
+
+// WithWSReadTimeout specifies the duration the connection can receive nothing (including pongs) before reconnecting.
+// It should be greater than `PingInterval`.
+func WithWSReadTimeout(d time.Duration) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.readTimeout = d
+	})
+}
+
+// WithWSWriteTimeout specifies the timeout of writing a message.
+func WithWSWriteTimeout(d time.Duration) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.writeTimeout = d
+	})
+}
+
+// WithWSReconnectBackoff specifies the exponential backoff of reconnecting, from min to max.
+func WithWSReconnectBackoff(min, max time.Duration) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	})
+}
+
+// WithWSSendQueueSize specifies the size of send queue, the queued messages are kept across reconnecting.
+func WithWSSendQueueSize(n int) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.queueSize = n
+	})
+}
+
+// WithWSOnConnect specifies the callback after each (re)connecting, eg: to subscribe the channels.
+func WithWSOnConnect(f func(c *WSClient)) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.onConnect = f
+	})
+}
+
+// WithWSErrorHandler specifies the handler of the connection errors, which are followed by reconnecting.
+func WithWSErrorHandler(f func(err error)) WSOption {
+	return newFuncWSOption(func(o *wsOptions) {
+		o.onError = f
+	})
+}
+
+// wsMessageType the `type` field of a JSON message
+type wsMessageType struct {
+	Type string `json:"type"`
+}
+
+// WSClient a websocket client with auto-reconnect, ping/pong and send queue.
+//
+// The JSON messages like `{"type": "ticker", ...}` are dispatched to the handler of its `type`,
+// the others are dispatched to the default handler.
+type WSClient struct {
+	url      string
+	options  *wsOptions
+	queue    chan []byte
+	handlers map[string]func(msg []byte)
+	fallback func(msg []byte)
+	mutex    sync.RWMutex
+}
+
+// NewWSClient returns a new websocket client of url (ws:// or wss://).
+//
+// The default `DialTimeout` is 10s.
+// The default `PingInterval` is 30s.
+// The default `ReadTimeout` is 60s.
+// The default `WriteTimeout` is 10s.
+// The default `ReconnectBackoff` is 1s to 30s.
+// The default `SendQueueSize` is 256.
+func NewWSClient(rawurl string, options ...WSOption) *WSClient {
+	o := &wsOptions{
+		headers:      make(http.Header),
+		dialTimeout:  10 * time.Second,
+		pingInterval: 30 * time.Second,
+		readTimeout:  60 * time.Second,
+		writeTimeout: 10 * time.Second,
+		minBackoff:   time.Second,
+		maxBackoff:   30 * time.Second,
+		queueSize:    256,
+	}
+
+	if len(options) > 0 {
+		for _, option := range options {
+			option.apply(o)
+		}
+	}
+
+	return &WSClient{
+		url:      rawurl,
+		options:  o,
+		queue:    make(chan []byte, o.queueSize),
+		handlers: make(map[string]func(msg []byte)),
+	}
+}
+
+// Handle registers the handler of JSON messages with the `type`.
+func (c *WSClient) Handle(msgType string, handler func(msg []byte)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.handlers[msgType] = handler
+}
+
+// HandleDefault registers the handler of messages which have no registered type handler.
+func (c *WSClient) HandleDefault(handler func(msg []byte)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.fallback = handler
+}
+
+// Send queues a text message, it returns ErrWSSendQueueFull instead of blocking when the queue is full.
+func (c *WSClient) Send(msg []byte) error {
+	select {
+	case c.queue <- msg:
+		return nil
+	default:
+		return ErrWSSendQueueFull
+	}
+}
+
+// SendJSON queues the JSON encoding of v, see Send.
+func (c *WSClient) SendJSON(v interface{}) error {
+	b, err := jsonCodec.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Send(b)
+}
+
+// Run connects to the server and dispatches the messages, it blocks until `ctx` is done,
+// reconnecting with backoff whenever the connection is lost.
+func (c *WSClient) Run(ctx context.Context) error {
+	backoff := c.options.minBackoff
+
+	for {
+		connected, err := c.serve(ctx)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != nil && c.options.onError != nil {
+			c.options.onError(err)
+		}
+
+		if connected {
+			backoff = c.options.minBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > c.options.maxBackoff {
+			backoff = c.options.maxBackoff
+		}
+	}
+}
+
+// serve runs a connection until it fails, returns whether the connection has been established.
+func (c *WSClient) serve(ctx context.Context) (bool, error) {
+	ws, err := c.dial()
+
+	if err != nil {
+		return false, err
+	}
+
+	defer ws.Close()
+
+	if c.options.onConnect != nil {
+		c.options.onConnect(c)
+	}
+
+	errs := make(chan error, 2)
+
+	done := make(chan struct{})
+
+	defer close(done)
+
+	SafeGo(func() {
+		errs <- callSafe(func() error { return c.readLoop(ws) })
+	})
+
+	SafeGo(func() {
+		errs <- callSafe(func() error { return c.writeLoop(ws, done) })
+	})
+
+	select {
+	case <-ctx.Done():
+		return true, ctx.Err()
+	case err := <-errs:
+		return true, err
+	}
+}
+
+func (c *WSClient) dial() (*websocket.Conn, error) {
+	u, err := url.Parse(c.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	origin := c.options.origin
+
+	if origin == "" {
+		scheme := "http"
+
+		if u.Scheme == "wss" {
+			scheme = "https"
+		}
+
+		origin = scheme + "://" + u.Host
+	}
+
+	config, err := websocket.NewConfig(c.url, origin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.Header = c.options.headers
+	config.TlsConfig = c.options.tlsConfig
+
+	addr := u.Host
+
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: c.options.dialTimeout}
+
+	var conn net.Conn
+
+	if u.Scheme == "wss" {
+		tlsConfig := c.options.tlsConfig
+
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = u.Hostname()
+		}
+
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.options.dialTimeout))
+
+	ws, err := websocket.NewClient(config, &wsActivityConn{Conn: conn, timeout: c.options.readTimeout})
+
+	if err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	conn.SetReadDeadline(time.Now().Add(c.options.readTimeout))
+
+	return ws, nil
+}
+
+func (c *WSClient) readLoop(ws *websocket.Conn) error {
+	for {
+		var msg []byte
+
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return err
+		}
+
+		c.dispatch(msg)
+	}
+}
+
+func (c *WSClient) dispatch(msg []byte) {
+	t := new(wsMessageType)
+
+	jsonCodec.Unmarshal(msg, t)
+
+	c.mutex.RLock()
+
+	h, ok := c.handlers[t.Type]
+
+	if !ok || t.Type == "" {
+		h = c.fallback
+	}
+
+	c.mutex.RUnlock()
+
+	if h != nil {
+		h(msg)
+	}
+}
+
+// writeLoop writes the queued messages and the pings, ws is only written here,
+// since the frame type of websocket.Conn is shared by writes.
+func (c *WSClient) writeLoop(ws *websocket.Conn, done chan struct{}) error {
+	ticker := time.NewTicker(c.options.pingInterval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case msg := <-c.queue:
+			ws.SetWriteDeadline(time.Now().Add(c.options.writeTimeout))
+
+			ws.PayloadType = websocket.TextFrame
+
+			if _, err := ws.Write(msg); err != nil {
+				// requeue the message for the next connection
+				c.Send(msg)
+
+				return err
+			}
+		case <-ticker.C:
+			ws.SetWriteDeadline(time.Now().Add(c.options.writeTimeout))
+
+			ws.PayloadType = websocket.PingFrame
+
+			if _, err := ws.Write([]byte{}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// wsActivityConn extends the read deadline whenever data (including pongs) is received,
+// so a silent connection times out after `timeout`.
+type wsActivityConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *wsActivityConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	if n > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+
+	return n, err
+}
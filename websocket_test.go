@@ -0,0 +1,77 @@
+package yiigo
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWSClient_Run(t *testing.T) {
+	var conns int32
+
+	ts := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		// drop the first connection to force reconnecting
+		if atomic.AddInt32(&conns, 1) == 1 {
+			ws.Close()
+
+			return
+		}
+
+		var msg string
+
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		websocket.Message.Send(ws, `{"type":"echo","data":`+msg+`}`)
+		websocket.Message.Send(ws, "plain")
+
+		websocket.Message.Receive(ws, &msg)
+	}))
+
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	var echo, plain string
+
+	c := NewWSClient(strings.Replace(ts.URL, "http://", "ws://", 1),
+		WithWSReconnectBackoff(10*time.Millisecond, 100*time.Millisecond),
+		WithWSOnConnect(func(c *WSClient) {
+			c.SendJSON(X{"id": 1})
+		}),
+	)
+
+	c.Handle("echo", func(msg []byte) {
+		echo = string(msg)
+	})
+
+	c.HandleDefault(func(msg []byte) {
+		plain = string(msg)
+
+		cancel()
+	})
+
+	if err := c.Run(ctx); err != context.Canceled {
+		t.Errorf("WSClient.Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	if want := `{"type":"echo","data":{"id":1}}`; echo != want {
+		t.Errorf("WSClient.Run() echo = %v, want %v", echo, want)
+	}
+
+	if plain != "plain" {
+		t.Errorf("WSClient.Run() plain = %v, want plain", plain)
+	}
+
+	if n := atomic.LoadInt32(&conns); n != 2 {
+		t.Errorf("WSClient.Run() connections = %d, want 2", n)
+	}
+}